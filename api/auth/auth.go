@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/middleware"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username" form:"username" binding:"required"`
+	Password string `json:"password" form:"password" binding:"required"`
+}
+
+// Login 用户名密码登录，签发 JWT
+// @Summary 登录
+// @Description 校验用户名密码并签发 JWT（HS256，有效期 auth.jwt_expiration 秒），后续请求携带 Authorization: Bearer <token>
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body LoginRequest true "登录信息"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/v1/auth/login [post]
+func Login(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	cfg := getConfig(c)
+	if cfg == nil || cfg.Auth.JWTSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "auth.jwt_secret is not configured"})
+		return
+	}
+	var req LoginRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	var user entity.User
+	if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "invalid username or password"})
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "invalid username or password"})
+		return
+	}
+	if user.DisabledAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "user disabled"})
+		return
+	}
+	ttl := time.Duration(cfg.Auth.JWTExpiration) * time.Second
+	token, err := middleware.IssueToken(cfg.Auth.JWTSecret, ttl, middleware.Identity{
+		UserID:   user.ID,
+		Username: user.Username,
+		Scopes:   middleware.ParseScopes(user.Scopes),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("issue token error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": gin.H{
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_in": cfg.Auth.JWTExpiration,
+		"user":       user,
+	}})
+}
+
+// Me 返回当前调用方身份
+// @Summary 当前用户
+// @Description 返回当前调用方的身份与权限范围；对应用户存在时一并返回用户信息
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/me [get]
+func Me(c *gin.Context) {
+	id, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "not authenticated"})
+		return
+	}
+	data := gin.H{"subject": id.Subject, "user_id": id.UserID, "username": id.Username, "scopes": id.Scopes}
+	if db, ok := c.Get("db"); ok && id.UserID != 0 {
+		var user entity.User
+		if err := db.(*gorm.DB).First(&user, "id = ?", id.UserID).Error; err == nil {
+			data["user"] = user
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": data})
+}
+
+func getDB(c *gin.Context) (*gorm.DB, bool) {
+	dbI, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+		return nil, false
+	}
+	return dbI.(*gorm.DB), true
+}
+
+func getConfig(c *gin.Context) *config.Config {
+	if v, ok := c.Get("config"); ok {
+		if cfg, ok := v.(*config.Config); ok {
+			return cfg
+		}
+	}
+	return nil
+}
+
+// isAdmin 判断当前调用方是否拥有 admin 权限范围；无身份时返回 false
+func isAdmin(c *gin.Context) bool {
+	id, ok := middleware.CurrentIdentity(c)
+	return ok && id.IsAdmin()
+}
+
+// requireAdmin 非管理员返回 403
+func requireAdmin(c *gin.Context) bool {
+	if isAdmin(c) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "admin scope required"})
+	return false
+}
+
+// isSelf 判断 userID 是否为当前调用方
+func isSelf(c *gin.Context, userID uint64) bool {
+	id, ok := middleware.CurrentIdentity(c)
+	return ok && id.UserID != 0 && id.UserID == userID
+}
+
+// normalizeScopes 校验并去重权限范围
+func normalizeScopes(scopes []string) (string, error) {
+	seen := map[string]bool{}
+	list := make([]string, 0, len(scopes))
+	for _, raw := range scopes {
+		for _, s := range middleware.ParseScopes(raw) {
+			s = strings.ToLower(s)
+			if !middleware.ValidScope(s) {
+				return "", fmt.Errorf("invalid scope %q, expected read, write or admin", s)
+			}
+			if !seen[s] {
+				seen[s] = true
+				list = append(list, s)
+			}
+		}
+	}
+	return strings.Join(list, ","), nil
+}
+
+func parseID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "invalid id"})
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,27 @@
+package auth
+
+import "github.com/gin-gonic/gin"
+
+// RegisterPublicRoutes 注册无需认证的路由（登录），由 /api/v1 分组传入
+func RegisterPublicRoutes(v1 *gin.RouterGroup) {
+    v1.POST("/auth/login", Login)
+}
+
+// RegisterRoutes 注册用户与 API Key 管理路由，由已挂载认证中间件的 /api/v1 分组传入
+func RegisterRoutes(v1 *gin.RouterGroup) {
+    auth := v1.Group("/auth")
+    {
+        auth.GET("/me", Me)
+        // 用户管理（管理员）
+        auth.POST("/users", CreateUser)
+        auth.GET("/users", ListUsers)
+        auth.GET("/users/:id", GetUser)
+        auth.PATCH("/users/:id", UpdateUser)
+        auth.DELETE("/users/:id", DeleteUser)
+        auth.GET("/users/:id/usage", GetUserUsage)
+        // API Key 管理：明文仅在创建时返回一次，删除即吊销
+        auth.POST("/keys", CreateApiKey)
+        auth.GET("/keys", ListApiKeys)
+        auth.DELETE("/keys/:id", RevokeApiKey)
+    }
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binhy/go-template/middleware"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyPrefix 生成的 API Key 统一前缀，便于在日志与密钥扫描中识别
+const apiKeyPrefix = "gtk_"
+
+// CreateApiKeyRequest 创建 API Key 请求
+type CreateApiKeyRequest struct {
+	Name      string   `json:"name" form:"name" binding:"required"`
+	Scopes    []string `json:"scopes" form:"scopes"`
+	ExpiresIn int64    `json:"expires_in" form:"expires_in"` // 有效期（秒），0 表示永不过期
+	UserID    uint64   `json:"user_id" form:"user_id"`       // 仅管理员可为其他用户创建
+}
+
+// CreateApiKey 创建 API Key
+// @Summary 创建 API Key
+// @Description 为当前用户（管理员可通过 user_id 指定用户）创建 API Key；scopes 不能超出所属用户与调用方（当前 JWT / API Key）的权限范围，缺省为两者的交集。明文 key 仅在本次响应中返回
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body CreateApiKeyRequest true "API Key 信息"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/keys [post]
+func CreateApiKey(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	var req CreateApiKeyRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if req.ExpiresIn < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "expires_in must be >= 0"})
+		return
+	}
+	userID := req.UserID
+	if id, ok := middleware.CurrentIdentity(c); ok && userID == 0 {
+		userID = id.UserID
+	}
+	if userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "user_id is required"})
+		return
+	}
+	if !isSelf(c, userID) && !requireAdmin(c) {
+		return
+	}
+	// isSelf / requireAdmin 均要求已认证，此处必有身份
+	caller, _ := middleware.CurrentIdentity(c)
+	var user entity.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "user not found"})
+		return
+	}
+	// 新 Key 的权限范围须同时在所属用户与调用方的权限范围之内，避免低权限凭据为自己签发高权限 Key
+	owner := &middleware.Identity{Scopes: middleware.ParseScopes(user.Scopes)}
+	var scopes string
+	if len(req.Scopes) > 0 {
+		normalized, err := normalizeScopes(req.Scopes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+			return
+		}
+		for _, s := range middleware.ParseScopes(normalized) {
+			if !owner.HasScope(s) {
+				c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("scope %q exceeds user scopes", s)})
+				return
+			}
+			if !caller.Grants(s) {
+				c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": fmt.Sprintf("scope %q exceeds caller scopes", s)})
+				return
+			}
+		}
+		scopes = normalized
+	} else {
+		granted := make([]string, 0, len(owner.Scopes))
+		for _, s := range owner.Scopes {
+			if caller.Grants(s) {
+				granted = append(granted, s)
+			}
+		}
+		scopes = strings.Join(granted, ",")
+	}
+	if scopes == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "api key must have at least one scope"})
+		return
+	}
+	plain, err := generateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("generate key error: %v", err)})
+		return
+	}
+	key := &entity.ApiKey{
+		UserID:  user.ID,
+		Name:    req.Name,
+		Prefix:  plain[:len(apiKeyPrefix)+8],
+		KeyHash: middleware.HashAPIKey(plain),
+		Scopes:  scopes,
+	}
+	if req.ExpiresIn > 0 {
+		exp := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		key.ExpiresAt = &exp
+	}
+	if err := db.Create(key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("save api key error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": gin.H{
+		"key":     plain,
+		"api_key": key,
+	}})
+}
+
+// ListApiKeys API Key 列表
+// @Summary API Key 列表
+// @Description 返回当前用户的 API Key（不含明文）；管理员可通过 user_id 查询其他用户
+// @Tags Auth
+// @Produce json
+// @Param user_id query int false "用户 ID（管理员）"
+// @Success 200 {array} entity.ApiKey
+// @Failure 403 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/keys [get]
+func ListApiKeys(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	q := db.Model(&entity.ApiKey{})
+	if s := c.Query("user_id"); s != "" {
+		userID, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "invalid user_id"})
+			return
+		}
+		if !isSelf(c, userID) && !requireAdmin(c) {
+			return
+		}
+		q = q.Where("user_id = ?", userID)
+	} else if id, ok := middleware.CurrentIdentity(c); ok {
+		q = q.Where("user_id = ?", id.UserID)
+	}
+	var keys []entity.ApiKey
+	if err := q.Order("id ASC").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("query error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": keys})
+}
+
+// RevokeApiKey 吊销 API Key
+// @Summary 吊销 API Key
+// @Description 记录吊销时间，之后使用该 key 的请求返回 401；所属用户或管理员可操作
+// @Tags Auth
+// @Produce json
+// @Param id path int true "API Key ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/keys/{id} [delete]
+func RevokeApiKey(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	var key entity.ApiKey
+	if err := db.First(&key, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "api key not found"})
+		return
+	}
+	if !isSelf(c, key.UserID) && !requireAdmin(c) {
+		return
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		if err := db.Model(&key).Update("revoked_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("revoke api key error: %v", err)})
+			return
+		}
+		key.RevokedAt = &now
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "revoked", "data": key})
+}
+
+// generateAPIKey 生成 32 字节随机密钥
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/internal/dbtest"
+	"github.com/binhy/go-template/middleware"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const testSecret = "test-secret"
+
+func newAuthRouter(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Open(t)
+	cfg := config.Default()
+	cfg.Auth.JWTSecret = testSecret
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("db", db)
+		c.Set("config", cfg)
+		c.Next()
+	})
+	RegisterRoutes(r.Group("/api/v1", middleware.Auth()))
+	return r, db
+}
+
+func postKey(t *testing.T, r *gin.Engine, authz string, body gin.H) (int, string) {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/keys", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authz)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp struct {
+		Data struct {
+			APIKey entity.ApiKey `json:"api_key"`
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp.Data.APIKey.Scopes
+}
+
+func TestCreateApiKeyLimitedToCallerScopes(t *testing.T) {
+	r, db := newAuthRouter(t)
+	admin := &entity.User{Username: "root", PasswordHash: "x", Scopes: "read,write,admin"}
+	if err := db.Create(admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	writeKey := "gtk_write_only"
+	if err := db.Create(&entity.ApiKey{UserID: admin.ID, Name: "ci", Prefix: writeKey, KeyHash: middleware.HashAPIKey(writeKey), Scopes: "write"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// write-only Key 不能为所属的管理员用户签发 admin Key
+	for _, scopes := range [][]string{{"admin"}, {"read", "admin"}} {
+		if code, _ := postKey(t, r, "ApiKey "+writeKey, gin.H{"name": "escalate", "scopes": scopes}); code != http.StatusForbidden {
+			t.Fatalf("scopes %v: status = %d, want 403", scopes, code)
+		}
+	}
+	// 缺省权限范围取用户与调用方的交集
+	if code, scopes := postKey(t, r, "ApiKey "+writeKey, gin.H{"name": "default"}); code != http.StatusOK || scopes != "read,write" {
+		t.Fatalf("default: status = %d, scopes = %q", code, scopes)
+	}
+	var n int64
+	db.Model(&entity.ApiKey{}).Where("scopes LIKE ?", "%admin%").Count(&n)
+	if n != 0 {
+		t.Fatalf("%d admin keys minted", n)
+	}
+
+	// 拥有 admin 权限范围的 JWT 可以签发 admin Key
+	token, err := middleware.IssueToken(testSecret, time.Hour, middleware.Identity{UserID: admin.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, scopes := postKey(t, r, "Bearer "+token, gin.H{"name": "ops", "scopes": []string{"admin"}}); code != http.StatusOK || scopes != "admin" {
+		t.Fatalf("admin token: status = %d, scopes = %q", code, scopes)
+	}
+}
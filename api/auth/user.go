@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/binhy/go-template/middleware"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/service"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// minPasswordLength 密码最小长度
+const minPasswordLength = 8
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	Username string   `json:"username" form:"username" binding:"required"`
+	Password string   `json:"password" form:"password" binding:"required"`
+	Scopes   []string `json:"scopes" form:"scopes"`
+}
+
+// UpdateUserRequest 更新用户请求，字段为空表示不修改
+type UpdateUserRequest struct {
+	Password   *string  `json:"password" form:"password"`
+	Scopes     []string `json:"scopes" form:"scopes"`
+	Disabled   *bool    `json:"disabled" form:"disabled"`
+	QuotaBytes *int64   `json:"quota_bytes" form:"quota_bytes"` // 0 表示恢复为 quota.user_quota
+}
+
+// CreateUser 创建用户
+// @Summary 创建用户
+// @Description 需要 admin 权限；scopes 可选 read / write / admin，默认 read,write
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body CreateUserRequest true "用户信息"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/users [post]
+func CreateUser(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	var req CreateUserRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("password must be at least %d characters", minPasswordLength)})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{middleware.ScopeRead, middleware.ScopeWrite}
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	var count int64
+	db.Model(&entity.User{}).Where("username = ?", req.Username).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "username already exists"})
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("hash password error: %v", err)})
+		return
+	}
+	user := &entity.User{Username: req.Username, PasswordHash: string(hash), Scopes: scopes}
+	if err := db.Create(user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("save user error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": user})
+}
+
+// ListUsers 用户列表
+// @Summary 用户列表
+// @Description 需要 admin 权限
+// @Tags Auth
+// @Produce json
+// @Success 200 {array} entity.User
+// @Failure 403 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/users [get]
+func ListUsers(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	var users []entity.User
+	if err := db.Order("id ASC").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("query error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": users})
+}
+
+// GetUser 获取用户
+// @Summary 获取用户
+// @Description 管理员可查询任意用户，普通用户只能查询自己
+// @Tags Auth
+// @Produce json
+// @Param id path int true "用户 ID"
+// @Success 200 {object} entity.User
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/users/{id} [get]
+func GetUser(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if !isSelf(c, id) && !requireAdmin(c) {
+		return
+	}
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	var user entity.User
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "user not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": user})
+}
+
+// UpdateUser 更新用户
+// @Summary 更新用户
+// @Description 普通用户只能修改自己的密码；管理员可修改任意用户的密码、权限范围、容量配额与禁用状态（禁用或降级后立即对其已签发的 JWT 与 API Key 生效）
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param id path int true "用户 ID"
+// @Param body body UpdateUserRequest true "更新内容"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/users/{id} [patch]
+func UpdateUser(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req UpdateUserRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	admin := isAdmin(c)
+	if !admin && (!isSelf(c, id) || req.Scopes != nil || req.Disabled != nil || req.QuotaBytes != nil) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "admin scope required"})
+		return
+	}
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	var user entity.User
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "user not found"})
+		return
+	}
+	updates := map[string]interface{}{}
+	if req.Password != nil {
+		if len(*req.Password) < minPasswordLength {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("password must be at least %d characters", minPasswordLength)})
+			return
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("hash password error: %v", err)})
+			return
+		}
+		updates["password_hash"] = string(hash)
+	}
+	if req.Scopes != nil {
+		scopes, err := normalizeScopes(req.Scopes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+			return
+		}
+		updates["scopes"] = scopes
+	}
+	if req.QuotaBytes != nil {
+		switch {
+		case *req.QuotaBytes < 0:
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "quota_bytes must be >= 0"})
+			return
+		case *req.QuotaBytes == 0:
+			updates["quota_bytes"] = nil
+		default:
+			updates["quota_bytes"] = *req.QuotaBytes
+		}
+	}
+	if req.Disabled != nil {
+		if *req.Disabled {
+			updates["disabled_at"] = time.Now()
+		} else {
+			updates["disabled_at"] = nil
+		}
+	}
+	if len(updates) > 0 {
+		if err := db.Model(&user).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("update user error: %v", err)})
+			return
+		}
+	}
+	db.First(&user, "id = ?", id)
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": user})
+}
+
+// DeleteUser 删除用户及其全部 API Key（已上传的文件保留）
+// @Summary 删除用户
+// @Description 需要 admin 权限；同时删除该用户的所有 API Key，已上传的文件保留
+// @Tags Auth
+// @Produce json
+// @Param id path int true "用户 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/users/{id} [delete]
+func DeleteUser(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if !requireAdmin(c) {
+		return
+	}
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&entity.User{}, "id = ?", id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("user_id = ?", id).Delete(&entity.ApiKey{}).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "user not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("delete user error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "deleted"})
+}
+
+// GetUserUsage 查询用户用量与生效的配额
+// @Summary 用户用量
+// @Description 管理员可查询任意用户，普通用户只能查询自己；used_bytes 包含软删除的文件与进行中分块上传已接收的分片，去重复用已有对象的文件同样按完整大小计入；quota_bytes 为 0 表示不限制
+// @Tags Auth
+// @Produce json
+// @Param id path int true "用户 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/auth/users/{id}/usage [get]
+func GetUserUsage(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if !isSelf(c, id) && !requireAdmin(c) {
+		return
+	}
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	var user entity.User
+	if err := db.First(&user, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "user not found"})
+		return
+	}
+	var quota int64
+	if cfg := getConfig(c); cfg != nil {
+		quota = cfg.Quota.UserQuota
+	}
+	if user.QuotaBytes != nil {
+		quota = *user.QuotaBytes
+	}
+	used, err := service.UserUsage(db, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("usage query error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": gin.H{
+		"user_id":     id,
+		"used_bytes":  used,
+		"quota_bytes": quota,
+	}})
+}
@@ -0,0 +1,193 @@
+package bucket
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/binhy/go-template/middleware"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GrantRequest 授权请求
+type GrantRequest struct {
+	UserID uint64 `json:"user_id" form:"user_id" binding:"required"`
+	Role   string `json:"role" form:"role" binding:"required"` // reader / writer / admin / owner
+}
+
+// ListACL 查询 Bucket 授权
+// @Summary 查询 Bucket 授权
+// @Description 需要该 Bucket 的 admin / owner 角色或全局 admin 权限
+// @Tags Buckets
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Success 200 {array} entity.BucketACL
+// @Failure 403 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/buckets/{bucket}/acl [get]
+func ListACL(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	bucket := c.Param("bucket")
+	if _, ok := requireBucketRole(c, db, bucket, entity.BucketRoleAdmin); !ok {
+		return
+	}
+	acls, err := service.ListBucketACL(db, bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("query error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": acls})
+}
+
+// GrantACL 授予或修改用户在 Bucket 上的角色
+// @Summary 授予 Bucket 角色
+// @Description Bucket admin 可授予 reader / writer / admin；授予 owner（转移所有权，原 owner 降级为 admin）或修改 owner 需由 owner 或全局管理员操作
+// @Tags Buckets
+// @Accept json
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Param body body GrantRequest true "授权信息"
+// @Success 200 {object} entity.BucketACL
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/buckets/{bucket}/acl [put]
+func GrantACL(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	bucket := c.Param("bucket")
+	var req GrantRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if !service.ValidBucketRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "invalid role, expected reader, writer, admin or owner"})
+		return
+	}
+	callerRole, ok := requireBucketRole(c, db, bucket, entity.BucketRoleAdmin)
+	if !ok {
+		return
+	}
+	targetRole, _, err := service.BucketRole(db, bucket, req.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket acl error: %v", err)})
+		return
+	}
+	if (req.Role == entity.BucketRoleOwner || targetRole == entity.BucketRoleOwner) && !service.BucketRoleAllows(callerRole, entity.BucketRoleOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": service.ErrOwnerRequired.Error()})
+		return
+	}
+	if targetRole == entity.BucketRoleOwner && req.Role != entity.BucketRoleOwner {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "transfer ownership to another user first"})
+		return
+	}
+	var count int64
+	db.Model(&entity.User{}).Where("id = ?", req.UserID).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "user not found"})
+		return
+	}
+	var grantedBy *uint64
+	if id, ok := middleware.CurrentIdentity(c); ok && id.UserID != 0 {
+		uid := id.UserID
+		grantedBy = &uid
+	}
+	acl, err := service.GrantBucketRole(db, bucket, req.UserID, req.Role, grantedBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("grant error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": acl})
+}
+
+// RevokeACL 撤销用户在 Bucket 上的角色
+// @Summary 撤销 Bucket 角色
+// @Description 需要该 Bucket 的 admin / owner 角色或全局 admin 权限；owner 不能被撤销，需先转移所有权
+// @Tags Buckets
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Param user_id path int true "用户 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/buckets/{bucket}/acl/{user_id} [delete]
+func RevokeACL(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	bucket := c.Param("bucket")
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "invalid user_id"})
+		return
+	}
+	if _, ok := requireBucketRole(c, db, bucket, entity.BucketRoleAdmin); !ok {
+		return
+	}
+	targetRole, _, err := service.BucketRole(db, bucket, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket acl error: %v", err)})
+		return
+	}
+	switch targetRole {
+	case "":
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "acl entry not found"})
+		return
+	case entity.BucketRoleOwner:
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "transfer ownership to another user first"})
+		return
+	}
+	if _, err := service.RevokeBucketRole(db, bucket, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("revoke error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "revoked"})
+}
+
+func getDB(c *gin.Context) (*gorm.DB, bool) {
+	dbI, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+		return nil, false
+	}
+	return dbI.(*gorm.DB), true
+}
+
+// requireBucketRole 校验当前调用方在 Bucket 上至少拥有 need 角色并返回其角色；
+// 全局管理员视为 owner，无身份或无权限时返回 403
+func requireBucketRole(c *gin.Context, db *gorm.DB, bucket, need string) (string, bool) {
+	id, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden: bucket " + need + " role required"})
+		return "", false
+	}
+	if id.IsAdmin() {
+		return entity.BucketRoleOwner, true
+	}
+	role, _, err := service.BucketRole(db, bucket, id.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket acl error: %v", err)})
+		return "", false
+	}
+	if !service.BucketRoleAllows(role, need) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden: bucket " + need + " role required"})
+		return "", false
+	}
+	return role, true
+}
@@ -0,0 +1,472 @@
+package bucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/middleware"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateBucketRequest 创建 Bucket 请求
+type CreateBucketRequest struct {
+	Name          string `json:"name" form:"name" binding:"required"`
+	Region        string `json:"region" form:"region"`
+	Versioning    bool   `json:"versioning" form:"versioning"`
+	ObjectLock    bool   `json:"object_lock" form:"object_lock"`
+	RetentionMode string `json:"retention_mode" form:"retention_mode"` // GOVERNANCE / COMPLIANCE，需开启 object_lock
+	RetentionDays int    `json:"retention_days" form:"retention_days"`
+	QuotaBytes    int64  `json:"quota_bytes" form:"quota_bytes"`         // 0 表示使用 quota.bucket_quota
+	MaxObjectSize int64  `json:"max_object_size" form:"max_object_size"` // 0 表示使用 quota.max_object_size
+}
+
+// CreateBucket 显式创建 Bucket
+// @Summary 创建 Bucket
+// @Description 按 S3 命名规则校验名称后创建 Bucket，可选区域、版本控制、对象锁定与默认保留策略、容量配额与最大对象大小；创建者成为该 Bucket 的 owner
+// @Tags Buckets
+// @Accept json
+// @Produce json
+// @Param body body CreateBucketRequest true "Bucket 信息"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/buckets [post]
+func CreateBucket(c *gin.Context) {
+	db, store, ok := getDeps(c)
+	if !ok {
+		return
+	}
+	var req CreateBucketRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if err := storage.ValidateBucketName(req.Name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid bucket name: %v", err)})
+		return
+	}
+	opts, err := req.options()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	if req.QuotaBytes < 0 || req.MaxObjectSize < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "quota_bytes and max_object_size must be >= 0"})
+		return
+	}
+	// 非管理员只能在默认配额之下收紧，放宽配额需管理员通过 PUT /api/v1/buckets/{bucket}/quota 设置
+	if id, ok := middleware.CurrentIdentity(c); !ok || !id.IsAdmin() {
+		if def := quotaConfig(c).BucketQuota; def > 0 && req.QuotaBytes > def {
+			c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": fmt.Sprintf("quota_bytes above the default of %d bytes requires admin scope", def)})
+			return
+		}
+	}
+
+	ctx := context.Background()
+	exists, err := store.BucketExists(ctx, req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("check bucket error: %v", err)})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "bucket already exists"})
+		return
+	}
+	if err := store.MakeBucket(ctx, req.Name, opts); err != nil {
+		if errors.Is(err, storage.ErrNotSupported) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "versioning and object lock are not supported by the storage driver"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("make bucket error: %v", err)})
+		return
+	}
+
+	rec := &entity.Bucket{
+		Name:          req.Name,
+		Region:        opts.Region,
+		Versioning:    opts.Versioning || opts.ObjectLocking,
+		ObjectLocking: opts.ObjectLocking,
+	}
+	if opts.RetentionMode != "" {
+		rec.RetentionMode = &opts.RetentionMode
+		rec.RetentionDays = &opts.RetentionDays
+	}
+	if req.QuotaBytes > 0 {
+		rec.QuotaBytes = &req.QuotaBytes
+	}
+	if req.MaxObjectSize > 0 {
+		rec.MaxObjectSize = &req.MaxObjectSize
+	}
+	var ownerID uint64
+	if id, ok := middleware.CurrentIdentity(c); ok && id.UserID != 0 {
+		ownerID = id.UserID
+		rec.CreatedBy = &ownerID
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rec).Error; err != nil {
+			return err
+		}
+		if ownerID == 0 {
+			return nil
+		}
+		_, err := service.GrantBucketRole(tx, rec.Name, ownerID, entity.BucketRoleOwner, rec.CreatedBy)
+		return err
+	})
+	if err != nil {
+		_ = store.RemoveBucket(ctx, req.Name)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("save bucket error: %v", err)})
+		return
+	}
+	role := ""
+	if ownerID != 0 {
+		role = entity.BucketRoleOwner
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": bucketView(rec.Name, rec.CreatedAt, rec, role)})
+}
+
+// ListBuckets 获取 Bucket 列表及其选项
+// @Summary Bucket 列表
+// @Description 管理员返回存储中的全部 Bucket；其余调用方仅返回被授予角色的 Bucket。显式创建的 Bucket 附带创建选项
+// @Tags Buckets
+// @Produce json
+// @Success 200 {array} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/buckets [get]
+func ListBuckets(c *gin.Context) {
+	db, store, ok := getDeps(c)
+	if !ok {
+		return
+	}
+	buckets, err := store.ListBuckets(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("list buckets error: %v", err)})
+		return
+	}
+	var roles map[string]string
+	if id, ok := middleware.CurrentIdentity(c); !ok {
+		roles = map[string]string{}
+	} else if !id.IsAdmin() {
+		if roles, err = service.AccessibleBuckets(db, id.UserID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket acl error: %v", err)})
+			return
+		}
+	}
+	var recs []entity.Bucket
+	if err := db.Find(&recs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("query error: %v", err)})
+		return
+	}
+	byName := make(map[string]*entity.Bucket, len(recs))
+	for i := range recs {
+		byName[recs[i].Name] = &recs[i]
+	}
+	list := make([]gin.H, 0, len(buckets))
+	for _, b := range buckets {
+		role := ""
+		if roles != nil {
+			var ok bool
+			if role, ok = roles[b.Name]; !ok {
+				continue
+			}
+		}
+		list = append(list, bucketView(b.Name, b.CreatedAt, byName[b.Name], role))
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": list})
+}
+
+// GetBucket 获取 Bucket 详情
+// @Summary Bucket 详情
+// @Description 需要该 Bucket 的 reader 及以上角色或全局 admin 权限
+// @Tags Buckets
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/buckets/{bucket} [get]
+func GetBucket(c *gin.Context) {
+	db, store, ok := getDeps(c)
+	if !ok {
+		return
+	}
+	name := c.Param("bucket")
+	role, ok := requireBucketRole(c, db, name, entity.BucketRoleReader)
+	if !ok {
+		return
+	}
+	info, found, err := findBucket(store, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("list buckets error: %v", err)})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "bucket not found"})
+		return
+	}
+	var rec *entity.Bucket
+	var r entity.Bucket
+	if err := db.Where("name = ?", name).First(&r).Error; err == nil {
+		rec = &r
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": bucketView(info.Name, info.CreatedAt, rec, role)})
+}
+
+// DeleteBucket 删除空 Bucket
+// @Summary 删除 Bucket
+// @Description 需要该 Bucket 的 owner 角色或全局 admin 权限；Bucket 中仍有文件（含软删除）或进行中的分块上传时返回 409
+// @Tags Buckets
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/buckets/{bucket} [delete]
+func DeleteBucket(c *gin.Context) {
+	db, store, ok := getDeps(c)
+	if !ok {
+		return
+	}
+	name := c.Param("bucket")
+	if _, ok := requireBucketRole(c, db, name, entity.BucketRoleOwner); !ok {
+		return
+	}
+	var files, sessions int64
+	db.Model(&entity.File{}).Where("bucket = ?", name).Count(&files)
+	db.Model(&entity.UploadSession{}).Where("bucket = ? AND status IN ?", name,
+		[]string{entity.UploadStatusActive, entity.UploadStatusCompleting}).Count(&sessions)
+	if files > 0 || sessions > 0 {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "bucket not empty", "data": gin.H{"files": files, "active_uploads": sessions}})
+		return
+	}
+	if err := store.RemoveBucket(context.Background(), name); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "bucket not found"})
+		case errors.Is(err, storage.ErrBucketNotEmpty):
+			c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "bucket not empty"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("remove bucket error: %v", err)})
+		}
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("name = ?", name).Delete(&entity.Bucket{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("bucket = ?", name).Delete(&entity.Folder{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("bucket = ?", name).Delete(&entity.BucketACL{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("delete bucket records error: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "deleted"})
+}
+
+// options 校验并转换为存储层的创建选项
+func (r CreateBucketRequest) options() (storage.BucketOptions, error) {
+	opts := storage.BucketOptions{
+		Region:        r.Region,
+		Versioning:    r.Versioning,
+		ObjectLocking: r.ObjectLock,
+		RetentionMode: strings.ToUpper(r.RetentionMode),
+		RetentionDays: r.RetentionDays,
+	}
+	switch opts.RetentionMode {
+	case "":
+		if opts.RetentionDays != 0 {
+			return opts, errors.New("retention_days requires retention_mode")
+		}
+	case storage.RetentionGovernance, storage.RetentionCompliance:
+		if !opts.ObjectLocking {
+			return opts, errors.New("default retention requires object_lock")
+		}
+		if opts.RetentionDays <= 0 {
+			return opts, errors.New("retention_days must be > 0")
+		}
+	default:
+		return opts, fmt.Errorf("invalid retention_mode %q, expected GOVERNANCE or COMPLIANCE", r.RetentionMode)
+	}
+	return opts, nil
+}
+
+// bucketView 组装 Bucket 响应；rec 为空表示上传时隐式创建、没有选项记录
+func bucketView(name string, createdAt time.Time, rec *entity.Bucket, role string) gin.H {
+	view := gin.H{"name": name, "createdAt": createdAt, "options": rec}
+	if role != "" {
+		view["role"] = role
+	}
+	return view
+}
+
+// findBucket 在存储中查找 Bucket
+func findBucket(store storage.ObjectStore, name string) (storage.BucketInfo, bool, error) {
+	buckets, err := store.ListBuckets(context.Background())
+	if err != nil {
+		return storage.BucketInfo{}, false, err
+	}
+	for _, b := range buckets {
+		if b.Name == name {
+			return b, true, nil
+		}
+	}
+	return storage.BucketInfo{}, false, nil
+}
+
+func getDeps(c *gin.Context) (*gorm.DB, storage.ObjectStore, bool) {
+	dbI, okDB := c.Get("db")
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
+		return nil, nil, false
+	}
+	return dbI.(*gorm.DB), storeI.(storage.ObjectStore), true
+}
+
+// QuotaRequest 设置 Bucket 配额请求；字段为空表示不修改，0 表示恢复为配置默认值
+type QuotaRequest struct {
+	QuotaBytes    *int64 `json:"quota_bytes" form:"quota_bytes"`
+	MaxObjectSize *int64 `json:"max_object_size" form:"max_object_size"`
+}
+
+// SetBucketQuota 设置 Bucket 容量配额与最大对象大小
+// @Summary 设置 Bucket 配额
+// @Description 需要全局 admin 权限；0 表示恢复为 quota.bucket_quota / quota.max_object_size，最大对象大小不能超过全局上限
+// @Tags Buckets
+// @Accept json
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Param body body QuotaRequest true "配额"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/buckets/{bucket}/quota [put]
+func SetBucketQuota(c *gin.Context) {
+	db, store, ok := getDeps(c)
+	if !ok {
+		return
+	}
+	if id, ok := middleware.CurrentIdentity(c); !ok || !id.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "admin scope required"})
+		return
+	}
+	name := c.Param("bucket")
+	var req QuotaRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if (req.QuotaBytes != nil && *req.QuotaBytes < 0) || (req.MaxObjectSize != nil && *req.MaxObjectSize < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "quota_bytes and max_object_size must be >= 0"})
+		return
+	}
+	exists, err := store.BucketExists(context.Background(), name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("check bucket error: %v", err)})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "bucket not found"})
+		return
+	}
+	// 上传时隐式创建的 Bucket 没有记录，设置配额时补建
+	var rec entity.Bucket
+	if err := db.Where(entity.Bucket{Name: name}).FirstOrCreate(&rec).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("save bucket error: %v", err)})
+		return
+	}
+	updates := map[string]interface{}{}
+	if req.QuotaBytes != nil {
+		updates["quota_bytes"] = nullIfZero(*req.QuotaBytes)
+	}
+	if req.MaxObjectSize != nil {
+		updates["max_object_size"] = nullIfZero(*req.MaxObjectSize)
+	}
+	if len(updates) > 0 {
+		if err := db.Model(&rec).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("update bucket error: %v", err)})
+			return
+		}
+	}
+	db.First(&rec, "id = ?", rec.ID)
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": rec})
+}
+
+// GetBucketUsage 查询 Bucket 用量与生效的限制
+// @Summary Bucket 用量
+// @Description 需要该 Bucket 的 reader 及以上角色或全局 admin 权限；used_bytes 包含软删除的文件与进行中分块上传已接收的分片，去重复用已有对象的文件同样按完整大小计入；限制为 0 表示不限制
+// @Tags Buckets
+// @Produce json
+// @Param bucket path string true "Bucket 名称"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/buckets/{bucket}/usage [get]
+func GetBucketUsage(c *gin.Context) {
+	db, _, ok := getDeps(c)
+	if !ok {
+		return
+	}
+	name := c.Param("bucket")
+	if _, ok := requireBucketRole(c, db, name, entity.BucketRoleReader); !ok {
+		return
+	}
+	limits, err := service.ResolveQuotaLimits(db, quotaConfig(c), name, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("quota lookup error: %v", err)})
+		return
+	}
+	used, err := service.BucketUsage(db, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("usage query error: %v", err)})
+		return
+	}
+	var files int64
+	db.Model(&entity.File{}).Where("bucket = ?", name).Count(&files)
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": gin.H{
+		"bucket":          name,
+		"used_bytes":      used,
+		"files":           files,
+		"quota_bytes":     limits.BucketQuota,
+		"max_object_size": limits.MaxObjectSize,
+	}})
+}
+
+func quotaConfig(c *gin.Context) config.QuotaConfig {
+	if v, ok := c.Get("config"); ok {
+		if cfg, ok := v.(*config.Config); ok && cfg != nil {
+			return cfg.Quota
+		}
+	}
+	return config.QuotaConfig{}
+}
+
+func nullIfZero(v int64) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
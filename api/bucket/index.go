@@ -0,0 +1,22 @@
+package bucket
+
+import "github.com/gin-gonic/gin"
+
+// RegisterRoutes 注册 Bucket 相关子路由，由 /api/v1 分组传入
+func RegisterRoutes(v1 *gin.RouterGroup) {
+    buckets := v1.Group("/buckets")
+    {
+        // 显式创建/删除 Bucket（上传时的隐式创建可通过 storage.auto_create_buckets 关闭）
+        buckets.POST("", CreateBucket)
+        buckets.GET("", ListBuckets)
+        buckets.GET("/:bucket", GetBucket)
+        buckets.DELETE("/:bucket", DeleteBucket)
+        // 配额与用量
+        buckets.PUT("/:bucket/quota", SetBucketQuota)
+        buckets.GET("/:bucket/usage", GetBucketUsage)
+        // Bucket 授权管理（owner / admin 角色或全局管理员）
+        buckets.GET("/:bucket/acl", ListACL)
+        buckets.PUT("/:bucket/acl", GrantACL)
+        buckets.DELETE("/:bucket/acl/:user_id", RevokeACL)
+    }
+}
@@ -0,0 +1,171 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/binhy/go-template/middleware"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// currentUploaderID 返回当前调用方的用户 ID，写入 UploaderID / OwnerID；匿名调用方（auth.disabled）或 sub 非数字时返回 nil
+func currentUploaderID(c *gin.Context) *uint64 {
+	id, ok := middleware.CurrentIdentity(c)
+	if !ok || id.UserID == 0 {
+		return nil
+	}
+	uid := id.UserID
+	return &uid
+}
+
+// isOwnerOrAdmin 判断当前调用方能否访问归属于 ownerID 的资源：
+// 管理员可访问全部；其余仅可访问自己上传的资源（无归属的历史数据仅管理员可访问）；无身份时一律拒绝
+func isOwnerOrAdmin(c *gin.Context, ownerID *uint64) bool {
+	id, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		return false
+	}
+	if id.IsAdmin() {
+		return true
+	}
+	return ownerID != nil && id.UserID != 0 && *ownerID == id.UserID
+}
+
+// bucketAllows 判断当前调用方在 Bucket 上是否拥有 need 角色；全局管理员不做限制，无身份时一律拒绝。
+// claimed 为 false 表示 Bucket 尚无授权记录（历史数据），此时读取仅按文件归属控制，写入仅限全局管理员
+func bucketAllows(c *gin.Context, db *gorm.DB, bucket, need string) (allowed, claimed bool, err error) {
+	id, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		return false, true, nil
+	}
+	if id.IsAdmin() {
+		return true, true, nil
+	}
+	if id.UserID == 0 {
+		return false, true, nil
+	}
+	role, claimed, err := service.BucketRole(db, bucket, id.UserID)
+	if err != nil {
+		return false, false, err
+	}
+	return service.BucketRoleAllows(role, need), claimed, nil
+}
+
+// pendingBucketKey 上下文键：authorizeBucketWrite 因 Bucket 尚不存在而放行时记录该 Bucket，由 ensureUploadBucket 新建并认领
+const pendingBucketKey = "file.pending_bucket"
+
+// authorizeBucketWrite 校验当前调用方能否向 Bucket 写入；无权限时返回 403。
+// 未认领的已有 Bucket（历史数据）仅全局管理员可写，其他用户须由管理员通过 ACL 接口授权；
+// 尚不存在的 Bucket 允许写入，由随后的 ensureUploadBucket 新建并认领给调用方
+func authorizeBucketWrite(c *gin.Context, db *gorm.DB, store storage.ObjectStore, bucket string) bool {
+	allowed, claimed, err := bucketAllows(c, db, bucket, entity.BucketRoleWriter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket acl error: %v", err)})
+		return false
+	}
+	if allowed {
+		return true
+	}
+	if !claimed {
+		exists, err := store.BucketExists(context.Background(), bucket)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("check bucket error: %v", err)})
+			return false
+		}
+		if !exists && currentUploaderID(c) != nil {
+			c.Set(pendingBucketKey, bucket)
+			return true
+		}
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden: bucket is not claimed, ask an admin to grant access"})
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden: no write access to bucket"})
+	return false
+}
+
+// authorizeFile 校验当前调用方对文件的访问权限：文件上传者、全局管理员，或在所属 Bucket 上拥有 need 角色的用户；无权限时返回 403
+func authorizeFile(c *gin.Context, rec *entity.File, need string) bool {
+	if fileAllowed(c, rec, need) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden"})
+	return false
+}
+
+// fileAllowed 判断当前调用方对文件是否拥有 need 权限，规则同 authorizeFile，不写响应
+func fileAllowed(c *gin.Context, rec *entity.File, need string) bool {
+	return ownerOrBucketRole(c, rec.Bucket, rec.UploaderID, need)
+}
+
+// ownerOrBucketRole 判断当前调用方是否为资源归属者、全局管理员，或在 bucket 上拥有 need 角色
+func ownerOrBucketRole(c *gin.Context, bucket string, ownerID *uint64, need string) bool {
+	if isOwnerOrAdmin(c, ownerID) {
+		return true
+	}
+	if dbI, ok := c.Get("db"); ok {
+		if allowed, claimed, err := bucketAllows(c, dbI.(*gorm.DB), bucket, need); err == nil && claimed && allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizeSession 校验当前调用方对分块上传会话的访问权限：须为会话所有者且仍拥有 Bucket 写权限（未认领的 Bucket 仅限全局管理员），无权限时返回 403
+func authorizeSession(c *gin.Context, sess *entity.UploadSession) bool {
+	if !isOwnerOrAdmin(c, sess.OwnerID) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden"})
+		return false
+	}
+	if dbI, ok := c.Get("db"); ok {
+		allowed, _, err := bucketAllows(c, dbI.(*gorm.DB), sess.Bucket, entity.BucketRoleWriter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket acl error: %v", err)})
+			return false
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden: no write access to bucket"})
+			return false
+		}
+	}
+	return true
+}
+
+// scopeToOwner 非管理员调用方只能查询自己上传的文件
+func scopeToOwner(c *gin.Context, q *gorm.DB) *gorm.DB {
+	id, ok := middleware.CurrentIdentity(c)
+	if ok && id.IsAdmin() {
+		return q
+	}
+	if !ok || id.UserID == 0 {
+		return q.Where("1 = 0")
+	}
+	return q.Where("uploader_id = ?", id.UserID)
+}
+
+// scopeToReadable 跨 Bucket 查询时限定为调用方可读的文件：自己上传的文件，以及拥有任意角色的 Bucket 中的文件
+func scopeToReadable(c *gin.Context, db, q *gorm.DB) (*gorm.DB, error) {
+	id, ok := middleware.CurrentIdentity(c)
+	if ok && id.IsAdmin() {
+		return q, nil
+	}
+	if !ok || id.UserID == 0 {
+		return q.Where("1 = 0"), nil
+	}
+	roles, err := service.AccessibleBuckets(db, id.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return q.Where("uploader_id = ?", id.UserID), nil
+	}
+	buckets := make([]string, 0, len(roles))
+	for b := range roles {
+		buckets = append(buckets, b)
+	}
+	return q.Where("uploader_id = ? OR bucket IN ?", id.UserID, buckets), nil
+}
@@ -0,0 +1,86 @@
+package file
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/binhy/go-template/internal/dbtest"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMissingIdentityIsDenied(t *testing.T) {
+	db := dbtest.Open(t)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("db", db)
+	owner := uint64(1)
+	if isOwnerOrAdmin(c, &owner) || isOwnerOrAdmin(c, nil) {
+		t.Fatal("caller without identity treated as owner or admin")
+	}
+	if allowed, _, err := bucketAllows(c, db, "docs", entity.BucketRoleReader); err != nil || allowed {
+		t.Fatalf("bucketAllows = %v, %v", allowed, err)
+	}
+	if fileAllowed(c, &entity.File{Bucket: "docs", UploaderID: &owner}, entity.BucketRoleReader) {
+		t.Fatal("caller without identity can read file")
+	}
+	if err := db.Create(&entity.File{Bucket: "docs"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	if err := scopeToOwner(c, db.Model(&entity.File{})).Count(&n).Error; err != nil || n != 0 {
+		t.Fatalf("scopeToOwner count = %d, %v", n, err)
+	}
+	q, err := scopeToReadable(c, db, db.Model(&entity.File{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Count(&n).Error; err != nil || n != 0 {
+		t.Fatalf("scopeToReadable count = %d, %v", n, err)
+	}
+}
+
+func TestWriteToUnclaimedBucketRequiresAdmin(t *testing.T) {
+	env := newTestEnv(t, nil)
+	alice, user := env.as(t, "alice")
+	bob, _ := env.as(t, "bob")
+
+	// 新建的 Bucket 由上传者认领为 owner
+	if code, resp := alice.form(t, "/api/v1/files", map[string]string{"bucket": "fresh"}, "file", []byte("a")); code != http.StatusOK {
+		t.Fatalf("upload to new bucket status = %d: %v", code, resp)
+	}
+	var acl entity.BucketACL
+	if err := env.db.First(&acl, "bucket = ?", "fresh").Error; err != nil || acl.UserID != user.ID || acl.Role != entity.BucketRoleOwner {
+		t.Fatalf("acl = %+v, %v", acl, err)
+	}
+	if code, _ := bob.form(t, "/api/v1/files", map[string]string{"bucket": "fresh"}, "file", []byte("b")); code != http.StatusForbidden {
+		t.Fatalf("upload to claimed bucket status = %d, want 403", code)
+	}
+
+	// 已存在但未认领的 Bucket 仅管理员可写，且上传不会认领该 Bucket
+	env.putFile(t, "legacy", "old.txt", []byte("old"), nil)
+	if code, _ := alice.form(t, "/api/v1/files", map[string]string{"bucket": "legacy"}, "file", []byte("a")); code != http.StatusForbidden {
+		t.Fatalf("upload to unclaimed bucket status = %d, want 403", code)
+	}
+	if code, _ := alice.form(t, "/api/v1/files/multipart/init", map[string]string{"bucket": "legacy", "filename": "big.bin"}, "", nil); code != http.StatusForbidden {
+		t.Fatalf("init on unclaimed bucket status = %d, want 403", code)
+	}
+	if code, _ := alice.do(t, http.MethodPost, "/api/v1/folders", map[string]string{"bucket": "legacy", "name": "dir"}); code != http.StatusForbidden {
+		t.Fatalf("folder in unclaimed bucket status = %d, want 403", code)
+	}
+	if code, resp := env.form(t, "/api/v1/files", map[string]string{"bucket": "legacy"}, "file", []byte("admin")); code != http.StatusOK {
+		t.Fatalf("admin upload status = %d: %v", code, resp)
+	}
+	var n int64
+	if err := env.db.Model(&entity.BucketACL{}).Where("bucket = ?", "legacy").Count(&n).Error; err != nil || n != 0 {
+		t.Fatalf("unclaimed bucket acl count = %d, %v", n, err)
+	}
+
+	// 管理员授权后可写
+	if err := env.db.Create(&entity.BucketACL{Bucket: "legacy", UserID: user.ID, Role: entity.BucketRoleWriter}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code, resp := alice.form(t, "/api/v1/files", map[string]string{"bucket": "legacy"}, "file", []byte("a")); code != http.StatusOK {
+		t.Fatalf("upload after grant status = %d: %v", code, resp)
+	}
+}
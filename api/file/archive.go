@@ -1,46 +1,74 @@
 package file
 
 import (
-    "archive/zip"
-    "bytes"
     "context"
+    "errors"
     "fmt"
     "io"
     "net/http"
     "os"
-    "os/exec"
     "path/filepath"
-    "strconv"
-    "strings"
-    "time"
 
     "github.com/binhy/go-template/model/entity"
+    "github.com/binhy/go-template/model/response"
+    "github.com/binhy/go-template/service"
+    "github.com/binhy/go-template/storage"
     "github.com/gin-gonic/gin"
-    "github.com/google/uuid"
-    "github.com/minio/minio-go/v7"
     "gorm.io/gorm"
 )
 
-// UploadArchive 处理压缩包上传：仅解压顶层或单层目录内的文件并存储到 MinIO，跳过更深层目录
-// @Summary 上传压缩包并存储其中的文件（跳过深层目录）
-// @Description 接收 zip 压缩包，解压后将位于根目录或一级目录内的文件上传到指定 Bucket；多级嵌套（深层目录）文件将被跳过。
+// UploadArchive 处理压缩包上传：默认仅解压顶层或单层目录内的文件并存储到对象存储，跳过更深层目录；
+// 开启 preserve_paths 时解压全部文件并保留目录结构
+// @Summary 上传压缩包并存储其中的文件
+// @Description 接收压缩包（按文件头魔数识别 zip、7z、tar 及 gzip / bzip2 / xz / zstd 压缩的 tar；非 tar 的压缩流作为单个文件存储），解压后将位于根目录或一级目录内的文件上传到指定 Bucket，多级嵌套（深层目录）文件将被跳过；
+// @Description preserve_paths=true 时上传任意深度的文件，目录在目标文件夹下逐级创建为文件夹，并作为对象 key 前缀。
+// @Description 响应 data.skipped 为未入库的条目列表，每项包含 name、reason（nested_directory、invalid_path、too_large、read_error、storage_error、database_error、unsupported_entry、encrypted、path_traversal、symlink、compression_ratio）与 detail；
+// @Description 条目数或解压总量超出 [archive] 配置的限制时中止解析，返回 400（code 40004）；中止时回滚已入库的文件（已创建的文件夹保留），响应 data.uploaded 为空。
 // @Tags Files
 // @Accept multipart/form-data
 // @Produce json
 // @Param bucket formData string true "MinIO Bucket 名称"
-// @Param file formData file true "zip 压缩包文件"
+// @Param file formData file true "压缩包文件：zip、7z、tar、tar.gz / tgz、tar.bz2、tar.xz、tar.zst，或 .gz / .bz2 / .xz / .zst 单文件"
+// @Param checksum formData string false "压缩包摘要（hex 或 base64），提供时服务端校验，不一致返回 400"
+// @Param checksum_algorithm formData string false "摘要算法：sha256（默认）、md5、crc32c"
+// @Param tags formData string false "标签，逗号分隔或重复传入，应用到解压出的每个文件"
+// @Param metadata formData string false "自定义元数据（JSON 对象），应用到解压出的每个文件"
+// @Param folder formData string false "解压出的文件所在文件夹路径，不存在时逐级创建；与 folder_id 二选一"
+// @Param folder_id formData int false "解压出的文件所在文件夹 ID"
+// @Param preserve_paths formData bool false "保留压缩包内的目录结构，默认 false"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "无法识别（code 40001）、已加密（code 40002）、损坏（code 40003）或超出解析限制（code 40004）的压缩包"
+// @Failure 500 {object} map[string]interface{} "存储、数据库等服务端错误（code 500）"
+// @Failure 413 {object} map[string]interface{} "压缩包或解压出的文件超出最大对象大小（code 41301），或超出容量配额（code 41302）"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/archive [post]
 func UploadArchive(c *gin.Context) {
     // 依赖通过 processArchiveFile 内部获取
 
+    // 读取表单字段前限制请求体大小，避免超大压缩包落地到临时目录
+    if !parseLimitedForm(c) {
+        return
+    }
     bucket := c.PostForm("bucket")
     if bucket == "" {
         c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "bucket is required"})
         return
     }
+    dbI, okDB := c.Get("db")
+    storeI, okStore := c.Get("storage")
+    if !okDB || !okStore {
+        c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
+        return
+    }
+    store := storeI.(storage.ObjectStore)
+    if !checkUploadBucket(c, store, bucket) || !authorizeBucketWrite(c, dbI.(*gorm.DB), store, bucket) {
+        return
+    }
+    folderID, ok := resolveUploadFolder(c, dbI.(*gorm.DB), bucket)
+    if !ok {
+        return
+    }
 
     // 获取上传的压缩包
     fileHeader, err := c.FormFile("file")
@@ -49,6 +77,22 @@ func UploadArchive(c *gin.Context) {
         return
     }
 
+    expected, err := parseChecksum(c.PostForm("checksum_algorithm"), c.PostForm("checksum"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+        return
+    }
+    labels, err := parseLabelForm(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+        return
+    }
+    preserve, err := parsePreservePaths(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+        return
+    }
+
     src, err := fileHeader.Open()
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("file open error: %v", err)})
@@ -72,37 +116,27 @@ func UploadArchive(c *gin.Context) {
         c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("create temp file error: %v", err)})
         return
     }
-    if _, err := io.Copy(out, src); err != nil {
+    digest := newDigester(expected)
+    if _, err := io.Copy(io.MultiWriter(out, digest), src); err != nil {
         _ = out.Close()
         c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("write temp file error: %v", err)})
         return
     }
     _ = out.Close()
-
-    // 校验/创建 Bucket
-    ctx := context.Background()
-    // 校验/创建 Bucket（使用 MinIO 客户端）
-    minioI, okMinio := c.Get("minio")
-    if !okMinio {
-        c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage not initialized"})
+    // 解析前校验压缩包摘要
+    if err := digest.Verify(); err != nil {
+        respondChecksumError(c, err)
         return
     }
-    mc := minioI.(*minio.Client)
-    exists, err := mc.BucketExists(ctx, bucket)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket check error: %v", err)})
+
+    // 校验/创建 Bucket
+    if !ensureUploadBucket(c, dbI.(*gorm.DB), store, bucket) {
         return
     }
-    if !exists {
-        if err := mc.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("make bucket error: %v", err)})
-            return
-        }
-    }
 
-    uploaded, skipped, err := processArchiveFile(c, bucket, tmpFile, fileHeader.Filename, tmpDir)
+    uploaded, skipped, err := processArchiveFile(c, bucket, tmpFile, fileHeader.Filename, currentUploaderID(c), folderID, labels, preserve)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+        respondArchiveError(c, uploaded, skipped, err)
         return
     }
 
@@ -113,183 +147,137 @@ func UploadArchive(c *gin.Context) {
     })
 }
 
-// isSevenZipFile 判断文件是否为 7z：检查魔数 37 7A BC AF 27 1C
-func isSevenZipFile(path string) bool {
-    f, err := os.Open(path)
-    if err != nil { return false }
-    defer f.Close()
-    head := make([]byte, 6)
-    n, _ := io.ReadFull(f, head)
-    if n < 6 { return false }
-    return head[0] == 0x37 && head[1] == 0x7A && head[2] == 0xBC && head[3] == 0xAF && head[4] == 0x27 && head[5] == 0x1C
-}
+// archiveStagingPrefix 压缩包分块上传暂存对象的 key 前缀
+const archiveStagingPrefix = "_staging/"
 
-// processArchiveFile 将指定压缩文件解析并上传内部文件（根或一级目录），返回上传与跳过列表
-func processArchiveFile(c *gin.Context, bucket, tmpFile, originalFilename, workDir string) ([]entity.File, []string, error) {
+// processArchiveFile 按文件头魔数识别压缩包格式，解析并上传内部文件，返回上传与跳过列表；
+// 各格式均由纯 Go 读取器逐个条目边解压边写入存储，不落地解压结果；
+// preserve 为 false 时仅上传根或一级目录内的文件，为 true 时上传任意深度的文件并在 folderID 下重建目录结构；
+// ownerID 写入每条文件记录的 UploaderID，解压出的文件放入 folderID 文件夹（为空表示根目录）并应用 labels
+func processArchiveFile(c *gin.Context, bucket, tmpFile, originalFilename string, ownerID, folderID *uint64, labels fileLabels, preserve bool) ([]entity.File, []SkippedEntry, error) {
     dbI, okDB := c.Get("db")
-    minioI, okMinio := c.Get("minio")
-    if !okDB || !okMinio {
+    storeI, okStore := c.Get("storage")
+    if !okDB || !okStore {
         return nil, nil, fmt.Errorf("storage or database not initialized")
     }
     db := dbI.(*gorm.DB)
-    mc := minioI.(*minio.Client)
-    ctx := context.Background()
-
-    uploaded := make([]entity.File, 0)
-    skipped := make([]string, 0)
-
-    fname := strings.ToLower(originalFilename)
-    is7z := strings.HasSuffix(fname, ".7z") || isSevenZipFile(tmpFile)
-    if !is7z {
-        zr, err := zip.OpenReader(tmpFile)
-        if err != nil {
-            return nil, nil, fmt.Errorf("unsupported archive or bad zip: %v", err)
+    store := storeI.(storage.ObjectStore)
+    tracker, err := service.NewQuotaTracker(db, quotaConfig(c), bucket, ownerID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("quota lookup error: %v", err)
+    }
+    ingest := &archiveIngest{
+        c:        c,
+        ctx:      context.Background(),
+        db:       db,
+        store:    store,
+        tracker:  tracker,
+        bucket:   bucket,
+        ownerID:  ownerID,
+        folderID: folderID,
+        labels:   labels,
+        preserve: preserve,
+        folders:  make(map[string]*uint64),
+        uploaded: make([]entity.File, 0),
+        skipped:  make([]SkippedEntry, 0),
+    }
+    stat, err := os.Stat(tmpFile)
+    if err != nil {
+        return ingest.uploaded, ingest.skipped, err
+    }
+    ingest.setLimits(archiveConfig(c), stat.Size())
+    if preserve && folderID != nil {
+        if ingest.base, err = service.FindFolder(db, bucket, *folderID); err != nil {
+            return ingest.uploaded, ingest.skipped, fmt.Errorf("folder lookup error: %w", err)
         }
-        defer zr.Close()
-        for _, f := range zr.File {
-            if f.FileInfo().IsDir() { continue }
-            name := strings.TrimSpace(f.Name)
-            if name == "" { skipped = append(skipped, f.Name); continue }
-            segments := strings.Split(name, "/")
-            cleaned := make([]string, 0, len(segments))
-            for _, s := range segments { s = strings.TrimSpace(s); if s != "" { cleaned = append(cleaned, s) } }
-            if len(cleaned) == 0 || len(cleaned) > 2 { skipped = append(skipped, f.Name); continue }
-
-            rc, err := f.Open()
-            if err != nil { skipped = append(skipped, f.Name); continue }
-            head := make([]byte, 512)
-            n, _ := io.ReadFull(rc, head)
-            contentType := safeContentType(http.DetectContentType(head[:n]))
-            reader := io.MultiReader(bytes.NewReader(head[:n]), rc)
-
-            base := cleaned[len(cleaned)-1]
-            ext := strings.ToLower(filepath.Ext(base))
-            objectName := uuid.New().String()
-            if ext != "" { objectName += ext }
+    }
 
-            putOpts := minio.PutObjectOptions{ContentType: contentType}
-            info, err := mc.PutObject(ctx, bucket, objectName, reader, int64(f.UncompressedSize64), putOpts)
-            _ = rc.Close()
-            if err != nil { skipped = append(skipped, f.Name); continue }
+    format, err := detectArchiveFormat(tmpFile)
+    if err != nil {
+        return ingest.uploaded, ingest.skipped, err
+    }
+    switch format {
+    case archiveFormatZip:
+        err = ingestZip(ingest, tmpFile)
+    case archiveFormatTar, archiveFormatGzip, archiveFormatBzip2, archiveFormatXz, archiveFormatZstd:
+        err = ingestCompressed(ingest, tmpFile, format, originalFilename)
+    case archiveFormatSevenZip:
+        err = ingestSevenZip(ingest, tmpFile)
+    }
+    if err != nil {
+        // 中途中止（超出配额或解析限制、压缩包损坏等）时回滚已入库的文件，失败的请求不改变已存储的数据
+        ingest.rollback()
+        return ingest.uploaded, ingest.skipped, err
+    }
+    return ingest.uploaded, ingest.skipped, nil
+}
 
-            originalName := base
-            rec := &entity.File{
-                Bucket:       bucket,
-                ObjectName:   objectName,
-                OriginalName: &originalName,
-                URL:          "",
-                Size:         ptrInt64(info.Size),
-                MimeType:     ptrString(contentType),
-                UploaderID:   nil,
-                IsDeleted:    false,
-                CreatedAt:    time.Now(),
-            }
-            if err := db.Create(rec).Error; err != nil {
-                skipped = append(skipped, f.Name)
-                _ = mc.RemoveObject(ctx, bucket, objectName, minio.RemoveObjectOptions{})
-                continue
-            }
-            serverURL := buildServerDownloadURL(c, rec.ID)
-            _ = db.Model(rec).Update("url", serverURL).Error
-            rec.URL = serverURL
-            uploaded = append(uploaded, *rec)
-        }
-    } else {
-        // 7z：通过 7z 解压到 workDir
-        cmd := exec.Command("7z", "x", "-y", "-o"+workDir, tmpFile)
-        if out, err := cmd.CombinedOutput(); err != nil {
-            return nil, nil, fmt.Errorf("7z extract failed: %v; output: %s", err, string(out))
-        }
-        // 遍历解压后的文件
-        err := filepath.Walk(workDir, func(path string, info os.FileInfo, err error) error {
-            if err != nil { return err }
-            if info.IsDir() { return nil }
-            if filepath.Base(path) == filepath.Base(tmpFile) { return nil }
-            rel, err := filepath.Rel(workDir, path)
-            if err != nil { skipped = append(skipped, path); return nil }
-            segs := strings.Split(rel, string(filepath.Separator))
-            cleaned := make([]string, 0, len(segs))
-            for _, s := range segs { s = strings.TrimSpace(s); if s != "" { cleaned = append(cleaned, s) } }
-            if len(cleaned) == 0 || len(cleaned) > 2 { skipped = append(skipped, rel); return nil }
+// reserveEntry 将压缩包内的单个文件计入配额：超过最大对象大小时跳过该文件，超出 Bucket / 用户配额时返回错误并停止解析
+func reserveEntry(tracker *service.QuotaTracker, size int64) (bool, error) {
+    err := tracker.Reserve(size)
+    var qe *service.QuotaError
+    if errors.As(err, &qe) && qe.Scope == service.QuotaScopeObject {
+        return true, nil
+    }
+    return false, err
+}
 
-            f, err := os.Open(path)
-            if err != nil { skipped = append(skipped, rel); return nil }
-            defer f.Close()
-            head := make([]byte, 512)
-            n, _ := io.ReadFull(f, head)
-            contentType := safeContentType(http.DetectContentType(head[:n]))
-            reader := io.MultiReader(bytes.NewReader(head[:n]), f)
-            base := cleaned[len(cleaned)-1]
-            ext := strings.ToLower(filepath.Ext(base))
-            objectName := uuid.New().String()
-            if ext != "" { objectName += ext }
-            putOpts := minio.PutObjectOptions{ContentType: contentType}
-            stat, _ := os.Stat(path)
-            size := int64(0)
-            if stat != nil { size = stat.Size() }
-            info2, err := mc.PutObject(ctx, bucket, objectName, reader, size, putOpts)
-            if err != nil { skipped = append(skipped, rel); return nil }
-            originalName := base
-            rec := &entity.File{
-                Bucket:       bucket,
-                ObjectName:   objectName,
-                OriginalName: &originalName,
-                URL:          "",
-                Size:         ptrInt64(info2.Size),
-                MimeType:     ptrString(contentType),
-                UploaderID:   nil,
-                IsDeleted:    false,
-                CreatedAt:    time.Now(),
-            }
-            if err := db.Create(rec).Error; err != nil {
-                skipped = append(skipped, rel)
-                _ = mc.RemoveObject(ctx, bucket, objectName, minio.RemoveObjectOptions{})
-                return nil
-            }
-            serverURL := buildServerDownloadURL(c, rec.ID)
-            _ = db.Model(rec).Update("url", serverURL).Error
-            rec.URL = serverURL
-            uploaded = append(uploaded, *rec)
-            return nil
-        })
-        if err != nil {
-            return nil, nil, fmt.Errorf("walk extracted files error: %v", err)
-        }
+// respondArchiveError 超出配额返回 413；无法识别、加密、损坏或超出解析限制的压缩包返回 400 及对应业务错误码；
+// 其余错误（存储、数据库、临时文件读写等）为服务端故障，返回 500。均附带跳过的条目，以及回滚失败、仍保留的文件
+func respondArchiveError(c *gin.Context, uploaded []entity.File, skipped []SkippedEntry, err error) {
+    data := gin.H{"uploaded": uploaded, "skipped": skipped}
+    if respondQuotaError(c, err, data) {
+        return
+    }
+    status, code := http.StatusBadRequest, 0
+    switch {
+    case errors.Is(err, errArchiveUnsupported):
+        code = response.CodeArchiveUnsupported
+    case errors.Is(err, errArchiveEncrypted):
+        code = response.CodeArchiveEncrypted
+    case errors.Is(err, errArchiveCorrupt):
+        code = response.CodeArchiveCorrupt
+    case errors.Is(err, errArchiveLimit):
+        code = response.CodeArchiveLimit
+    default:
+        status, code = http.StatusInternalServerError, http.StatusInternalServerError
     }
-    return uploaded, skipped, nil
+    c.JSON(status, gin.H{"code": code, "msg": err.Error(), "data": data})
 }
 
 // InitArchiveChunkUpload 初始化压缩包分块上传
 // @Summary 初始化压缩包分块上传
-// @Description 返回会话ID，后续使用 /api/v1/files/archive/multipart/chunk 上传分片
+// @Description 返回会话ID，后续使用 /api/v1/files/archive/multipart/chunk 上传分片；分片写入存储后端的暂存对象
 // @Tags Files
 // @Accept multipart/form-data
 // @Produce json
 // @Param bucket formData string true "Bucket 名称"
 // @Param filename formData string true "原始压缩包文件名"
 // @Param mime_type formData string false "MIME 类型"
+// @Param total_size formData int false "压缩包总大小（字节）"
+// @Param total_chunks formData int false "分片总数；提供后分片与完成请求的 total_chunks 须与之一致"
+// @Param checksum formData string false "整个文件摘要（hex 或 base64），完成时校验；也可在完成请求中提供"
+// @Param checksum_algorithm formData string false "摘要算法：sha256（默认）、md5、crc32c"
+// @Param tags formData string false "标签，应用到解压出的每个文件"
+// @Param metadata formData string false "自定义元数据（JSON 对象），应用到解压出的每个文件"
+// @Param folder formData string false "解压出的文件所在文件夹路径，不存在时逐级创建；与 folder_id 二选一"
+// @Param folder_id formData int false "解压出的文件所在文件夹 ID"
+// @Param preserve_paths formData bool false "解压时保留压缩包内的目录结构，默认 false"
 // @Success 200 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{} "超出最大对象大小（code 41301）或容量配额（code 41302）"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/archive/multipart/init [post]
 func InitArchiveChunkUpload(c *gin.Context) {
-    bucket := c.PostForm("bucket")
-    filename := c.PostForm("filename")
-    mimeType := c.PostForm("mime_type")
-    if bucket == "" || filename == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "bucket and filename are required"})
-        return
-    }
-    uploadID := uuid.New().String()
-    base := filepath.Join("cache", "uploads", uploadID)
-    if err := os.MkdirAll(base, os.ModePerm); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("init session error: %v", err)})
-        return
-    }
-    _ = os.WriteFile(filepath.Join(base, "meta.txt"), []byte(fmt.Sprintf("bucket=%s\nfilename=%s\nmime=%s\n", bucket, filename, mimeType)), os.ModePerm)
-    c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "ok", "data": gin.H{"upload_id": uploadID}})
+    // 压缩包先拼接为暂存对象，解析完成后删除
+    handleInit(c, sessionKindArchive, func(filename string) string {
+        return archiveStagingPrefix + objectNameFor(filename)
+    })
 }
 
-// UploadArchiveChunk 上传压缩包分片；当收到最后一个分片时进行合并并解析内容入库
+// UploadArchiveChunk 上传压缩包分片；分片可并发、乱序上传，全部到齐后自动解析内容入库
 // @Summary 上传压缩包分片
+// @Description 除最后一个分片外每片至少 5MiB；当所有分片到齐时自动完成解析，也可显式调用 /api/v1/files/archive/multipart/complete
 // @Tags Files
 // @Accept multipart/form-data
 // @Produce json
@@ -297,68 +285,95 @@ func InitArchiveChunkUpload(c *gin.Context) {
 // @Param chunk_index formData int true "当前分片序号（从1开始）"
 // @Param total_chunks formData int true "分片总数"
 // @Param chunk formData file true "分片文件"
-// @Param bucket formData string false "Bucket（可冗余）"
-// @Param filename formData string false "原始文件名（可冗余）"
+// @Param checksum formData string false "该分片的摘要（hex 或 base64），写入前校验，不一致返回 400，可重传该分片"
+// @Param checksum_algorithm formData string false "摘要算法：sha256（默认）、md5、crc32c"
 // @Success 200 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{} "超出最大对象大小（code 41301）或容量配额（code 41302）"
+// @Failure 409 {object} map[string]interface{} "会话已开始合并或已结束，本次分片未被记录"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/archive/multipart/chunk [post]
 func UploadArchiveChunk(c *gin.Context) {
-    uploadID := c.PostForm("upload_id")
-    chunkIndexStr := c.PostForm("chunk_index")
-    totalChunksStr := c.PostForm("total_chunks")
-    if uploadID == "" || chunkIndexStr == "" || totalChunksStr == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "upload_id, chunk_index, total_chunks are required"})
+    handleChunk(c, sessionKindArchive, finalizeArchiveUpload)
+}
+
+// CompleteArchiveChunkUpload 显式完成压缩包分块上传：校验全部分片到齐后解析内容入库
+// @Summary 完成压缩包分块上传
+// @Description 所有分片（可并发、乱序）上传完成后调用；缺少分片时返回 400 及缺失的分片序号
+// @Tags Files
+// @Accept multipart/form-data
+// @Produce json
+// @Param upload_id formData string true "初始化返回的会话ID"
+// @Param total_chunks formData int true "分片总数"
+// @Param checksum formData string false "整个文件摘要（hex 或 base64），覆盖初始化时声明的摘要；拼接后校验，不一致返回 400 并结束会话"
+// @Param checksum_algorithm formData string false "摘要算法：sha256（默认）、md5、crc32c"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "缺少分片、摘要不一致，或无法识别（code 40001）、已加密（code 40002）、损坏（code 40003）或超出解析限制（code 40004）的压缩包"
+// @Failure 409 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{} "超出最大对象大小（code 41301）或容量配额（code 41302）"
+// @Failure 500 {object} map[string]interface{} "存储、数据库等服务端错误（code 500）"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/archive/multipart/complete [post]
+func CompleteArchiveChunkUpload(c *gin.Context) {
+    handleComplete(c, sessionKindArchive, finalizeArchiveUpload)
+}
+
+// finalizeArchiveUpload 完成暂存对象的分块上传并解析入库，完成后删除暂存对象与会话；调用方需已持有合并锁
+func finalizeArchiveUpload(c *gin.Context, sess *entity.UploadSession, parts []storage.Part) {
+    db, store, ok := sessionDeps(c)
+    if !ok {
         return
     }
-    chunkIndex, _ := strconv.Atoi(chunkIndexStr)
-    totalChunks, _ := strconv.Atoi(totalChunksStr)
-    base := filepath.Join("cache", "uploads", uploadID)
-    if _, err := os.Stat(base); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "invalid upload_id"})
+    ctx := context.Background()
+    if _, err := store.CompleteMultipartUpload(ctx, sess.Bucket, sess.ObjectName, sess.StorageUploadID, parts); err != nil {
+        releaseCompleteLock(db, sess)
+        c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("complete multipart upload error: %v", err)})
         return
     }
-    fh, err := c.FormFile("chunk")
-    if err != nil { c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("chunk fetch error: %v", err)}); return }
-    src, err := fh.Open()
-    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk open error: %v", err)}); return }
-    defer src.Close()
-    partPath := filepath.Join(base, fmt.Sprintf("part_%06d", chunkIndex))
-    out, err := os.Create(partPath)
-    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk save error: %v", err)}); return }
-    if _, err := io.Copy(out, src); err != nil { _ = out.Close(); c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk write error: %v", err)}); return }
+    // 暂存对象已拼接完成，无论解析成败都结束会话
+    finalStatus := entity.UploadStatusCompleted
+    defer func() { finishSession(db, sess, finalStatus) }()
+    defer store.RemoveObject(ctx, sess.Bucket, sess.ObjectName)
+
+    // zip/7z 需要随机读取，将暂存对象下载到临时目录后解析；tar 系列从该文件顺序流式读取
+    workDir, err := os.MkdirTemp("", "upload-archive-")
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("create temp dir error: %v", err)})
+        return
+    }
+    defer os.RemoveAll(workDir)
+    tmpFile := filepath.Join(workDir, "archive"+filepath.Ext(sess.ObjectName))
+    obj, err := store.GetObject(ctx, sess.Bucket, sess.ObjectName, storage.GetOptions{})
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("get object error: %v", err)})
+        return
+    }
+    out, err := os.Create(tmpFile)
+    if err != nil {
+        _ = obj.Close()
+        c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("create temp file error: %v", err)})
+        return
+    }
+    digest := newDigester(sessionChecksum(sess))
+    _, err = io.Copy(io.MultiWriter(out, digest), obj)
+    _ = obj.Close()
     _ = out.Close()
-    if chunkIndex < totalChunks {
-        c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "chunk received", "data": gin.H{"received": chunkIndex, "total": totalChunks}})
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("write temp file error: %v", err)})
+        return
+    }
+    // 解析前校验压缩包摘要
+    if err := digest.Verify(); err != nil {
+        finalStatus = entity.UploadStatusAborted
+        respondChecksumError(c, err)
         return
     }
-    // 合并
-    mergedPath := filepath.Join(base, "merged.tmp")
-    merged, err := os.Create(mergedPath)
-    if err != nil { c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("merge create error: %v", err)}); return }
-    for i := 1; i <= totalChunks; i++ {
-        p := filepath.Join(base, fmt.Sprintf("part_%06d", i))
-        part, err := os.Open(p)
-        if err != nil { _ = merged.Close(); c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("open part error: %v", err)}); return }
-        if _, err := io.Copy(merged, part); err != nil { _ = part.Close(); _ = merged.Close(); c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("merge write error: %v", err)}); return }
-        _ = part.Close()
-    }
-    if _, err := merged.Seek(0, io.SeekStart); err != nil { _ = merged.Close(); c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("merge seek error: %v", err)}); return }
-    _ = merged.Close()
-
-    // 读取元信息
-    metaBytes, _ := os.ReadFile(filepath.Join(base, "meta.txt"))
-    meta := parseMeta(string(metaBytes))
-    bucket := meta["bucket"]
-    filename := meta["filename"]
-    if bucket == "" { bucket = c.PostForm("bucket") }
-    if filename == "" { filename = c.PostForm("filename") }
 
-    uploaded, skipped, err := processArchiveFile(c, bucket, mergedPath, filename, base)
-    // 清理临时目录
-    _ = os.Remove(mergedPath)
-    _ = os.RemoveAll(base)
+    uploaded, skipped, err := processArchiveFile(c, sess.Bucket, tmpFile, sess.Filename, sess.OwnerID, sess.FolderID, fileLabels{Tags: sess.Tags, Metadata: sess.Metadata}, sess.PreservePaths)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+        respondArchiveError(c, uploaded, skipped, err)
         return
     }
     c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": gin.H{"uploaded": uploaded, "skipped": skipped}})
-}
\ No newline at end of file
+}
@@ -0,0 +1,295 @@
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// 按魔数识别的压缩包格式
+const (
+	archiveFormatZip      = "zip"
+	archiveFormatSevenZip = "7z"
+	archiveFormatTar      = "tar"
+	archiveFormatGzip     = "gzip"
+	archiveFormatBzip2    = "bzip2"
+	archiveFormatXz       = "xz"
+	archiveFormatZstd     = "zstd"
+)
+
+// 压缩包整体无法解析时返回的错误，由 respondArchiveError 映射为业务错误码
+var (
+	errArchiveUnsupported = errors.New("unsupported archive format")
+	errArchiveEncrypted   = errors.New("archive is encrypted")
+	errArchiveCorrupt     = errors.New("archive is corrupt")
+	// errArchiveLimit 条目数或解压总量超出 [archive] 配置的限制
+	errArchiveLimit = errors.New("archive exceeds limits")
+)
+
+// tarBlockSize tar 头部块大小，ustar 魔数位于偏移 257
+const tarBlockSize = 512
+
+var archiveMagics = []struct {
+	format string
+	magic  []byte
+}{
+	{archiveFormatZip, []byte("PK\x03\x04")},
+	{archiveFormatZip, []byte("PK\x05\x06")}, // 空 zip
+	{archiveFormatSevenZip, []byte{0x37, 0x7A, 0xBC, 0xAF, 0x27, 0x1C}},
+	{archiveFormatGzip, []byte{0x1F, 0x8B}},
+	{archiveFormatBzip2, []byte("BZh")},
+	{archiveFormatXz, []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}},
+	{archiveFormatZstd, []byte{0x28, 0xB5, 0x2F, 0xFD}},
+}
+
+// compressedSuffixes 单文件压缩流去掉后缀即为原文件名
+var compressedSuffixes = []string{".gz", ".gzip", ".bz2", ".xz", ".zst", ".zstd"}
+
+// detectArchiveFormat 读取文件头部的魔数识别压缩包格式；无压缩的 tar 通过头部块校验和识别
+func detectArchiveFormat(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, tarBlockSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+	for _, m := range archiveMagics {
+		if bytes.HasPrefix(head, m.magic) {
+			return m.format, nil
+		}
+	}
+	if isTarHeader(head) {
+		return archiveFormatTar, nil
+	}
+	return "", errArchiveUnsupported
+}
+
+// isTarHeader 校验 tar 头部块：按规范将校验和字段视为空格后，所有字节之和须与记录的校验和一致
+func isTarHeader(block []byte) bool {
+	if len(block) < tarBlockSize {
+		return false
+	}
+	field := strings.Trim(string(block[148:156]), " \x00")
+	want, err := strconv.ParseInt(field, 8, 64)
+	if err != nil {
+		return false
+	}
+	var unsigned, signed int64
+	for i, b := range block[:tarBlockSize] {
+		if i >= 148 && i < 156 {
+			b = ' '
+		}
+		unsigned += int64(b)
+		signed += int64(int8(b))
+	}
+	return want == unsigned || want == signed
+}
+
+// openDecompressor 按格式包装解压流
+func openDecompressor(format string, r io.Reader) (io.ReadCloser, error) {
+	switch format {
+	case archiveFormatGzip:
+		return gzip.NewReader(r)
+	case archiveFormatBzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case archiveFormatXz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case archiveFormatZstd:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported compression %q", format)
+}
+
+// ingestZip 按中央目录逐个解压 zip 条目并直接写入存储；zip 记录每个条目的压缩后大小，可逐条目校验压缩比
+func ingestZip(ingest *archiveIngest, tmpFile string) error {
+	zr, err := zip.OpenReader(tmpFile)
+	if err != nil {
+		return fmt.Errorf("%w: bad zip: %v", errArchiveCorrupt, err)
+	}
+	defer zr.Close()
+	if err := ingest.checkEntryCount(len(zr.File)); err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = ingest.addDir(f.Name)
+		case mode&os.ModeSymlink != 0:
+			err = ingest.reject(f.Name, skipReasonSymlink, nil)
+		case !mode.IsRegular():
+			err = ingest.reject(f.Name, skipReasonUnsupportedEntry, fmt.Errorf("zip entry mode %v", mode))
+		case f.Flags&0x1 != 0:
+			// 通用标志位 bit 0 表示条目已加密，archive/zip 不支持解密
+			err = ingest.reject(f.Name, skipReasonEncrypted, nil)
+		default:
+			// 解压时 archive/zip 校验实际字节数与目录中记录的大小一致，不会超出已计入限制的 UncompressedSize64
+			err = ingest.add(f.Name, int64(f.UncompressedSize64), int64(f.CompressedSize64), f.Open)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ingestCompressed 边解压边入库：内容为 tar 时逐个条目写入存储，否则作为单个文件写入，均不落地到磁盘
+func ingestCompressed(ingest *archiveIngest, tmpFile, format, originalFilename string) error {
+	f, err := os.Open(tmpFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if format == archiveFormatTar {
+		return ingestTar(ingest, tar.NewReader(bufio.NewReader(f)))
+	}
+	rc, err := openDecompressor(format, bufio.NewReader(f))
+	if err != nil {
+		return fmt.Errorf("%w: bad %s stream: %v", errArchiveCorrupt, format, err)
+	}
+	defer rc.Close()
+	br := bufio.NewReaderSize(rc, tarBlockSize*8)
+	// 解压后的首个块为合法 tar 头部时按 tar 处理（tar.gz、tar.zst 等）
+	if head, _ := br.Peek(tarBlockSize); isTarHeader(head) {
+		return ingestTar(ingest, tar.NewReader(br))
+	}
+	var headerName string
+	if gz, ok := rc.(*gzip.Reader); ok {
+		headerName = gz.Name
+	}
+	name := compressedEntryName(originalFilename, headerName)
+	return ingest.add(name, -1, -1, func() (io.ReadCloser, error) { return io.NopCloser(br), nil })
+}
+
+// ingestTar 顺序读取 tar 条目：目录创建为文件夹，普通文件直接从 tar 流写入存储，链接与其余类型（设备等）记入 skipped；
+// tar 条目内容的长度由头部决定，读取器不会返回超出 hdr.Size 的数据
+func ingestTar(ingest *archiveIngest, tr *tar.Reader) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: bad tar: %v", errArchiveCorrupt, err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = ingest.addDir(hdr.Name)
+		case tar.TypeReg:
+			err = ingest.add(hdr.Name, hdr.Size, -1, func() (io.ReadCloser, error) { return io.NopCloser(tr), nil })
+		case tar.TypeSymlink, tar.TypeLink:
+			err = ingest.reject(hdr.Name, skipReasonSymlink, fmt.Errorf("link to %q", hdr.Linkname))
+		case tar.TypeXGlobalHeader:
+			// PAX 全局头仅包含元信息
+		default:
+			err = ingest.reject(hdr.Name, skipReasonUnsupportedEntry, fmt.Errorf("tar entry type %q", hdr.Typeflag))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ingestSevenZip 按在压缩包中的顺序逐个解压 7z 条目并直接写入存储，固实压缩块的解码器由读取器缓存复用，不落地解压结果
+func ingestSevenZip(ingest *archiveIngest, tmpFile string) error {
+	zr, err := sevenzip.OpenReader(tmpFile)
+	if err != nil {
+		return sevenZipError(err)
+	}
+	defer zr.Close()
+	if err := ingest.checkEntryCount(len(zr.File)); err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		// 兼容以 \ 分隔目录的压缩包
+		name := strings.ReplaceAll(f.Name, "\\", "/")
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = ingest.addDir(name)
+		case mode&fs.ModeSymlink != 0:
+			err = ingest.reject(name, skipReasonSymlink, nil)
+		case mode.IsRegular():
+			// 条目读取器最多返回 UncompressedSize 字节
+			open := func() (io.ReadCloser, error) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, sevenZipError(err)
+				}
+				return &sevenZipEntry{ReadCloser: rc}, nil
+			}
+			err = ingest.add(name, int64(f.UncompressedSize), -1, open)
+		default:
+			err = ingest.reject(name, skipReasonUnsupportedEntry, fmt.Errorf("7z entry mode %v", mode))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sevenZipEntry 将条目读取过程中的错误转换为 errArchiveEncrypted / errArchiveCorrupt
+type sevenZipEntry struct {
+	io.ReadCloser
+}
+
+func (e *sevenZipEntry) Read(p []byte) (int, error) {
+	n, err := e.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = sevenZipError(err)
+	}
+	return n, err
+}
+
+// sevenZipError 区分加密（未提供密码）与损坏的 7z 压缩包
+func sevenZipError(err error) error {
+	var re *sevenzip.ReadError
+	if errors.As(err, &re) && re.Encrypted {
+		return fmt.Errorf("%w: %v", errArchiveEncrypted, err)
+	}
+	return fmt.Errorf("%w: %v", errArchiveCorrupt, err)
+}
+
+// compressedEntryName 单文件压缩流的文件名：优先使用 gzip 头部记录的原文件名，否则去掉上传文件名的压缩后缀
+func compressedEntryName(originalFilename, headerName string) string {
+	if headerName != "" {
+		return path.Base(headerName)
+	}
+	base := path.Base(strings.ReplaceAll(originalFilename, "\\", "/"))
+	lower := strings.ToLower(base)
+	for _, suffix := range compressedSuffixes {
+		if strings.HasSuffix(lower, suffix) && len(base) > len(suffix) {
+			return base[:len(base)-len(suffix)]
+		}
+	}
+	return base
+}
@@ -0,0 +1,102 @@
+package file
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tarHeaderBlock 使用 archive/tar 生成一个合法的 tar 头部块
+func tarHeaderBlock(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "a.txt", Mode: 0o644, Size: 0, Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()[:tarBlockSize]
+}
+
+func TestIsTarHeader(t *testing.T) {
+	block := tarHeaderBlock(t)
+	if !isTarHeader(block) {
+		t.Fatal("valid tar header not recognised")
+	}
+	if isTarHeader(block[:tarBlockSize-1]) {
+		t.Error("short block recognised as tar")
+	}
+	if isTarHeader(make([]byte, tarBlockSize)) {
+		t.Error("zero block recognised as tar")
+	}
+	tampered := append([]byte(nil), block...)
+	tampered[0] ^= 0xFF
+	if isTarHeader(tampered) {
+		t.Error("block with bad checksum recognised as tar")
+	}
+}
+
+func TestDetectArchiveFormat(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{"a.zip", []byte("PK\x03\x04rest"), archiveFormatZip},
+		{"empty.zip", []byte("PK\x05\x06"), archiveFormatZip},
+		{"a.7z", []byte{0x37, 0x7A, 0xBC, 0xAF, 0x27, 0x1C, 0x00}, archiveFormatSevenZip},
+		{"a.gz", []byte{0x1F, 0x8B, 0x08}, archiveFormatGzip},
+		{"a.bz2", []byte("BZh91AY"), archiveFormatBzip2},
+		{"a.xz", []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}, archiveFormatXz},
+		{"a.zst", []byte{0x28, 0xB5, 0x2F, 0xFD}, archiveFormatZstd},
+		{"a.tar", tarHeaderBlock(t), archiveFormatTar},
+	}
+	for _, tt := range tests {
+		p := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(p, tt.head, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := detectArchiveFormat(p)
+		if err != nil || got != tt.want {
+			t.Errorf("detectArchiveFormat(%s) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	for name, data := range map[string][]byte{"plain.txt": []byte("hello world"), "empty": nil} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := detectArchiveFormat(p); !errors.Is(err, errArchiveUnsupported) {
+			t.Errorf("detectArchiveFormat(%s) error = %v, want errArchiveUnsupported", name, err)
+		}
+	}
+}
+
+func TestCompressedEntryName(t *testing.T) {
+	tests := []struct {
+		original, header, want string
+	}{
+		{"report.csv.gz", "", "report.csv"},
+		{"REPORT.CSV.GZ", "", "REPORT.CSV"},
+		{"data.tar.zst", "", "data.tar"},
+		{"logs.xz", "", "logs"},
+		{"dir/sub/notes.bz2", "", "notes"},
+		{"C:\\Users\\me\\notes.gzip", "", "notes"},
+		{".gz", "", ".gz"},
+		{"plain", "", "plain"},
+		{"upload.gz", "original.txt", "original.txt"},
+		{"upload.gz", "../../etc/passwd", "passwd"},
+	}
+	for _, tt := range tests {
+		if got := compressedEntryName(tt.original, tt.header); got != tt.want {
+			t.Errorf("compressedEntryName(%q, %q) = %q, want %q", tt.original, tt.header, got, tt.want)
+		}
+	}
+}
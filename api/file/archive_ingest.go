@@ -0,0 +1,430 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxObjectKeyLength 对象 key 的最大字节数（S3 限制）
+const maxObjectKeyLength = 1024
+
+// ratioGraceBytes 解压量不超过该值时不校验压缩比
+const ratioGraceBytes = 1 << 20
+
+// 压缩包条目的跳过原因
+const (
+	skipReasonNestedDirectory  = "nested_directory"  // 未开启 preserve_paths 时位于多级目录中
+	skipReasonInvalidPath      = "invalid_path"      // 路径为空，或目录 / 文件名不合法
+	skipReasonTooLarge         = "too_large"         // 超过单对象大小上限
+	skipReasonReadError        = "read_error"        // 读取条目失败
+	skipReasonStorageError     = "storage_error"     // 写入对象存储失败
+	skipReasonDatabaseError    = "database_error"    // 创建文件夹或文件记录失败
+	skipReasonUnsupportedEntry = "unsupported_entry" // 链接、设备文件等非普通文件条目
+	skipReasonEncrypted        = "encrypted"         // 条目已加密，不支持解密
+	skipReasonPathTraversal    = "path_traversal"    // 绝对路径或包含 .. 的路径
+	skipReasonSymlink          = "symlink"           // 符号链接或硬链接
+	skipReasonCompressionRatio = "compression_ratio" // 压缩比超过 archive.max_ratio
+)
+
+// SkippedEntry 压缩包中未入库的条目
+type SkippedEntry struct {
+	Name   string `json:"name"`             // 条目在压缩包内的路径
+	Reason string `json:"reason"`           // 跳过原因：nested_directory、invalid_path、too_large、read_error、storage_error、database_error、unsupported_entry、encrypted、path_traversal、symlink、compression_ratio
+	Detail string `json:"detail,omitempty"` // 错误详情
+}
+
+// archiveIngest 逐个将压缩包条目写入对象存储并创建文件记录，汇总已入库与跳过的条目
+type archiveIngest struct {
+	c        *gin.Context
+	ctx      context.Context
+	db       *gorm.DB
+	store    storage.ObjectStore
+	tracker  *service.QuotaTracker
+	bucket   string
+	ownerID  *uint64
+	folderID *uint64
+	labels   fileLabels
+	// preserve 为 true 时保留任意深度的目录结构：目录创建为 base 下的子文件夹，并作为对象 key 前缀
+	preserve bool
+	base     *entity.Folder
+	folders  map[string]*uint64
+	// limits 压缩包解析限制；maxTotal 为按 MaxTotalSize 与 MaxRatio × 压缩包大小得出的解压总量上限，0 表示不限制
+	limits   config.ArchiveConfig
+	maxTotal int64
+	entries  int
+	total    int64
+
+	uploaded []entity.File
+	skipped  []SkippedEntry
+}
+
+// archiveConfig 从 Context 中获取压缩包解析限制，未注入配置时使用默认值
+func archiveConfig(c *gin.Context) config.ArchiveConfig {
+	if v, ok := c.Get("config"); ok {
+		if cfg, ok := v.(*config.Config); ok && cfg != nil {
+			return cfg.Archive
+		}
+	}
+	return config.Default().Archive
+}
+
+// setLimits 设置解析限制，archiveSize 为压缩包文件大小
+func (a *archiveIngest) setLimits(limits config.ArchiveConfig, archiveSize int64) {
+	a.limits = limits
+	a.maxTotal = limits.MaxTotalSize
+	if limits.MaxRatio > 0 {
+		ratioCap := limits.MaxRatio * archiveSize
+		if ratioCap < ratioGraceBytes {
+			ratioCap = ratioGraceBytes
+		}
+		if a.maxTotal <= 0 || ratioCap < a.maxTotal {
+			a.maxTotal = ratioCap
+		}
+	}
+}
+
+// checkEntryCount 预先校验条目总数（zip / 7z 的目录表），避免部分入库后才中止
+func (a *archiveIngest) checkEntryCount(n int) error {
+	if a.limits.MaxEntries > 0 && n > a.limits.MaxEntries {
+		return fmt.Errorf("%w: more than %d entries", errArchiveLimit, a.limits.MaxEntries)
+	}
+	return nil
+}
+
+// nextEntry 对每个条目（含目录与被拒绝的条目）计数，超出 MaxEntries 时中止
+func (a *archiveIngest) nextEntry() error {
+	a.entries++
+	return a.checkEntryCount(a.entries)
+}
+
+// reserveTotal 将 size 计入解压总量，超出上限时中止
+func (a *archiveIngest) reserveTotal(size int64) error {
+	if a.maxTotal > 0 && a.total+size > a.maxTotal {
+		return fmt.Errorf("%w: uncompressed size exceeds %d bytes", errArchiveLimit, a.maxTotal)
+	}
+	a.total += size
+	return nil
+}
+
+// reject 计数并记录被拒绝的条目（链接、不支持的类型等）
+func (a *archiveIngest) reject(name, reason string, err error) error {
+	if err := a.nextEntry(); err != nil {
+		return err
+	}
+	a.skip(name, reason, err)
+	return nil
+}
+
+// unsafeEntryPath 判断条目路径是否为绝对路径（含 Windows 盘符）或包含 ..（/ 与 \ 均视为分隔符）
+func unsafeEntryPath(name string) bool {
+	normalized := strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(normalized, "/") {
+		return true
+	}
+	if len(normalized) >= 2 && normalized[1] == ':' && unicode.IsLetter(rune(normalized[0])) && normalized[0] < utf8.RuneSelf {
+		return true
+	}
+	for _, s := range strings.Split(normalized, "/") {
+		if strings.TrimSpace(s) == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePreservePaths 解析表单字段 preserve_paths，缺省为 false
+func parsePreservePaths(c *gin.Context) (bool, error) {
+	v := c.PostForm("preserve_paths")
+	if v == "" {
+		return false, nil
+	}
+	preserve, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid preserve_paths")
+	}
+	return preserve, nil
+}
+
+// skip 记录跳过的条目
+func (a *archiveIngest) skip(name, reason string, err error) {
+	entry := SkippedEntry{Name: name, Reason: reason}
+	if err != nil {
+		entry.Detail = err.Error()
+	}
+	a.skipped = append(a.skipped, entry)
+}
+
+// splitEntryPath 按 / 拆分条目路径，去除各级首尾空白并忽略空的层级与 .（tar 常见的 ./ 前缀）
+func splitEntryPath(name string) []string {
+	segments := make([]string, 0, 4)
+	for _, s := range strings.Split(name, "/") {
+		if s = strings.TrimSpace(s); s != "" && s != "." {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// entryFolder 返回目录 dir 对应的文件夹 ID，必要时逐级创建；失败时记录跳过原因并返回 false
+func (a *archiveIngest) entryFolder(name string, dir []string) (*uint64, bool) {
+	if !a.preserve || len(dir) == 0 {
+		return a.folderID, true
+	}
+	path := strings.Join(dir, "/")
+	if id, ok := a.folders[path]; ok {
+		return id, true
+	}
+	f, err := service.EnsureSubfolderPath(a.db, a.bucket, a.base, path, a.ownerID)
+	if err != nil {
+		reason := skipReasonDatabaseError
+		if errors.Is(err, service.ErrInvalidFolder) {
+			reason = skipReasonInvalidPath
+		}
+		a.skip(name, reason, err)
+		return nil, false
+	}
+	a.folders[path] = &f.ID
+	return &f.ID, true
+}
+
+// addDir 处理目录条目：保留目录结构时创建对应文件夹（含空目录），否则忽略
+func (a *archiveIngest) addDir(name string) error {
+	if err := a.nextEntry(); err != nil {
+		return err
+	}
+	if unsafeEntryPath(name) {
+		a.skip(name, skipReasonPathTraversal, nil)
+		return nil
+	}
+	if a.preserve {
+		a.entryFolder(name, splitEntryPath(name))
+	}
+	return nil
+}
+
+// add 将一个文件条目写入对象存储并创建文件记录；size 为 -1 表示大小未知（单文件压缩流），写入后再计入配额与解压总量；
+// compressed 为条目压缩后的大小，-1 表示未知（tar、7z 仅校验整个压缩包的压缩比）。
+// 条目本身的问题记入 skipped，仅在超出 Bucket / 用户配额或压缩包限制等需要中止整个压缩包时返回错误
+func (a *archiveIngest) add(name string, size, compressed int64, open func() (io.ReadCloser, error)) error {
+	if err := a.nextEntry(); err != nil {
+		return err
+	}
+	if unsafeEntryPath(name) {
+		a.skip(name, skipReasonPathTraversal, nil)
+		return nil
+	}
+	segments := splitEntryPath(name)
+	if len(segments) == 0 {
+		a.skip(name, skipReasonInvalidPath, errors.New("empty path"))
+		return nil
+	}
+	if !a.preserve && len(segments) > 2 {
+		a.skip(name, skipReasonNestedDirectory, nil)
+		return nil
+	}
+	dir, base := segments[:len(segments)-1], segments[len(segments)-1]
+	objectName := objectNameFor(base)
+	if a.preserve {
+		if err := service.ValidateFolderName(base); err != nil {
+			a.skip(name, skipReasonInvalidPath, err)
+			return nil
+		}
+		if len(dir) > 0 {
+			objectName = strings.Join(dir, "/") + "/" + objectName
+		}
+		if len(objectName) > maxObjectKeyLength {
+			a.skip(name, skipReasonInvalidPath, fmt.Errorf("object key exceeds %d bytes", maxObjectKeyLength))
+			return nil
+		}
+	}
+	folderID, ok := a.entryFolder(name, dir)
+	if !ok {
+		return nil
+	}
+	// reserved 为已计入配额的字节数，条目未能入库时归还，避免后续条目被误判超出配额
+	var reserved int64
+	stored := false
+	defer func() {
+		if !stored {
+			a.tracker.Release(reserved)
+		}
+	}()
+	if size >= 0 {
+		if a.limits.MaxEntrySize > 0 && size > a.limits.MaxEntrySize {
+			a.skip(name, skipReasonTooLarge, fmt.Errorf("exceeds archive.max_entry_size %d", a.limits.MaxEntrySize))
+			return nil
+		}
+		if a.limits.MaxRatio > 0 && compressed >= 0 && size > ratioGraceBytes && size > a.limits.MaxRatio*compressed {
+			a.skip(name, skipReasonCompressionRatio, fmt.Errorf("compression ratio exceeds %d", a.limits.MaxRatio))
+			return nil
+		}
+		if err := a.reserveTotal(size); err != nil {
+			return err
+		}
+		if skip, err := reserveEntry(a.tracker, size); err != nil {
+			return err
+		} else if skip {
+			a.skip(name, skipReasonTooLarge, nil)
+			return nil
+		}
+		reserved = size
+	}
+
+	rc, err := open()
+	if err != nil {
+		a.skip(name, entrySkipReason(err), err)
+		return nil
+	}
+	defer rc.Close()
+	src := &entrySource{r: rc}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(src, head)
+	contentType := safeContentType(http.DetectContentType(head[:n]))
+	digest := newDigester(nil)
+	var reader io.Reader = io.MultiReader(bytes.NewReader(head[:n]), src)
+	if limit := a.streamLimit(); size < 0 && limit >= 0 {
+		// 大小未知时最多读取上限 + 1 字节，超出部分不再写入存储
+		reader = io.LimitReader(reader, limit+1)
+	}
+	reader = io.TeeReader(reader, digest)
+
+	info, err := a.store.PutObject(a.ctx, a.bucket, objectName, reader, size, a.labels.putOptions(a.c, a.bucket, contentType))
+	if src.err != nil {
+		// 解压失败导致的写入失败按读取错误上报；写入可能已成功（读取器提前结束），需删除不完整的对象
+		if err == nil {
+			_ = a.store.RemoveObject(a.ctx, a.bucket, objectName)
+		}
+		a.skip(name, entrySkipReason(src.err), src.err)
+		return nil
+	}
+	if err != nil {
+		a.skip(name, skipReasonStorageError, err)
+		return nil
+	}
+	if size < 0 {
+		if a.limits.MaxEntrySize > 0 && info.Size > a.limits.MaxEntrySize {
+			_ = a.store.RemoveObject(a.ctx, a.bucket, objectName)
+			a.skip(name, skipReasonTooLarge, fmt.Errorf("exceeds archive.max_entry_size %d", a.limits.MaxEntrySize))
+			return nil
+		}
+		if err := a.reserveTotal(info.Size); err != nil {
+			_ = a.store.RemoveObject(a.ctx, a.bucket, objectName)
+			return err
+		}
+		if skip, err := reserveEntry(a.tracker, info.Size); err != nil || skip {
+			_ = a.store.RemoveObject(a.ctx, a.bucket, objectName)
+			if err != nil {
+				return err
+			}
+			a.skip(name, skipReasonTooLarge, nil)
+			return nil
+		}
+		reserved = info.Size
+	}
+	objectName, err = registerObject(a.c, a.db, a.store, a.bucket, objectName, digest.SHA256(), info.Size)
+	if err != nil {
+		a.skip(name, skipReasonDatabaseError, err)
+		return nil
+	}
+
+	originalName := base
+	rec := &entity.File{
+		Bucket:       a.bucket,
+		ObjectName:   objectName,
+		OriginalName: &originalName,
+		URL:          "",
+		Size:         ptrInt64(info.Size),
+		MimeType:     ptrString(contentType),
+		UploaderID:   a.ownerID,
+		FolderID:     folderID,
+		SHA256:       ptrString(digest.SHA256()),
+		Tags:         a.labels.Tags,
+		Metadata:     a.labels.Metadata,
+		IsDeleted:    false,
+		CreatedAt:    time.Now(),
+	}
+	if err := service.CreateFileWithQuota(a.db, a.tracker.Limits, rec); err != nil {
+		_ = service.ReleaseObject(a.ctx, a.db, a.store, a.bucket, objectName)
+		var qe *service.QuotaError
+		if errors.As(err, &qe) {
+			// 并发上传已占用配额，与 reserveEntry 超出配额时一样停止解析
+			return err
+		}
+		a.skip(name, skipReasonDatabaseError, err)
+		return nil
+	}
+	serverURL := buildServerDownloadURL(a.c, rec.ID)
+	_ = a.db.Model(rec).Update("url", serverURL).Error
+	rec.URL = serverURL
+	a.uploaded = append(a.uploaded, *rec)
+	stored = true
+	return nil
+}
+
+// rollback 中止解析时删除本次已入库的文件记录并释放对应对象（去重对象按引用计数释放），
+// 回滚失败的文件仍保留在 uploaded 中；已创建的文件夹不做回滚
+func (a *archiveIngest) rollback() {
+	kept := a.uploaded[:0]
+	for _, rec := range a.uploaded {
+		if err := a.db.Delete(&entity.File{}, rec.ID).Error; err != nil {
+			kept = append(kept, rec)
+			continue
+		}
+		_ = service.ReleaseObject(a.ctx, a.db, a.store, a.bucket, rec.ObjectName)
+	}
+	a.uploaded = kept
+}
+
+// streamLimit 大小未知的条目最多写入的字节数：取单对象上限、单条目上限与剩余解压总量中的最小值，-1 表示不限制
+func (a *archiveIngest) streamLimit() int64 {
+	limit := int64(-1)
+	for _, v := range []int64{a.tracker.Limits.MaxObjectSize, a.limits.MaxEntrySize} {
+		if v > 0 && (limit < 0 || v < limit) {
+			limit = v
+		}
+	}
+	if a.maxTotal > 0 {
+		if remaining := a.maxTotal - a.total; limit < 0 || remaining < limit {
+			limit = remaining
+		}
+	}
+	return limit
+}
+
+// entrySource 记录读取条目内容时发生的错误，用于区分解压失败与存储写入失败
+type entrySource struct {
+	r   io.Reader
+	err error
+}
+
+func (e *entrySource) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
+
+// entrySkipReason 读取条目失败时的跳过原因
+func entrySkipReason(err error) string {
+	if errors.Is(err, errArchiveEncrypted) {
+		return skipReasonEncrypted
+	}
+	return skipReasonReadError
+}
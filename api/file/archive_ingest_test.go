@@ -0,0 +1,53 @@
+package file
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnsafeEntryPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"/etc/passwd", true},
+		{"\\windows\\system32", true},
+		{"C:/x", true},
+		{"c:\\x", true},
+		{"..", true},
+		{"../a", true},
+		{"a/../b", true},
+		{"a\\..\\b", true},
+		{"a/ .. /b", true},
+		{"a/b.txt", false},
+		{"./a/b.txt", false},
+		{"a..b", false},
+		{"a/...", false},
+		{"1:x", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := unsafeEntryPath(tt.name); got != tt.want {
+			t.Errorf("unsafeEntryPath(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitEntryPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"", []string{}},
+		{"a.txt", []string{"a.txt"}},
+		{"./docs/a.txt", []string{"docs", "a.txt"}},
+		{"docs//2024/ a.txt ", []string{"docs", "2024", "a.txt"}},
+		{"docs/./a.txt", []string{"docs", "a.txt"}},
+		{"docs/", []string{"docs"}},
+	}
+	for _, tt := range tests {
+		if got := splitEntryPath(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitEntryPath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
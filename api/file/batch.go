@@ -0,0 +1,645 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/model/response"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxBatchSize 单次批量操作最多处理的文件数
+const maxBatchSize = 1000
+
+var (
+	// errBatchAborted atomic 批量操作中有文件失败，全部修改已回滚
+	errBatchAborted = errors.New("rolled back: another file in the atomic batch failed")
+	errFileGone     = errors.New("file not found")
+	errFileDeleted  = errors.New("file is deleted")
+)
+
+// BatchRequest 批量操作的文件 ID；atomic 为 true 时任一文件失败则全部回滚，否则仅跳过失败的文件
+type BatchRequest struct {
+	IDs    []uint64 `json:"ids" binding:"required"`
+	Atomic bool     `json:"atomic"`
+}
+
+// BatchMoveRequest 批量移动文件到目标 Bucket 的文件夹中
+type BatchMoveRequest struct {
+	BatchRequest
+	Bucket   string  `json:"bucket" binding:"required"` // 目标 Bucket，可与文件所在 Bucket 相同
+	FolderID *uint64 `json:"folder_id"`                 // 目标 Bucket 中的文件夹，为空表示根目录
+}
+
+// BatchTagRequest 批量增删标签，先移除 remove 中的标签再追加 add 中的标签
+type BatchTagRequest struct {
+	BatchRequest
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// BatchItemResult 单个文件的处理结果，成功时 file 为处理后的文件信息
+type BatchItemResult struct {
+	ID    uint64       `json:"id"`
+	OK    bool         `json:"ok"`
+	Error string       `json:"error,omitempty"`
+	File  *entity.File `json:"file,omitempty"`
+}
+
+// BatchResult 批量操作结果，items 与请求中的 ids 顺序一致（重复的 ID 只处理一次）
+type BatchResult struct {
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Items     []BatchItemResult `json:"items"`
+}
+
+// batchOp 在事务 tx 中处理单个文件，并将 rec 修改为处理后的状态；返回错误时该文件的数据库修改回滚到保存点
+type batchOp func(tx *gorm.DB, rec *entity.File) error
+
+// runBatch 加载 ids 对应的文件并校验权限，在同一事务中逐个调用 op：单个文件失败只回滚该文件，atomic 时整体回滚。
+// 请求无效时写入 400 响应并返回 false
+func runBatch(c *gin.Context, db *gorm.DB, req *BatchRequest, need string, op batchOp) (*BatchResult, bool) {
+	result, byID, ok := loadBatch(c, db, req, need)
+	if ok {
+		applyBatch(db, req, result, byID, op)
+	}
+	return result, ok
+}
+
+// loadBatch 去重并加载 ids 对应的文件、校验权限，不存在或无权限的文件直接记为失败。
+// 需要在事务外先行处理存储（如复制对象）的操作在 loadBatch 与 applyBatch 之间进行；请求无效时写入 400 响应并返回 false
+func loadBatch(c *gin.Context, db *gorm.DB, req *BatchRequest, need string) (*BatchResult, map[uint64]*entity.File, bool) {
+	ids := make([]uint64, 0, len(req.IDs))
+	seen := map[uint64]bool{}
+	for _, id := range req.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ids is required"})
+		return nil, nil, false
+	}
+	if len(ids) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("too many ids: at most %d per batch", maxBatchSize)})
+		return nil, nil, false
+	}
+	var recs []entity.File
+	if err := db.Where("id IN ?", ids).Find(&recs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("query error: %v", err)})
+		return nil, nil, false
+	}
+	byID := make(map[uint64]*entity.File, len(recs))
+	for i := range recs {
+		byID[recs[i].ID] = &recs[i]
+	}
+
+	// 权限在开启事务前校验，避免事务占用连接期间再查询授权记录
+	result := &BatchResult{Items: make([]BatchItemResult, len(ids))}
+	for i, id := range ids {
+		item := &result.Items[i]
+		item.ID = id
+		if rec, ok := byID[id]; !ok {
+			item.Error = errFileGone.Error()
+		} else if !fileAllowed(c, rec, need) {
+			item.Error = "forbidden"
+		}
+	}
+	return result, byID, true
+}
+
+// applyBatch 在同一事务中对尚未失败的文件逐个调用 op：单个文件失败只回滚该文件，atomic 时任一文件失败则整体回滚
+func applyBatch(db *gorm.DB, req *BatchRequest, result *BatchResult, byID map[uint64]*entity.File, op batchOp) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range result.Items {
+			item := &result.Items[i]
+			if item.Error == "" {
+				rec := *byID[item.ID]
+				if err := tx.Transaction(func(stx *gorm.DB) error { return op(stx, &rec) }); err != nil {
+					item.Error = err.Error()
+				} else {
+					item.OK, item.File = true, &rec
+				}
+			}
+			if !item.OK && req.Atomic {
+				return errBatchAborted
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		failBatch(result, err)
+	}
+}
+
+// failBatch 事务整体回滚或提交失败时，将已成功与尚未处理的文件记为失败
+func failBatch(result *BatchResult, err error) {
+	msg := err.Error()
+	if !errors.Is(err, errBatchAborted) {
+		msg = fmt.Sprintf("commit error: %v", err)
+	}
+	for i := range result.Items {
+		if item := &result.Items[i]; item.OK || item.Error == "" {
+			item.OK, item.File, item.Error = false, nil, msg
+		}
+	}
+}
+
+// respondBatch 统计结果、补充下载链接并返回
+func respondBatch(c *gin.Context, result *BatchResult) {
+	for i := range result.Items {
+		item := &result.Items[i]
+		if item.OK {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+		if item.File != nil {
+			item.File.URL = buildServerDownloadURL(c, item.ID)
+		}
+	}
+	response.Success(c, result)
+}
+
+// BatchDeleteFiles 批量软删除文件
+// @Summary 批量删除文件
+// @Description 批量将文件移入回收站，逐个返回处理结果；所有修改在同一事务中提交，atomic 为 true 时任一文件失败则全部回滚。
+// @Description 权限要求与单个删除相同，已删除的文件视为成功
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Param body body BatchRequest true "文件 ID 列表（最多 1000 个）"
+// @Success 200 {object} response.Response[BatchResult]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/batch/delete [post]
+func BatchDeleteFiles(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	if !okDB {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	result, ok := runBatch(c, db, &req, entity.BucketRoleAdmin, func(tx *gorm.DB, rec *entity.File) error {
+		if rec.IsDeleted {
+			return nil
+		}
+		now := time.Now()
+		res := tx.Model(&entity.File{}).Where("id = ?", rec.ID).
+			Updates(map[string]interface{}{"is_deleted": true, "deleted_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errFileGone
+		}
+		rec.IsDeleted, rec.DeletedAt = true, &now
+		return nil
+	})
+	if ok {
+		respondBatch(c, result)
+	}
+}
+
+// BatchRestoreFiles 批量从回收站恢复文件
+// @Summary 批量恢复文件
+// @Description 批量恢复软删除的文件，逐个返回处理结果；所有修改在同一事务中提交，atomic 为 true 时任一文件失败则全部回滚。
+// @Description 权限要求与单个恢复相同，未删除的文件视为成功，已被永久清理的文件返回 file not found
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Param body body BatchRequest true "文件 ID 列表（最多 1000 个）"
+// @Success 200 {object} response.Response[BatchResult]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/batch/restore [post]
+func BatchRestoreFiles(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	if !okDB {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	result, ok := runBatch(c, db, &req, entity.BucketRoleAdmin, func(tx *gorm.DB, rec *entity.File) error {
+		if !rec.IsDeleted {
+			return nil
+		}
+		res := tx.Model(&entity.File{}).Where("id = ? AND purging_at IS NULL", rec.ID).
+			Updates(map[string]interface{}{"is_deleted": false, "deleted_at": nil})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errFileGone
+		}
+		rec.IsDeleted, rec.DeletedAt = false, nil
+		return nil
+	})
+	if ok {
+		respondBatch(c, result)
+	}
+}
+
+// BatchHardDeleteFiles 批量物理删除文件
+// @Summary 批量物理删除文件
+// @Description 批量删除文件记录（不可恢复），逐个返回处理结果；记录在同一事务中删除，提交后通过存储后端的批量删除接口（S3 DeleteObjects）移除对象，
+// @Description 去重对象仅在最后一个引用释放时删除。atomic 为 true 时任一文件失败则全部回滚；权限要求与单个物理删除相同
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Param body body BatchRequest true "文件 ID 列表（最多 1000 个）"
+// @Success 200 {object} response.Response[BatchResult]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/batch/hard-delete [post]
+func BatchHardDeleteFiles(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	store := storeI.(storage.ObjectStore)
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	// 记录与引用计数随事务提交，提交后再批量删除已无引用的对象
+	removable := map[uint64]service.ObjectRef{}
+	result, ok := runBatch(c, db, &req, entity.BucketRoleAdmin, func(tx *gorm.DB, rec *entity.File) error {
+		res := tx.Delete(&entity.File{}, rec.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errFileGone
+		}
+		remove, err := service.ReleaseBlob(tx, rec.Bucket, rec.ObjectName)
+		if err != nil {
+			return err
+		}
+		if remove {
+			removable[rec.ID] = service.ObjectRef{Bucket: rec.Bucket, Key: rec.ObjectName}
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+	var refs []service.ObjectRef
+	for _, item := range result.Items {
+		if ref, found := removable[item.ID]; found && item.OK {
+			refs = append(refs, ref)
+		}
+	}
+	failed := service.RemoveObjects(context.Background(), store, refs)
+	for i := range result.Items {
+		item := &result.Items[i]
+		item.File = nil
+		if ref, found := removable[item.ID]; found && item.OK {
+			if err := failed[ref]; err != nil {
+				item.OK, item.Error = false, fmt.Sprintf("record deleted but remove object failed: %v", err)
+			}
+		}
+	}
+	respondBatch(c, result)
+}
+
+// BatchMoveFiles 批量移动文件到目标 Bucket 的文件夹中
+// @Summary 批量移动文件
+// @Description 将文件移动到目标 Bucket 的 folder_id 文件夹（为空表示根目录）。同一 Bucket 内仅修改所在文件夹；
+// @Description 跨 Bucket 时先在事务外复制对象再更新记录，提交后删除原 Bucket 中已无引用的对象，未移动成功的文件释放其副本；目标 Bucket 开启去重时相同内容复用已有对象。
+// @Description 需要源文件的删除权限与目标 Bucket 的 writer 及以上角色，移动计入目标 Bucket 的配额；已删除的文件不可移动。
+// @Description 所有记录修改在同一事务中提交，atomic 为 true 时任一文件失败则全部回滚
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Param body body BatchMoveRequest true "文件 ID 列表（最多 1000 个）、目标 Bucket 与文件夹"
+// @Success 200 {object} response.Response[BatchResult]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/batch/move [post]
+func BatchMoveFiles(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	store := storeI.(storage.ObjectStore)
+	var req BatchMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	dest := req.Bucket
+	if !checkUploadBucket(c, store, dest) || !authorizeBucketWrite(c, db, store, dest) {
+		return
+	}
+	ctx := context.Background()
+	if !ensureUploadBucket(c, db, store, dest) {
+		return
+	}
+	folder, ok := resolveFolder(c, db, dest, req.FolderID, nil)
+	if !ok {
+		return
+	}
+	var folderID *uint64
+	if folder != nil {
+		folderID = &folder.ID
+	}
+	// 移动不改变上传者的用量，仅校验目标 Bucket 的限制
+	tracker, ok := newQuotaTracker(c, db, dest, nil)
+	if !ok {
+		return
+	}
+
+	result, byID, ok := loadBatch(c, db, &req.BatchRequest, entity.BucketRoleAdmin)
+	if !ok {
+		return
+	}
+	// 跨 Bucket 移动先在事务外复制对象：copies 为目标 Bucket 中各持有一个引用的对象，文件失败或回滚时释放
+	copies := map[uint64]service.ObjectRef{}
+	for i := range result.Items {
+		item := &result.Items[i]
+		if item.Error == "" {
+			if rec := byID[item.ID]; !rec.IsDeleted && rec.Bucket != dest {
+				var size int64
+				if rec.Size != nil {
+					size = *rec.Size
+				}
+				if err := tracker.Reserve(size); err != nil {
+					item.Error = err.Error()
+				} else if key, err := copyToBucket(c, db, store, rec, dest); err != nil {
+					// 归还预留的用量，避免后续文件被误判超出配额
+					tracker.Release(size)
+					item.Error = err.Error()
+				} else {
+					copies[rec.ID] = service.ObjectRef{Bucket: dest, Key: key}
+				}
+			}
+		}
+		if item.Error != "" && req.Atomic {
+			break
+		}
+	}
+
+	// sources 为提交后需删除的原对象
+	sources := map[uint64]service.ObjectRef{}
+	applyBatch(db, &req.BatchRequest, result, byID, func(tx *gorm.DB, rec *entity.File) error {
+		if rec.IsDeleted {
+			return errFileDeleted
+		}
+		if rec.Bucket == dest {
+			// 同一 Bucket 内移动仅修改所在文件夹，无需复制对象
+			if err := tx.Model(&entity.File{}).Where("id = ?", rec.ID).Update("folder_id", folderID).Error; err != nil {
+				return err
+			}
+			rec.FolderID = folderID
+			return nil
+		}
+		copied := copies[rec.ID]
+		var size int64
+		if rec.Size != nil {
+			size = *rec.Size
+		}
+		// 复制前的预留可能已被并发上传占用，写入前在事务中按目标 Bucket 的最新用量再次校验
+		if err := service.EnforceQuota(tx, tracker.Limits, dest, nil, size); err != nil {
+			return err
+		}
+		// 复制期间文件可能已被删除或移走，按原位置条件更新
+		res := tx.Model(&entity.File{}).
+			Where("id = ? AND bucket = ? AND object_name = ? AND is_deleted = ?", rec.ID, rec.Bucket, rec.ObjectName, false).
+			Updates(map[string]interface{}{"bucket": dest, "object_name": copied.Key, "folder_id": folderID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errFileGone
+		}
+		remove, err := service.ReleaseBlob(tx, rec.Bucket, rec.ObjectName)
+		if err != nil {
+			return err
+		}
+		if remove {
+			sources[rec.ID] = service.ObjectRef{Bucket: rec.Bucket, Key: rec.ObjectName}
+		}
+		rec.Bucket, rec.ObjectName, rec.FolderID = dest, copied.Key, folderID
+		return nil
+	})
+
+	var obsolete []service.ObjectRef
+	for _, item := range result.Items {
+		if item.OK {
+			if ref, found := sources[item.ID]; found {
+				obsolete = append(obsolete, ref)
+			}
+		} else if ref, found := copies[item.ID]; found {
+			// 未移动成功的文件释放其副本：去重对象减少引用，其余直接删除
+			_ = service.ReleaseObject(ctx, db, store, ref.Bucket, ref.Key)
+		}
+	}
+	// 删除失败仅遗留无记录引用的对象，不影响移动结果
+	_ = service.RemoveObjects(ctx, store, obsolete)
+	respondBatch(c, result)
+}
+
+// copyToBucket 将文件的对象复制到 bucket，返回持有一个引用的对象名：目标 Bucket 开启去重时可能复用相同内容的已有对象。
+// 在事务外调用，不再需要时由调用方通过 service.ReleaseObject 释放
+func copyToBucket(c *gin.Context, db *gorm.DB, store storage.ObjectStore, rec *entity.File, bucket string) (string, error) {
+	dedup := dedupEnabled(c, bucket) && rec.SHA256 != nil && *rec.SHA256 != ""
+	if dedup {
+		existing, ok, err := service.AcquireBlob(db, bucket, *rec.SHA256)
+		if err != nil {
+			return "", fmt.Errorf("dedup lookup error: %w", err)
+		}
+		if ok {
+			return existing, nil
+		}
+	}
+	key := uuid.New().String() + strings.ToLower(filepath.Ext(rec.ObjectName))
+	contentType := "application/octet-stream"
+	if rec.MimeType != nil && *rec.MimeType != "" {
+		contentType = *rec.MimeType
+	}
+	opts := fileLabels{Tags: rec.Tags, Metadata: rec.Metadata}.putOptions(c, bucket, contentType)
+	ctx := context.Background()
+	info, err := store.CopyObject(ctx, rec.Bucket, rec.ObjectName, bucket, key, opts)
+	if err != nil {
+		return "", fmt.Errorf("copy object error: %w", err)
+	}
+	if !dedup {
+		return key, nil
+	}
+	final, err := service.RegisterBlob(db, bucket, *rec.SHA256, key, info.Size)
+	if err != nil || final != key {
+		_ = store.RemoveObject(ctx, bucket, key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("dedup register error: %w", err)
+	}
+	return final, nil
+}
+
+// BatchTagFiles 批量增删文件标签
+// @Summary 批量修改文件标签
+// @Description 对每个文件先移除 remove 中的标签再追加 add 中的标签（合并后最多 10 个），记录提交后同步到对象标签（去重 Bucket 中的共享对象除外），同步失败的文件恢复原标签；
+// @Description 需要上传者或 Bucket writer 及以上角色，已删除的文件不可修改。所有记录修改在同一事务中提交，atomic 为 true 时任一文件失败则全部回滚
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Param body body BatchTagRequest true "文件 ID 列表（最多 1000 个）与要增删的标签"
+// @Success 200 {object} response.Response[BatchResult]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/batch/tag [post]
+func BatchTagFiles(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	store := storeI.(storage.ObjectStore)
+	var req BatchTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	add, err := service.NormalizeTags(req.Add)
+	if err == nil {
+		_, err = service.NormalizeTags(req.Remove)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "add or remove is required"})
+		return
+	}
+	removed := map[string]bool{}
+	for _, t := range req.Remove {
+		removed[strings.TrimSpace(t)] = true
+	}
+
+	// 先在事务中修改记录，提交后再同步对象标签；changed 为标签有变化的文件及其原标签
+	changed := map[uint64]entity.Tags{}
+	result, ok := runBatch(c, db, &req.BatchRequest, entity.BucketRoleWriter, func(tx *gorm.DB, rec *entity.File) error {
+		if rec.IsDeleted {
+			return errFileDeleted
+		}
+		merged := make([]string, 0, len(rec.Tags)+len(add))
+		for _, t := range rec.Tags {
+			if !removed[t] {
+				merged = append(merged, t)
+			}
+		}
+		tags, err := service.NormalizeTags(append(merged, add...))
+		if err != nil {
+			return err
+		}
+		if slices.Equal(tags, rec.Tags) {
+			return nil
+		}
+		if err := tx.Model(&entity.File{}).Where("id = ?", rec.ID).Update("tags", tags).Error; err != nil {
+			return err
+		}
+		changed[rec.ID] = rec.Tags
+		rec.Tags = tags
+		return nil
+	})
+	if !ok {
+		return
+	}
+	syncBatchTags(db, store, &req.BatchRequest, result, changed, func(bucket string) bool { return !dedupEnabled(c, bucket) })
+	respondBatch(c, result)
+}
+
+// syncBatchTags 将已提交的标签同步到对象（mirror 为 false 的 Bucket 除外）。同步失败的文件恢复数据库中的原标签并记为失败；
+// atomic 时其余文件的记录与已同步的对象标签也一并恢复
+func syncBatchTags(db *gorm.DB, store storage.ObjectStore, req *BatchRequest, result *BatchResult, changed map[uint64]entity.Tags, mirror func(bucket string) bool) {
+	ctx := context.Background()
+	revert := func(item *BatchItemResult) error {
+		prev := changed[item.ID]
+		return revertFileLabels(db, item.ID, map[string]interface{}{"tags": nil}, fileLabels{Tags: item.File.Tags}, fileLabels{Tags: prev})
+	}
+	var synced []*BatchItemResult
+	failed := false
+	for i := range result.Items {
+		item := &result.Items[i]
+		if _, found := changed[item.ID]; !found || !item.OK || !mirror(item.File.Bucket) {
+			continue
+		}
+		if failed && req.Atomic {
+			break
+		}
+		if err := store.SetObjectTags(ctx, item.File.Bucket, item.File.ObjectName, service.ObjectTags(item.File.Tags)); err != nil {
+			msg := fmt.Sprintf("set object tags: %v", err)
+			if rerr := revert(item); rerr != nil {
+				msg = fmt.Sprintf("%s; revert record: %v", msg, rerr)
+			}
+			item.OK, item.File, item.Error = false, nil, msg
+			failed = true
+			continue
+		}
+		synced = append(synced, item)
+	}
+	if !failed || !req.Atomic {
+		return
+	}
+	for _, item := range synced {
+		_ = store.SetObjectTags(ctx, item.File.Bucket, item.File.ObjectName, service.ObjectTags(changed[item.ID]))
+	}
+	for i := range result.Items {
+		item := &result.Items[i]
+		if !item.OK {
+			continue
+		}
+		msg := errBatchAborted.Error()
+		if _, found := changed[item.ID]; found {
+			if err := revert(item); err != nil {
+				msg = fmt.Sprintf("%s; revert record: %v", msg, err)
+			}
+		}
+		item.OK, item.File, item.Error = false, nil, msg
+	}
+}
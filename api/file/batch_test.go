@@ -0,0 +1,172 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/storage"
+)
+
+// batchStore copyFail / tagFail 中的对象复制或设置标签失败，tags 记录最近一次写入的对象标签
+type batchStore struct {
+	storage.ObjectStore
+	copyFail map[string]bool
+	tagFail  map[string]bool
+	tags     map[string]map[string]string
+}
+
+func (s *batchStore) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, opts storage.PutOptions) (storage.ObjectInfo, error) {
+	if s.copyFail[srcKey] {
+		return storage.ObjectInfo{}, errors.New("copy failed")
+	}
+	return s.ObjectStore.CopyObject(ctx, srcBucket, srcKey, dstBucket, dstKey, opts)
+}
+
+func (s *batchStore) SetObjectTags(ctx context.Context, bucket, key string, tags map[string]string) error {
+	if s.tagFail[key] {
+		return errors.New("tagging failed")
+	}
+	s.tags[bucket+"/"+key] = tags
+	return s.ObjectStore.SetObjectTags(ctx, bucket, key, tags)
+}
+
+func newBatchEnv(t *testing.T) (*testEnv, *batchStore) {
+	var bs *batchStore
+	env := newTestEnv(t, func(s storage.ObjectStore) storage.ObjectStore {
+		bs = &batchStore{ObjectStore: s, copyFail: map[string]bool{}, tagFail: map[string]bool{}, tags: map[string]map[string]string{}}
+		return bs
+	})
+	return env, bs
+}
+
+func (e *testEnv) objectCount(t *testing.T, bucket string) int {
+	t.Helper()
+	objs, err := e.store.ListObjects(context.Background(), bucket, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(objs)
+}
+
+func batchItems(t *testing.T, resp map[string]interface{}) []map[string]interface{} {
+	t.Helper()
+	var items []map[string]interface{}
+	for _, it := range resp["data"].(map[string]interface{})["items"].([]interface{}) {
+		items = append(items, it.(map[string]interface{}))
+	}
+	return items
+}
+
+func TestBatchMoveAtomicRollback(t *testing.T) {
+	env, bs := newBatchEnv(t)
+	a := env.putFile(t, "src", "a.txt", []byte("a"), nil)
+	b := env.putFile(t, "src", "b.txt", []byte("b"), nil)
+	bs.copyFail["b.txt"] = true
+
+	code, resp := env.do(t, http.MethodPost, "/api/v1/files/batch/move", map[string]interface{}{
+		"ids": []uint64{a.ID, b.ID}, "atomic": true, "bucket": "dst",
+	})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	for _, it := range batchItems(t, resp) {
+		if it["ok"] == true {
+			t.Fatalf("item %v succeeded in a rolled back batch", it)
+		}
+	}
+	for _, rec := range []*entity.File{a, b} {
+		if got := env.reload(t, rec.ID); got.Bucket != "src" || got.ObjectName != rec.ObjectName {
+			t.Fatalf("record moved: %+v", got)
+		}
+	}
+	// 已复制的副本被释放，原对象保留
+	if n := env.objectCount(t, "dst"); n != 0 {
+		t.Fatalf("dst has %d objects after rollback", n)
+	}
+	if n := env.objectCount(t, "src"); n != 2 {
+		t.Fatalf("src has %d objects", n)
+	}
+}
+
+func TestBatchMovePartialFailure(t *testing.T) {
+	env, bs := newBatchEnv(t)
+	a := env.putFile(t, "src", "a.txt", []byte("a"), nil)
+	b := env.putFile(t, "src", "b.txt", []byte("b"), nil)
+	bs.copyFail["b.txt"] = true
+
+	code, resp := env.do(t, http.MethodPost, "/api/v1/files/batch/move", map[string]interface{}{
+		"ids": []uint64{a.ID, b.ID}, "bucket": "dst",
+	})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	items := batchItems(t, resp)
+	if items[0]["ok"] != true || items[1]["ok"] == true {
+		t.Fatalf("items = %v", items)
+	}
+	if got := env.reload(t, a.ID); got.Bucket != "dst" {
+		t.Fatalf("a not moved: %+v", got)
+	}
+	if got := env.reload(t, b.ID); got.Bucket != "src" {
+		t.Fatalf("b moved: %+v", got)
+	}
+	if env.objectCount(t, "dst") != 1 || env.objectCount(t, "src") != 1 {
+		t.Fatalf("dst = %d, src = %d objects", env.objectCount(t, "dst"), env.objectCount(t, "src"))
+	}
+}
+
+func TestBatchTagAtomicRollback(t *testing.T) {
+	env, bs := newBatchEnv(t)
+	a := env.putFile(t, "docs", "a.txt", []byte("a"), func(f *entity.File) { f.Tags = entity.Tags{"old"} })
+	b := env.putFile(t, "docs", "b.txt", []byte("b"), func(f *entity.File) { f.Tags = entity.Tags{"old"} })
+	bs.tagFail["b.txt"] = true
+
+	code, resp := env.do(t, http.MethodPost, "/api/v1/files/batch/tag", map[string]interface{}{
+		"ids": []uint64{a.ID, b.ID}, "atomic": true, "add": []string{"new"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	for _, it := range batchItems(t, resp) {
+		if it["ok"] == true {
+			t.Fatalf("item %v succeeded in a rolled back batch", it)
+		}
+	}
+	for _, rec := range []*entity.File{a, b} {
+		if got := env.reload(t, rec.ID); !reflect.DeepEqual(got.Tags, entity.Tags{"old"}) {
+			t.Fatalf("tags = %v, want [old]", got.Tags)
+		}
+	}
+	// 已同步的对象标签恢复为原标签
+	if got := bs.tags["docs/a.txt"]; len(got) != 1 {
+		t.Fatalf("object tags of a = %v", got)
+	}
+}
+
+func TestBatchTagPartialFailure(t *testing.T) {
+	env, bs := newBatchEnv(t)
+	a := env.putFile(t, "docs", "a.txt", []byte("a"), nil)
+	b := env.putFile(t, "docs", "b.txt", []byte("b"), nil)
+	bs.tagFail["b.txt"] = true
+
+	code, resp := env.do(t, http.MethodPost, "/api/v1/files/batch/tag", map[string]interface{}{
+		"ids": []uint64{a.ID, b.ID}, "add": []string{"new"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	items := batchItems(t, resp)
+	if items[0]["ok"] != true || items[1]["ok"] == true {
+		t.Fatalf("items = %v", items)
+	}
+	if got := env.reload(t, a.ID); !reflect.DeepEqual(got.Tags, entity.Tags{"new"}) {
+		t.Fatalf("a tags = %v", got.Tags)
+	}
+	if got := env.reload(t, b.ID); len(got.Tags) != 0 {
+		t.Fatalf("b tags = %v, want reverted", got.Tags)
+	}
+}
@@ -0,0 +1,69 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// checkUploadBucket 上传前校验 Bucket 名称是否符合 S3 命名规则；
+// 关闭 storage.auto_create_buckets 时 Bucket 必须已存在，否则返回 404
+func checkUploadBucket(c *gin.Context, store storage.ObjectStore, bucket string) bool {
+	if err := storage.ValidateBucketName(bucket); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid bucket name: %v", err)})
+		return false
+	}
+	if autoCreateBuckets(c) {
+		return true
+	}
+	exists, err := store.BucketExists(context.Background(), bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("check bucket error: %v", err)})
+		return false
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "bucket not found, create it via POST /api/v1/buckets first"})
+		return false
+	}
+	return true
+}
+
+// ensureUploadBucket 确保 Bucket 存在；本次请求新建的 Bucket 由调用方认领为 owner，已存在的未认领 Bucket 不做认领。
+// 授权时 Bucket 尚不存在、随后被并发请求抢先创建时，按已有 Bucket 重新校验写权限。失败时写入响应并返回 false
+func ensureUploadBucket(c *gin.Context, db *gorm.DB, store storage.ObjectStore, bucket string) bool {
+	created, err := storage.EnsureBucket(context.Background(), store, bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("ensure bucket error: %v", err)})
+		return false
+	}
+	pending := c.GetString(pendingBucketKey) == bucket
+	if !created {
+		return !pending || authorizeBucketWrite(c, db, store, bucket)
+	}
+	if uid := currentUploaderID(c); uid != nil {
+		owner, err := service.ClaimBucket(db, bucket, *uid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("claim bucket error: %v", err)})
+			return false
+		}
+		if !owner && pending {
+			return authorizeBucketWrite(c, db, store, bucket)
+		}
+	}
+	return true
+}
+
+func autoCreateBuckets(c *gin.Context) bool {
+	if v, ok := c.Get("config"); ok {
+		if cfg, ok := v.(*config.Config); ok && cfg != nil {
+			return cfg.Storage.AutoCreateBuckets
+		}
+	}
+	return true
+}
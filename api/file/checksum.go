@@ -0,0 +1,175 @@
+package file
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 支持的校验算法；未指定时默认 sha256
+const (
+	checksumSHA256 = "sha256"
+	checksumMD5    = "md5"
+	checksumCRC32C = "crc32c"
+)
+
+// checksumMismatchError 客户端提供的摘要与服务端计算结果不一致
+type checksumMismatchError struct {
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e *checksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch (%s): expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// expectedChecksum 客户端声明的摘要
+type expectedChecksum struct {
+	Algorithm string
+	Value     string
+}
+
+func newChecksumHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case checksumSHA256:
+		return sha256.New(), nil
+	case checksumMD5:
+		return md5.New(), nil
+	case checksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum_algorithm %q, expected sha256, md5 or crc32c", algorithm)
+}
+
+// restoreHash 新建 algorithm 摘要并恢复持久化的中间状态；state 为空时从头计算
+func restoreHash(algorithm string, state []byte) (hash.Hash, error) {
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if len(state) > 0 {
+		if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+			return nil, fmt.Errorf("restore %s state: %v", algorithm, err)
+		}
+	}
+	return h, nil
+}
+
+// hashState 导出摘要的中间状态，用于跨请求继续累积
+func hashState(h hash.Hash) ([]byte, error) {
+	return h.(encoding.BinaryMarshaler).MarshalBinary()
+}
+
+// parseChecksum 解析客户端摘要，value 可为 hex 或 base64（S3 风格）；value 为空时返回 nil
+func parseChecksum(algorithm, value string) (*expectedChecksum, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+	if algorithm == "" {
+		algorithm = checksumSHA256
+	}
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := decodeDigest(value, h.Size())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s checksum: %v", algorithm, err)
+	}
+	return &expectedChecksum{Algorithm: algorithm, Value: hex.EncodeToString(raw)}, nil
+}
+
+func decodeDigest(value string, size int) ([]byte, error) {
+	if len(value) == hex.EncodedLen(size) {
+		if raw, err := hex.DecodeString(value); err == nil {
+			return raw, nil
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(raw) != size {
+		return nil, fmt.Errorf("expected %d-byte digest in hex or base64", size)
+	}
+	return raw, nil
+}
+
+// digester 在一次读取中同时计算 SHA-256（入库）与客户端声明算法的摘要（校验）
+type digester struct {
+	sha      hash.Hash
+	expected *expectedChecksum
+	extra    hash.Hash
+}
+
+func newDigester(expected *expectedChecksum) *digester {
+	d := &digester{sha: sha256.New(), expected: expected}
+	if expected != nil && expected.Algorithm != checksumSHA256 {
+		d.extra, _ = newChecksumHash(expected.Algorithm)
+	}
+	return d
+}
+
+func (d *digester) Write(p []byte) (int, error) {
+	d.sha.Write(p)
+	if d.extra != nil {
+		d.extra.Write(p)
+	}
+	return len(p), nil
+}
+
+// SHA256 返回已写入内容的 SHA-256（hex）
+func (d *digester) SHA256() string { return hex.EncodeToString(d.sha.Sum(nil)) }
+
+// Verify 校验客户端声明的摘要；未声明时总是通过
+func (d *digester) Verify() error {
+	if d.expected == nil {
+		return nil
+	}
+	actual := d.SHA256()
+	if d.extra != nil {
+		actual = hex.EncodeToString(d.extra.Sum(nil))
+	}
+	if actual != d.expected.Value {
+		return &checksumMismatchError{Algorithm: d.expected.Algorithm, Expected: d.expected.Value, Actual: actual}
+	}
+	return nil
+}
+
+// digestReader 读取 r 的全部内容并计算摘要，内容同时写入 tee（如分块上传的整体摘要）
+func digestReader(r io.Reader, expected *expectedChecksum, tee ...io.Writer) (*digester, error) {
+	d := newDigester(expected)
+	var w io.Writer = d
+	if len(tee) > 0 {
+		w = io.MultiWriter(append([]io.Writer{d}, tee...)...)
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// respondChecksumError 摘要不一致返回 400 及双方摘要，其余错误返回 500
+func respondChecksumError(c *gin.Context, err error) {
+	var mismatch *checksumMismatchError
+	if errors.As(err, &mismatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "checksum mismatch", "data": gin.H{
+			"algorithm": mismatch.Algorithm,
+			"expected":  mismatch.Expected,
+			"actual":    mismatch.Actual,
+		}})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("checksum error: %v", err)})
+}
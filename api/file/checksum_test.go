@@ -0,0 +1,70 @@
+package file
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestParseChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("hello"))
+	shaHex := hex.EncodeToString(sum[:])
+	md := md5.Sum([]byte("hello"))
+	mdHex := hex.EncodeToString(md[:])
+	tests := []struct {
+		name      string
+		algorithm string
+		value     string
+		want      *expectedChecksum
+		wantErr   bool
+	}{
+		{name: "empty value", algorithm: "sha256", value: "  "},
+		{name: "default sha256 hex", value: shaHex, want: &expectedChecksum{Algorithm: checksumSHA256, Value: shaHex}},
+		{name: "upper-case hex", algorithm: "SHA256", value: strings.ToUpper(shaHex), want: &expectedChecksum{Algorithm: checksumSHA256, Value: shaHex}},
+		{name: "sha256 base64", algorithm: "sha256", value: base64.StdEncoding.EncodeToString(sum[:]), want: &expectedChecksum{Algorithm: checksumSHA256, Value: shaHex}},
+		{name: "md5 hex", algorithm: "md5", value: mdHex, want: &expectedChecksum{Algorithm: checksumMD5, Value: mdHex}},
+		{name: "md5 base64", algorithm: " md5 ", value: base64.StdEncoding.EncodeToString(md[:]), want: &expectedChecksum{Algorithm: checksumMD5, Value: mdHex}},
+		{name: "crc32c hex", algorithm: "crc32c", value: "e3069283", want: &expectedChecksum{Algorithm: checksumCRC32C, Value: "e3069283"}},
+		{name: "unknown algorithm", algorithm: "sha1", value: shaHex, wantErr: true},
+		{name: "wrong length", algorithm: "md5", value: shaHex, wantErr: true},
+		{name: "not hex or base64", algorithm: "sha256", value: "not-a-digest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum(tt.algorithm, tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigesterVerify(t *testing.T) {
+	sum := sha256.Sum256([]byte("hello"))
+	expected, err := parseChecksum("sha256", hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newDigester(expected)
+	_, _ = d.Write([]byte("hello"))
+	if err := d.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	d = newDigester(expected)
+	_, _ = d.Write([]byte("hellO"))
+	if err := d.Verify(); err == nil {
+		t.Fatal("expected checksum mismatch")
+	}
+}
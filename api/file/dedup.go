@@ -0,0 +1,34 @@
+package file
+
+import (
+	"context"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// dedupEnabled 判断 bucket 是否开启内容去重（storage.dedup_buckets）
+func dedupEnabled(c *gin.Context, bucket string) bool {
+	if v, ok := c.Get("config"); ok {
+		if cfg, ok := v.(*config.Config); ok {
+			return cfg.Storage.DedupEnabled(bucket)
+		}
+	}
+	return false
+}
+
+// registerObject 对象写入存储后按需登记内容摘要并持有引用，返回应引用的对象名；
+// 已存在相同内容或登记失败时删除刚写入的对象
+func registerObject(c *gin.Context, db *gorm.DB, store storage.ObjectStore, bucket, objectName, sha256 string, size int64) (string, error) {
+	if !dedupEnabled(c, bucket) {
+		return objectName, nil
+	}
+	final, err := service.RegisterBlob(db, bucket, sha256, objectName, size)
+	if err != nil || final != objectName {
+		_ = store.RemoveObject(context.Background(), bucket, objectName)
+	}
+	return final, err
+}
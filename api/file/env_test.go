@@ -0,0 +1,161 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/internal/dbtest"
+	"github.com/binhy/go-template/middleware"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// testEnv 挂载全部文件路由的测试环境：SQLite 数据库、本地存储，认证关闭（匿名管理员）
+type testEnv struct {
+	db    *gorm.DB
+	store storage.ObjectStore
+	cfg   *config.Config
+	r     *gin.Engine
+	// authz 非空时作为请求的 Authorization 头，以指定用户身份调用
+	authz string
+}
+
+// newTestEnv wrap 非空时用于包装本地存储（如注入失败）
+func newTestEnv(t *testing.T, wrap func(storage.ObjectStore) storage.ObjectStore) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	local, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var store storage.ObjectStore = local
+	if wrap != nil {
+		store = wrap(store)
+	}
+	env := &testEnv{db: dbtest.Open(t), store: store, cfg: config.Default()}
+	env.cfg.Auth.Disabled = true
+	env.r = gin.New()
+	env.r.Use(func(c *gin.Context) {
+		c.Set("db", env.db)
+		c.Set("storage", env.store)
+		c.Set("config", env.cfg)
+		c.Next()
+	})
+	RegisterRoutes(env.r.Group("/api/v1", middleware.Auth()))
+	return env
+}
+
+// do 发送 JSON 请求，返回状态码与解析后的响应体
+func (e *testEnv) do(t *testing.T, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.authz != "" {
+		req.Header.Set("Authorization", e.authz)
+	}
+	w := httptest.NewRecorder()
+	e.r.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+// form 发送 multipart 表单请求；file 非 nil 时作为 fileField 字段上传
+func (e *testEnv) form(t *testing.T, path string, fields map[string]string, fileField string, file []byte) (int, map[string]interface{}) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if file != nil {
+		fw, err := mw.CreateFormFile(fileField, "blob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if e.authz != "" {
+		req.Header.Set("Authorization", e.authz)
+	}
+	w := httptest.NewRecorder()
+	e.r.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+// putFile 直接写入对象与文件记录
+func (e *testEnv) putFile(t *testing.T, bucket, key string, data []byte, mut func(*entity.File)) *entity.File {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := storage.EnsureBucket(ctx, e.store, bucket); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.store.PutObject(ctx, bucket, key, bytes.NewReader(data), int64(len(data)), storage.PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	size := int64(len(data))
+	rec := &entity.File{Bucket: bucket, ObjectName: key, URL: bucket + "/" + key, Size: &size}
+	if mut != nil {
+		mut(rec)
+	}
+	if err := e.db.Create(rec).Error; err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func (e *testEnv) reload(t *testing.T, id uint64) *entity.File {
+	t.Helper()
+	var rec entity.File
+	if err := e.db.First(&rec, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &rec
+}
+
+// as 创建拥有读写权限的普通用户及其 API Key，返回以该用户身份发起请求的环境副本
+func (e *testEnv) as(t *testing.T, username string) (*testEnv, *entity.User) {
+	t.Helper()
+	u := &entity.User{Username: username, PasswordHash: "x", Scopes: "read,write"}
+	if err := e.db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	plain := "gtk_" + username
+	key := &entity.ApiKey{UserID: u.ID, Name: username, Prefix: plain, KeyHash: middleware.HashAPIKey(plain), Scopes: u.Scopes}
+	if err := e.db.Create(key).Error; err != nil {
+		t.Fatal(err)
+	}
+	as := *e
+	as.authz = "ApiKey " + plain
+	return &as, u
+}
@@ -2,53 +2,74 @@ package file
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/binhy/go-template/middleware"
 	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/model/response"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/minio/minio-go/v7"
 	"gorm.io/gorm"
 )
 
-// UploadFile 处理文件上传到 MinIO，并将元数据保存到数据库
+// UploadFile 处理文件上传到对象存储，并将元数据保存到数据库
 // @Summary 上传文件
-// @Description 上传文件到指定 Bucket，自动创建不存在的 Bucket，并返回文件元数据
+// @Description 上传文件到指定 Bucket 并返回文件元数据；Bucket 名称须符合 S3 命名规则，不存在的 Bucket 仅在 storage.auto_create_buckets 开启时自动创建（否则返回 404）；Bucket 开启去重（storage.dedup_buckets）时相同内容复用已有对象
 // @Tags Files
 // @Accept multipart/form-data
 // @Produce json
 // @Param bucket formData string true "MinIO Bucket 名称"
 // @Param file formData file true "要上传的文件"
+// @Param checksum formData string false "文件摘要（hex 或 base64），提供时服务端校验，不一致返回 400"
+// @Param checksum_algorithm formData string false "摘要算法：sha256（默认）、md5、crc32c"
+// @Param tags formData string false "标签，逗号分隔或重复传入，最多 10 个"
+// @Param metadata formData string false "自定义元数据，字符串值的 JSON 对象，如 {\"project\":\"apollo\"}"
+// @Param folder formData string false "目标文件夹路径，如 docs/2024，不存在时逐级创建；与 folder_id 二选一，均未提供时位于 Bucket 根目录"
+// @Param folder_id formData int false "目标文件夹 ID"
 // @Success 200 {object} entity.File
-// @Failure 400 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Bucket 名称不合法、缺少文件或摘要不一致"
+// @Failure 404 {object} map[string]interface{} "Bucket 不存在且未开启 storage.auto_create_buckets"
 // @Failure 500 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{} "超出最大对象大小（code 41301）或容量配额（code 41302）"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files [post]
 func UploadFile(c *gin.Context) {
 	// 从上下文获取依赖，避免 import cycle
 	dbI, okDB := c.Get("db")
-	minioI, okMinio := c.Get("minio")
-	endpointI, _ := c.Get("minio_endpoint")
-	secureI, _ := c.Get("minio_secure")
-	if !okDB || !okMinio {
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
 		return
 	}
 	db := dbI.(*gorm.DB)
-	mc := minioI.(*minio.Client)
+	store := storeI.(storage.ObjectStore)
+	if !parseLimitedForm(c) {
+		return
+	}
 
 	bucket := c.PostForm("bucket")
 	if bucket == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "bucket is required"})
 		return
 	}
+	if !checkUploadBucket(c, store, bucket) || !authorizeBucketWrite(c, db, store, bucket) {
+		return
+	}
+	folderID, ok := resolveUploadFolder(c, db, bucket)
+	if !ok {
+		return
+	}
 
 	// 获取上传的文件
 	fileHeader, err := c.FormFile("file")
@@ -56,27 +77,50 @@ func UploadFile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("file fetch error: %v", err)})
 		return
 	}
+	tracker, ok := newQuotaTracker(c, db, bucket, currentUploaderID(c))
+	if !ok || !checkQuota(c, tracker, fileHeader.Size, fileHeader.Size) {
+		return
+	}
+
+	expected, err := parseChecksum(c.PostForm("checksum_algorithm"), c.PostForm("checksum"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	labels, err := parseLabelForm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
 
+	// 写入存储前先计算摘要并校验，避免产生损坏的对象
 	src, err := fileHeader.Open()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("file open error: %v", err)})
 		return
 	}
+	digest, err := digestReader(src, expected)
+	_ = src.Close()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("file read error: %v", err)})
+		return
+	}
+	if err := digest.Verify(); err != nil {
+		respondChecksumError(c, err)
+		return
+	}
+	src, err = fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("file open error: %v", err)})
+		return
+	}
 	defer src.Close()
 
 	// 确保 bucket 存在
 	ctx := context.Background()
-	exists, err := mc.BucketExists(ctx, bucket)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket check error: %v", err)})
+	if !ensureUploadBucket(c, db, store, bucket) {
 		return
 	}
-	if !exists {
-		if err := mc.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("make bucket error: %v", err)})
-			return
-		}
-	}
 
 	// 生成对象名，保留原扩展名
 	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
@@ -91,24 +135,35 @@ func UploadFile(c *gin.Context) {
 		contentType = "application/octet-stream"
 	}
 
-	// 上传到 MinIO
-	putOpts := minio.PutObjectOptions{ContentType: contentType}
-	info, err := mc.PutObject(ctx, bucket, objectName, src, fileHeader.Size, putOpts)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("put object error: %v", err)})
-		return
-	}
-
-	// 生成访问 URL（假设直连 endpoint，如果反向代理需自行替换）
-	scheme := "http"
-	if b, ok := secureI.(bool); ok && b {
-		scheme = "https"
+	// 开启去重时，相同内容直接复用已有对象，跳过上传
+	size := fileHeader.Size
+	reused := false
+	if dedupEnabled(c, bucket) {
+		existing, ok, err := service.AcquireBlob(db, bucket, digest.SHA256())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("dedup lookup error: %v", err)})
+			return
+		}
+		if ok {
+			objectName, reused = existing, true
+		}
 	}
-	endpoint := ""
-	if s, ok := endpointI.(string); ok {
-		endpoint = s
+	if !reused {
+		// 上传到对象存储
+		putOpts := labels.putOptions(c, bucket, contentType)
+		info, err := store.PutObject(ctx, bucket, objectName, src, fileHeader.Size, putOpts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("put object error: %v", err)})
+			return
+		}
+		size = info.Size
+		final, err := registerObject(c, db, store, bucket, objectName, digest.SHA256(), size)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("dedup register error: %v", err)})
+			return
+		}
+		objectName = final
 	}
-	url := fmt.Sprintf("%s://%s/%s/%s", scheme, endpoint, bucket, objectName)
 
 	// 记录数据库（先保存，再更新 URL 为服务器下载链接）
 	originalName := fileHeader.Filename
@@ -116,14 +171,23 @@ func UploadFile(c *gin.Context) {
 		Bucket:       bucket,
 		ObjectName:   objectName,
 		OriginalName: &originalName,
-		URL:          url, // 初始写入 MinIO 直链，随后更新为服务器下载链接
-		Size:         ptrInt64(info.Size),
+		URL:          "", // 先空，随后更新为服务器下载链接
+		Size:         ptrInt64(size),
 		MimeType:     &contentType,
-		UploaderID:   nil,
+		UploaderID:   currentUploaderID(c),
+		FolderID:     folderID,
+		SHA256:       ptrString(digest.SHA256()),
+		Tags:         labels.Tags,
+		Metadata:     labels.Metadata,
 		IsDeleted:    false,
 		CreatedAt:    time.Now(),
 	}
-	if err := db.Create(rec).Error; err != nil {
+	// 写入存储前的配额校验可能已被并发上传抢先占用，写入记录时在事务中按最新用量再次校验
+	if err := service.CreateFileWithQuota(db, tracker.Limits, rec); err != nil {
+		_ = service.ReleaseObject(ctx, db, store, bucket, objectName)
+		if respondQuotaError(c, err, nil) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("save record error: %v", err)})
 		return
 	}
@@ -135,31 +199,38 @@ func UploadFile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": rec})
 }
 
-// DownloadFile 根据 id 从数据库找到文件记录，并从 MinIO 获取对象，流式返回
+// DownloadFile 根据 id 从数据库找到文件记录，并从对象存储获取对象，流式返回
 // @Summary 下载文件
 // @Description 根据文件记录 ID，从 MinIO 流式下载文件
 // @Tags Files
 // @Param id path int true "文件记录 ID"
+// @Param access_token query string false "JWT，供无法携带请求头的浏览器直链使用（仅本接口接受）；分享链接建议使用预签名 URL"
 // @Success 200 {file} file
 // @Failure 404 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 410 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/{id}/download [get]
 func DownloadFile(c *gin.Context) {
 	dbI, okDB := c.Get("db")
-	minioI, okMinio := c.Get("minio")
-	if !okDB || !okMinio {
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
 		return
 	}
 	db := dbI.(*gorm.DB)
-	mc := minioI.(*minio.Client)
+	store := storeI.(storage.ObjectStore)
 	id := c.Param("id")
 	var rec entity.File
 	if err := db.First(&rec, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "file not found"})
 		return
 	}
+	if !authorizeFile(c, &rec, entity.BucketRoleReader) {
+		return
+	}
 	if rec.IsDeleted {
 		c.JSON(http.StatusGone, gin.H{"code": 410, "msg": "file is deleted"})
 		return
@@ -167,7 +238,7 @@ func DownloadFile(c *gin.Context) {
 
 	ctx := context.Background()
 	// 获取对象信息以设置响应头
-	stat, err := mc.StatObject(ctx, rec.Bucket, rec.ObjectName, minio.StatObjectOptions{})
+	stat, err := store.StatObject(ctx, rec.Bucket, rec.ObjectName)
 	if err != nil {
 		// 如果获取失败，继续下载但使用默认类型
 		stat.ContentType = "application/octet-stream"
@@ -189,9 +260,9 @@ func DownloadFile(c *gin.Context) {
 			c.Status(http.StatusRequestedRangeNotSatisfiable)
 			return
 		}
-		opts := minio.GetObjectOptions{}
+		opts := storage.GetOptions{}
 		opts.SetRange(start, end)
-		obj, err := mc.GetObject(ctx, rec.Bucket, rec.ObjectName, opts)
+		obj, err := store.GetObject(ctx, rec.Bucket, rec.ObjectName, opts)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("get object error: %v", err)})
 			return
@@ -206,7 +277,7 @@ func DownloadFile(c *gin.Context) {
 	}
 
 	// 无 Range，正常全量下载
-	obj, err := mc.GetObject(ctx, rec.Bucket, rec.ObjectName, minio.GetObjectOptions{})
+	obj, err := store.GetObject(ctx, rec.Bucket, rec.ObjectName, storage.GetOptions{})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("get object error: %v", err)})
 		return
@@ -225,6 +296,9 @@ func DownloadFile(c *gin.Context) {
 // @Produce json
 // @Success 200 {object} entity.File
 // @Failure 404 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/{id} [get]
 func GetFile(c *gin.Context) {
 	dbI, okDB := c.Get("db")
@@ -239,18 +313,25 @@ func GetFile(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "file not found"})
 		return
 	}
+	if !authorizeFile(c, &rec, entity.BucketRoleReader) {
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": rec})
 }
 
-// DeleteFile 将文件记录标记为已删除（软删除）
+// DeleteFile 将文件记录标记为已删除（软删除），移入回收站
 // @Summary 删除文件（软删除）
-// @Description 根据文件记录 ID，将其标记为已删除；已删除的文件下载接口将返回 410
+// @Description 根据文件记录 ID，将其标记为已删除并记录删除时间；已删除的文件下载接口将返回 410，
+// @Description 可通过 POST /api/v1/files/{id}/restore 恢复，超过 trash.retention 后由后台任务永久删除
 // @Tags Files
 // @Param id path int true "文件记录 ID"
 // @Produce json
 // @Success 200 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/{id} [delete]
 func DeleteFile(c *gin.Context) {
 	dbI, okDB := c.Get("db")
@@ -266,6 +347,9 @@ func DeleteFile(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "file not found"})
 		return
 	}
+	if !authorizeFile(c, &rec, entity.BucketRoleAdmin) {
+		return
+	}
 
 	if rec.IsDeleted {
 		// 幂等处理：已删除直接返回成功
@@ -273,7 +357,8 @@ func DeleteFile(c *gin.Context) {
 		return
 	}
 
-	rec.IsDeleted = true
+	now := time.Now()
+	rec.IsDeleted, rec.DeletedAt = true, &now
 	if err := db.Save(&rec).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "update record error"})
 		return
@@ -295,25 +380,28 @@ func safeContentType(ct string) string {
 	return ct
 }
 
-// HardDeleteFile 物理删除文件：从 MinIO 中移除对象，并删除数据库记录
+// HardDeleteFile 物理删除文件：从对象存储中移除对象，并删除数据库记录
 // @Summary 物理删除文件
-// @Description 根据文件记录 ID，从 MinIO 删除对象，并删除数据库记录（不可恢复）
+// @Description 根据文件记录 ID，从 MinIO 删除对象，并删除数据库记录（不可恢复）；开启去重的 Bucket 中对象被多条记录引用时仅减少引用计数
 // @Tags Files
 // @Param id path int true "文件记录 ID"
 // @Produce json
 // @Success 200 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/{id}/hard-delete [delete]
 func HardDeleteFile(c *gin.Context) {
 	dbI, okDB := c.Get("db")
-	minioI, okMinio := c.Get("minio")
-	if !okDB || !okMinio {
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
 		return
 	}
 	db := dbI.(*gorm.DB)
-	mc := minioI.(*minio.Client)
+	store := storeI.(storage.ObjectStore)
 
 	id := c.Param("id")
 	var rec entity.File
@@ -321,10 +409,13 @@ func HardDeleteFile(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "file not found"})
 		return
 	}
+	if !authorizeFile(c, &rec, entity.BucketRoleAdmin) {
+		return
+	}
 
 	ctx := context.Background()
-	// 先删除 MinIO 对象，确保不会留下存储残留
-	if err := mc.RemoveObject(ctx, rec.Bucket, rec.ObjectName, minio.RemoveObjectOptions{}); err != nil {
+	// 先释放存储对象，确保不会留下存储残留；去重对象仅在最后一个引用释放时删除
+	if err := service.ReleaseObject(ctx, db, store, rec.Bucket, rec.ObjectName); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "remove object error"})
 		return
 	}
@@ -340,37 +431,33 @@ func HardDeleteFile(c *gin.Context) {
 
 // InitChunkUpload 初始化分块上传会话
 // @Summary 初始化分块上传
-// @Description 返回会话ID，前端每个分片携带该ID上传，服务端缓存分片后合并
+// @Description 返回会话ID与过期时间，前端每个分片携带该ID上传；会话持久化在数据库中，服务重启或多实例部署下均可续传；分片直接写入存储后端的原生分块上传，不落 API 服务器磁盘
 // @Tags Files
 // @Accept multipart/form-data
 // @Produce json
 // @Param bucket formData string true "Bucket 名称"
 // @Param filename formData string true "原始文件名"
 // @Param mime_type formData string false "MIME 类型"
+// @Param total_size formData int false "文件总大小（字节）"
+// @Param total_chunks formData int false "分片总数；提供后分片与完成请求的 total_chunks 须与之一致"
+// @Param checksum formData string false "整个文件摘要（hex 或 base64），由按序到达的分片累积得出并在完成时校验；也可在完成请求中提供（md5 / crc32c 须在初始化时声明）"
+// @Param checksum_algorithm formData string false "摘要算法：sha256（默认）、md5、crc32c"
+// @Param tags formData string false "标签，逗号分隔或重复传入，最多 10 个"
+// @Param metadata formData string false "自定义元数据，字符串值的 JSON 对象"
+// @Param folder formData string false "目标文件夹路径，不存在时逐级创建；与 folder_id 二选一"
+// @Param folder_id formData int false "目标文件夹 ID"
 // @Success 200 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{} "超出最大对象大小（code 41301）或容量配额（code 41302）"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/multipart/init [post]
 func InitChunkUpload(c *gin.Context) {
-	bucket := c.PostForm("bucket")
-	filename := c.PostForm("filename")
-	mimeType := c.PostForm("mime_type")
-	if bucket == "" || filename == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "bucket and filename are required"})
-		return
-	}
-	uploadID := uuid.New().String()
-	// 在 cache 目录下创建临时会话目录
-	base := filepath.Join("cache", "uploads", uploadID)
-	if err := os.MkdirAll(base, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("init session error: %v", err)})
-		return
-	}
-	// 保存元信息
-	_ = os.WriteFile(filepath.Join(base, "meta.txt"), []byte(fmt.Sprintf("bucket=%s\nfilename=%s\nmime=%s\n", bucket, filename, mimeType)), os.ModePerm)
-	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "ok", "data": gin.H{"upload_id": uploadID}})
+	handleInit(c, sessionKindFile, objectNameFor)
 }
 
-// UploadChunk 上传单个分片；当收到最后一个分片时进行合并并上传到 MinIO
+// UploadChunk 上传单个分片；分片可并发、乱序上传，服务端检测到全部分片到齐后自动完成上传
 // @Summary 上传分片
+// @Description 分片作为存储后端分块上传的一个 part 直接写入（除最后一个分片外每片至少 5MiB）；当所有分片到齐时自动完成，也可显式调用 /api/v1/files/multipart/complete
 // @Tags Files
 // @Accept multipart/form-data
 // @Produce json
@@ -378,178 +465,199 @@ func InitChunkUpload(c *gin.Context) {
 // @Param chunk_index formData int true "当前分片序号（从1开始）"
 // @Param total_chunks formData int true "分片总数"
 // @Param chunk formData file true "分片文件"
-// @Param bucket formData string false "Bucket（可冗余）"
-// @Param filename formData string false "原始文件名（可冗余）"
+// @Param checksum formData string false "该分片的摘要（hex 或 base64），写入前校验，不一致返回 400，可重传该分片"
+// @Param checksum_algorithm formData string false "摘要算法：sha256（默认）、md5、crc32c"
 // @Success 200 {object} map[string]interface{}
+// @Failure 413 {object} map[string]interface{} "超出最大对象大小（code 41301）或容量配额（code 41302）"
+// @Failure 409 {object} map[string]interface{} "会话已开始合并或已结束，本次分片未被记录"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/multipart/chunk [post]
 func UploadChunk(c *gin.Context) {
-	dbI, okDB := c.Get("db")
-	minioI, okMinio := c.Get("minio")
-	if !okDB || !okMinio {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
-		return
-	}
-	db := dbI.(*gorm.DB)
-	mc := minioI.(*minio.Client)
+	handleChunk(c, sessionKindFile, finalizeChunkUpload)
+}
 
-	uploadID := c.PostForm("upload_id")
-	chunkIndexStr := c.PostForm("chunk_index")
-	totalChunksStr := c.PostForm("total_chunks")
-	if uploadID == "" || chunkIndexStr == "" || totalChunksStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "upload_id, chunk_index, total_chunks are required"})
-		return
-	}
-	chunkIndex, _ := strconv.Atoi(chunkIndexStr)
-	totalChunks, _ := strconv.Atoi(totalChunksStr)
-	base := filepath.Join("cache", "uploads", uploadID)
-	if _, err := os.Stat(base); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "invalid upload_id"})
-		return
-	}
+// CompleteChunkUpload 显式完成分块上传：校验全部分片到齐后由存储后端拼接为目标对象
+// @Summary 完成分块上传
+// @Description 所有分片（可并发、乱序）上传完成后调用；缺少分片时返回 400 及缺失的分片序号。整个文件的 SHA-256 由按序到达的分片累积得出，不回读拼接后的对象；有分片乱序到达时不记录 sha256、不参与去重，若要求校验整体摘要则返回 409 及 hashed_parts，按序重传其后的分片后再完成
+// @Tags Files
+// @Accept multipart/form-data
+// @Produce json
+// @Param upload_id formData string true "初始化返回的会话ID"
+// @Param total_chunks formData int true "分片总数"
+// @Param checksum formData string false "整个文件摘要（hex 或 base64），覆盖初始化时声明的摘要；拼接前校验，不一致返回 400 并结束会话"
+// @Param checksum_algorithm formData string false "摘要算法：sha256（默认）、md5、crc32c"
+// @Success 200 {object} entity.File
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/multipart/complete [post]
+func CompleteChunkUpload(c *gin.Context) {
+	handleComplete(c, sessionKindFile, finalizeChunkUpload)
+}
 
-	// 保存当前分片
-	fh, err := c.FormFile("chunk")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("chunk fetch error: %v", err)})
+// finalizeChunkUpload 完成存储后端的分块上传并写入数据库记录，随后结束会话；调用方需已持有合并锁
+func finalizeChunkUpload(c *gin.Context, sess *entity.UploadSession, parts []storage.Part) {
+	db, store, ok := sessionDeps(c)
+	if !ok {
 		return
 	}
-	src, err := fh.Open()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk open error: %v", err)})
-		return
-	}
-	defer src.Close()
-	partPath := filepath.Join(base, fmt.Sprintf("part_%06d", chunkIndex))
-	out, err := os.Create(partPath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk save error: %v", err)})
-		return
-	}
-	if _, err := io.Copy(out, src); err != nil {
-		_ = out.Close()
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk write error: %v", err)})
-		return
-	}
-	_ = out.Close()
 
-	// 如果还未到最后一个分片，返回进度
-	if chunkIndex < totalChunks {
-		c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "chunk received", "data": gin.H{"received": chunkIndex, "total": totalChunks}})
+	ctx := context.Background()
+	// 整体摘要由分片到达时按序累积得出，不回读拼接后的对象；有分片乱序到达时无法得出
+	digest, err := sessionDigester(sess, len(parts))
+	if err != nil {
+		releaseCompleteLock(db, sess)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("restore digest error: %v", err)})
 		return
 	}
-
-	// 最后一个分片：合并并上传到 MinIO
-	// 读取元信息
-	metaBytes, _ := os.ReadFile(filepath.Join(base, "meta.txt"))
-	meta := parseMeta(string(metaBytes))
-	bucket := meta["bucket"]
-	filename := meta["filename"]
-	mimeType := meta["mime"]
-	if bucket == "" {
-		bucket = c.PostForm("bucket")
-	}
-	if filename == "" {
-		filename = c.PostForm("filename")
-	}
-	if mimeType == "" {
-		mimeType = fh.Header.Get("Content-Type")
-	}
-
-	// 合并文件到临时文件
-	mergedPath := filepath.Join(base, "merged.tmp")
-	merged, err := os.Create(mergedPath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("merge create error: %v", err)})
+	if digest == nil && sessionChecksum(sess) != nil {
+		// 客户端要求校验整体摘要：按序重传尚未累积的分片后可再次完成
+		releaseCompleteLock(db, sess)
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": fmt.Sprintf("whole-file checksum needs chunks in order, re-send chunks from %d", sess.HashedParts+1), "data": gin.H{"hashed_parts": sess.HashedParts}})
 		return
 	}
-	// 逐个分片按顺序写入
-	for i := 1; i <= totalChunks; i++ {
-		p := filepath.Join(base, fmt.Sprintf("part_%06d", i))
-		part, err := os.Open(p)
-		if err != nil {
-			_ = merged.Close()
-			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("open part error: %v", err)})
+	// 拼接前校验整体摘要，不一致时中止分块上传并结束会话
+	if digest != nil {
+		if err := digest.Verify(); err != nil {
+			_ = store.AbortMultipartUpload(ctx, sess.Bucket, sess.ObjectName, sess.StorageUploadID)
+			finishSession(db, sess, entity.UploadStatusAborted)
+			respondChecksumError(c, err)
 			return
 		}
-		if _, err := io.Copy(merged, part); err != nil {
-			_ = part.Close()
-			_ = merged.Close()
-			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("merge write error: %v", err)})
-			return
-		}
-		_ = part.Close()
-	}
-	if _, err := merged.Seek(0, io.SeekStart); err != nil {
-		_ = merged.Close()
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("merge seek error: %v", err)})
-		return
 	}
-
-	// 确保 bucket 存在
-	ctx := context.Background()
-	exists, err := mc.BucketExists(ctx, bucket)
+	info, err := store.CompleteMultipartUpload(ctx, sess.Bucket, sess.ObjectName, sess.StorageUploadID, parts)
 	if err != nil {
-		_ = merged.Close()
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket check error: %v", err)})
-		return
-	}
-	if !exists {
-		if err := mc.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
-			_ = merged.Close()
-			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("make bucket error: %v", err)})
+		releaseCompleteLock(db, sess)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("complete multipart upload error: %v", err)})
+		return
+	}
+	// 开启去重时，若已存在相同内容则删除刚拼接的对象并引用已有对象；整体摘要未知时不参与去重
+	objectName := sess.ObjectName
+	var sha256 *string
+	if digest != nil {
+		sha256 = ptrString(digest.SHA256())
+		if objectName, err = registerObject(c, db, store, sess.Bucket, sess.ObjectName, *sha256, info.Size); err != nil {
+			finishSession(db, sess, entity.UploadStatusAborted)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("dedup register error: %v", err)})
 			return
 		}
 	}
-	// 对象名保留原扩展
-	ext := strings.ToLower(filepath.Ext(filename))
-	objectName := uuid.New().String()
-	if ext != "" {
-		objectName += ext
-	}
-	putOpts := minio.PutObjectOptions{ContentType: safeContentType(mimeType)}
-	fi, _ := os.Stat(mergedPath)
-	info, err := mc.PutObject(ctx, bucket, objectName, merged, fi.Size(), putOpts)
-	_ = merged.Close()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("put object error: %v", err)})
-		return
-	}
 	// 写入数据库并生成下载链接
-	originalName := filename
+	originalName := sess.Filename
+	mimeType := ""
+	if sess.MimeType != nil {
+		mimeType = *sess.MimeType
+	}
 	rec := &entity.File{
-		Bucket:       bucket,
+		Bucket:       sess.Bucket,
 		ObjectName:   objectName,
 		OriginalName: &originalName,
 		URL:          "", // 先空，随后更新为服务器URL
 		Size:         ptrInt64(info.Size),
 		MimeType:     ptrString(safeContentType(mimeType)),
-		UploaderID:   nil,
+		UploaderID:   sess.OwnerID,
+		FolderID:     sess.FolderID,
+		SHA256:       sha256,
+		Tags:         sess.Tags,
+		Metadata:     sess.Metadata,
 		IsDeleted:    false,
 		CreatedAt:    time.Now(),
 	}
-	if err := db.Create(rec).Error; err != nil {
+	// 分片已计入配额，写入文件记录与删除分片记录在同一事务中完成，用量不会重复计入或短暂缺失
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rec).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("session_id = ?", sess.ID).Delete(&entity.UploadPart{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.UploadSession{}).Where("id = ?", sess.ID).Update("status", entity.UploadStatusCompleted).Error
+	}); err != nil {
+		// 存储端的分块上传已完成，无法重试，释放对象并结束会话
+		_ = service.ReleaseObject(ctx, db, store, sess.Bucket, objectName)
+		finishSession(db, sess, entity.UploadStatusAborted)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("save record error: %v", err)})
 		return
 	}
+	sess.Status = entity.UploadStatusCompleted
 	serverURL := buildServerDownloadURL(c, rec.ID)
 	_ = db.Model(rec).Update("url", serverURL).Error
 	rec.URL = serverURL
 
-	// 清理临时目录
-	_ = os.Remove(mergedPath)
-	_ = os.RemoveAll(base)
-
 	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "upload completed", "data": rec})
 }
 
-// ListFilesByBucket 根据 bucket 列出所有文件（返回带服务器下载链接）
+// ListFilesByBucket 分页列出 bucket 中的文件（返回带服务器下载链接）
 // @Summary 根据 Bucket 获取文件列表
+// @Description 支持偏移分页（offset）或游标分页（cursor，取上一页的 next_cursor），按 MIME 前缀、文件名子串、大小范围、创建时间范围与上传者筛选，total 为筛选后的总数；
+// @Description 指定 folder 或 folder_id 时按目录浏览：仅返回直接位于该文件夹中的文件，并在 folder / folders 中返回当前文件夹与其直接子文件夹
 // @Tags Files
 // @Param bucket path string true "Bucket 名称"
+// @Param limit query int false "每页数量，默认 50，最大 500"
+// @Param offset query int false "偏移量，与 cursor 二选一"
+// @Param cursor query string false "游标，取上一页返回的 next_cursor"
+// @Param sort query string false "排序字段：id、name、size、created_at，默认 id"
+// @Param order query string false "排序方向：asc、desc，默认 desc"
+// @Param folder query string false "按文件夹浏览：文件夹路径，如 docs/2024，\"/\" 表示根目录；与 folder_id 二选一"
+// @Param folder_id query int false "按文件夹浏览：文件夹 ID"
+// @Param mime_prefix query string false "MIME 类型前缀，如 image/"
+// @Param name query string false "文件名包含的子串（不区分大小写）"
+// @Param min_size query int false "最小字节数（含）"
+// @Param max_size query int false "最大字节数（含）"
+// @Param created_after query string false "创建时间下限（含），RFC3339 或 YYYY-MM-DD"
+// @Param created_before query string false "创建时间上限（不含），RFC3339 或 YYYY-MM-DD"
+// @Param uploader_id query int false "上传者用户 ID"
+// @Param tag query []string false "须同时包含的标签（Postgres）" collectionFormat(multi)
+// @Param meta query []string false "须匹配的元数据，格式 key:value（Postgres）" collectionFormat(multi)
 // @Produce json
-// @Success 200 {array} entity.File
+// @Success 200 {object} response.Response[FolderListing]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/bucket/{bucket} [get]
 func ListFilesByBucket(c *gin.Context) {
+	listBucketFiles(c, false)
+}
+
+// ListTrashByBucket 分页列出 bucket 回收站中的文件（已软删除、尚未被永久清理）
+// @Summary 获取 Bucket 回收站文件列表
+// @Description 列出已软删除的文件，分页、筛选与排序参数同文件列表，另支持按 deleted_at 排序（默认 deleted_at desc）；超过 trash.retention 的文件会被后台任务永久删除
+// @Tags Files
+// @Param bucket path string true "Bucket 名称"
+// @Param limit query int false "每页数量，默认 50，最大 500"
+// @Param offset query int false "偏移量，与 cursor 二选一"
+// @Param cursor query string false "游标，取上一页返回的 next_cursor"
+// @Param sort query string false "排序字段：id、name、size、created_at、deleted_at，默认 deleted_at"
+// @Param order query string false "排序方向：asc、desc，默认 desc"
+// @Param folder query string false "按文件夹浏览：文件夹路径，如 docs/2024，\"/\" 表示根目录；与 folder_id 二选一"
+// @Param folder_id query int false "按文件夹浏览：文件夹 ID"
+// @Param mime_prefix query string false "MIME 类型前缀，如 image/"
+// @Param name query string false "文件名包含的子串（不区分大小写）"
+// @Param min_size query int false "最小字节数（含）"
+// @Param max_size query int false "最大字节数（含）"
+// @Param created_after query string false "创建时间下限（含），RFC3339 或 YYYY-MM-DD"
+// @Param created_before query string false "创建时间上限（不含），RFC3339 或 YYYY-MM-DD"
+// @Param uploader_id query int false "上传者用户 ID"
+// @Param tag query []string false "须同时包含的标签（Postgres）" collectionFormat(multi)
+// @Param meta query []string false "须匹配的元数据，格式 key:value（Postgres）" collectionFormat(multi)
+// @Produce json
+// @Success 200 {object} response.Response[FolderListing]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/bucket/{bucket}/trash [get]
+func ListTrashByBucket(c *gin.Context) {
+	listBucketFiles(c, true)
+}
+
+// listBucketFiles 列出 bucket 中未删除（deleted 为 false）或回收站中（deleted 为 true）的文件
+func listBucketFiles(c *gin.Context, deleted bool) {
 	dbI, okDB := c.Get("db")
 	if !okDB {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
@@ -557,41 +665,110 @@ func ListFilesByBucket(c *gin.Context) {
 	}
 	db := dbI.(*gorm.DB)
 	bucket := c.Param("bucket")
-	var list []entity.File
-	if err := db.Where("bucket = ? AND is_deleted = ?", bucket, false).Order("id DESC").Find(&list).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("query error: %v", err)})
+	// 已认领的 Bucket 需 reader 及以上角色，可查看其中全部文件；未认领的历史 Bucket 仅返回自己上传的文件
+	allowed, claimed, err := bucketAllows(c, db, bucket, entity.BucketRoleReader)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket acl error: %v", err)})
+		return
+	}
+	if claimed && !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden: no read access to bucket"})
+		return
+	}
+	params, err := bindListFilesQuery(c, deleted)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+	q := db.Model(&entity.File{}).Where("bucket = ? AND is_deleted = ?", bucket, deleted)
+	if !claimed {
+		q = scopeToOwner(c, q)
+	}
+	browse := params.Folder != nil || params.FolderID != nil
+	folder, ok := resolveFolder(c, db, bucket, params.FolderID, params.Folder)
+	if !ok {
+		return
+	}
+	if folder != nil {
+		q = q.Where("folder_id = ?", folder.ID)
+	} else if browse {
+		q = q.Where("folder_id IS NULL")
+	}
+	if q, err = params.apply(q); err != nil {
+		response.Error(c, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+	page, err := params.paginate(q)
+	if err != nil {
+		if errors.Is(err, errInvalidCursor) {
+			response.Error(c, http.StatusBadRequest, 400, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, 500, fmt.Sprintf("query error: %v", err))
 		return
 	}
 	// 动态补充服务器下载链接，确保旧数据也可用
-	for i := range list {
-		list[i].URL = buildServerDownloadURL(c, list[i].ID)
+	for i := range page.Items {
+		page.Items[i].URL = buildServerDownloadURL(c, page.Items[i].ID)
 	}
-	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": list})
+	listing := &FolderListing{Page: *page, Folder: folder}
+	if browse {
+		if listing.Folders, err = listSubfolders(db, bucket, folder); err != nil {
+			response.Error(c, http.StatusInternalServerError, 500, fmt.Sprintf("query error: %v", err))
+			return
+		}
+	}
+	response.Success(c, listing)
 }
 
-// ListBuckets 获取所有 MinIO Buckets 列表
-// @Summary 获取所有 Buckets 列表
+// ListBuckets 获取存储中的 Buckets 列表，非管理员仅返回自己拥有角色的 Bucket
+// @Summary 获取 Buckets 列表
+// @Description 管理员返回存储中的全部 Bucket；其余调用方仅返回被授予角色的 Bucket，并附带自己的角色
 // @Tags Files
 // @Produce json
 // @Success 200 {array} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/buckets [get]
 func ListBuckets(c *gin.Context) {
-    minioI, okMinio := c.Get("minio")
-    if !okMinio {
+    storeI, okStore := c.Get("storage")
+    if !okStore {
         c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage not initialized"})
         return
     }
-    mc := minioI.(*minio.Client)
+    store := storeI.(storage.ObjectStore)
     ctx := context.Background()
-    buckets, err := mc.ListBuckets(ctx)
+    buckets, err := store.ListBuckets(ctx)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("list buckets error: %v", err)})
         return
     }
+    var roles map[string]string
+    if id, ok := middleware.CurrentIdentity(c); !ok {
+        roles = map[string]string{}
+    } else if !id.IsAdmin() {
+        dbI, okDB := c.Get("db")
+        if !okDB {
+            c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+            return
+        }
+        if roles, err = service.AccessibleBuckets(dbI.(*gorm.DB), id.UserID); err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket acl error: %v", err)})
+            return
+        }
+    }
     list := make([]gin.H, 0, len(buckets))
     for _, b := range buckets {
-        list = append(list, gin.H{"name": b.Name, "createdAt": b.CreationDate})
+        item := gin.H{"name": b.Name, "createdAt": b.CreatedAt}
+        if roles != nil {
+            role, ok := roles[b.Name]
+            if !ok {
+                continue
+            }
+            item["role"] = role
+        }
+        list = append(list, item)
     }
     c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": list})
 }
@@ -602,22 +779,29 @@ func ListBuckets(c *gin.Context) {
 // @Param id path int true "文件记录 ID"
 // @Param expiry query int false "过期时间秒，默认600"
 // @Produce json
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/v1/files/{id}/presigned [get]
 func GetPresignedDownload(c *gin.Context) {
 	dbI, okDB := c.Get("db")
-	minioI, okMinio := c.Get("minio")
-	if !okDB || !okMinio {
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
 		return
 	}
 	db := dbI.(*gorm.DB)
-	mc := minioI.(*minio.Client)
+	store := storeI.(storage.ObjectStore)
 	id := c.Param("id")
 	var rec entity.File
 	if err := db.First(&rec, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "file not found"})
 		return
 	}
+	if !authorizeFile(c, &rec, entity.BucketRoleReader) {
+		return
+	}
 	if rec.IsDeleted {
 		c.JSON(http.StatusGone, gin.H{"code": 410, "msg": "file is deleted"})
 		return
@@ -627,8 +811,12 @@ func GetPresignedDownload(c *gin.Context) {
 	if v, err := strconv.Atoi(expiryStr); err == nil && v > 0 && v <= int(time.Hour.Seconds()) {
 		expiry = time.Duration(v) * time.Second
 	}
-	// 生成预签名URL
-	u, err := mc.PresignedGetObject(context.Background(), rec.Bucket, rec.ObjectName, expiry, nil)
+	// 生成预签名URL；存储驱动不支持直连时回退为服务器下载链接
+	u, err := store.PresignedGetObject(context.Background(), rec.Bucket, rec.ObjectName, expiry)
+	if errors.Is(err, storage.ErrNotSupported) {
+		c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": gin.H{"url": buildServerDownloadURL(c, rec.ID), "expiry": int(expiry.Seconds())}})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("presign error: %v", err)})
 		return
@@ -686,23 +874,4 @@ func parseRange(h string, size *int64) (start int64, end int64, ok bool) {
 	return s, e, true
 }
 
-// parseMeta 解析简单的 key=value 文本
-func parseMeta(s string) map[string]string {
-	res := map[string]string{}
-	for _, line := range strings.Split(s, "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		kv := strings.SplitN(line, "=", 2)
-		if len(kv) != 2 {
-			continue
-		}
-		k := strings.TrimSpace(kv[0])
-		v := strings.TrimSpace(kv[1])
-		res[k] = v
-	}
-	return res
-}
-
 func ptrString(s string) *string { return &s }
@@ -0,0 +1,33 @@
+package file
+
+import "testing"
+
+func TestParseRange(t *testing.T) {
+	size := int64(100)
+	tests := []struct {
+		header     string
+		size       *int64
+		start, end int64
+		ok         bool
+	}{
+		{"bytes=0-9", &size, 0, 9, true},
+		{"BYTES=10-19", &size, 10, 19, true},
+		{"bytes=90-", &size, 90, 99, true},
+		{"bytes=90-500", &size, 90, 99, true},
+		{"bytes= 5 - 6 ", &size, 5, 6, true},
+		{"bytes=5-6", nil, 5, 6, true},
+		{"bytes=5-", nil, 0, 0, false},
+		{"bytes=9-5", &size, 0, 0, false},
+		{"bytes=-5", &size, 0, 0, false},
+		{"bytes=a-b", &size, 0, 0, false},
+		{"bytes=5", &size, 0, 0, false},
+		{"items=0-1", &size, 0, 0, false},
+		{"", &size, 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, end, ok := parseRange(tt.header, tt.size)
+		if ok != tt.ok || (ok && (start != tt.start || end != tt.end)) {
+			t.Errorf("parseRange(%q) = %d, %d, %v; want %d, %d, %v", tt.header, start, end, ok, tt.start, tt.end, tt.ok)
+		}
+	}
+}
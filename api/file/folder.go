@@ -0,0 +1,370 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/model/response"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FolderListing 文件列表：按文件夹浏览（指定 folder 或 folder_id）时附带当前文件夹（根目录为空）与其直接子文件夹，
+// 此时 items 仅包含直接位于该文件夹中的文件
+type FolderListing struct {
+	response.Page[entity.File]
+	Folder  *entity.Folder  `json:"folder,omitempty"`
+	Folders []entity.Folder `json:"folders,omitempty"`
+}
+
+// CreateFolderRequest 创建文件夹：指定 path（自动创建缺失的上级文件夹），或 parent_id（为空表示根目录）与 name
+type CreateFolderRequest struct {
+	Bucket   string  `json:"bucket" binding:"required"`
+	Path     string  `json:"path"`
+	ParentID *uint64 `json:"parent_id"`
+	Name     string  `json:"name"`
+}
+
+// RenameFolderRequest 重命名文件夹
+type RenameFolderRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MoveFolderRequest 移动文件夹，parent_id 为空表示移动到 Bucket 根目录
+type MoveFolderRequest struct {
+	ParentID *uint64 `json:"parent_id"`
+}
+
+// respondFolderError 将文件夹操作的错误映射为响应
+func respondFolderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidFolder), errors.Is(err, service.ErrFolderCycle):
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+	case errors.Is(err, service.ErrFolderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": err.Error()})
+	case errors.Is(err, service.ErrFolderExists), errors.Is(err, service.ErrFolderNotEmpty):
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("folder error: %v", err)})
+	}
+}
+
+// resolveFolder 按 ID 或路径查找 bucket 中的文件夹，两者均为空或路径为根目录时返回 nil；失败时已写入响应
+func resolveFolder(c *gin.Context, db *gorm.DB, bucket string, id *uint64, path *string) (*entity.Folder, bool) {
+	var folder *entity.Folder
+	var err error
+	switch {
+	case id != nil && path != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "folder and folder_id are mutually exclusive"})
+		return nil, false
+	case id != nil:
+		folder, err = service.FindFolder(db, bucket, *id)
+	case path != nil:
+		folder, err = service.FindFolderByPath(db, bucket, *path)
+	}
+	if err != nil {
+		respondFolderError(c, err)
+		return nil, false
+	}
+	return folder, true
+}
+
+// resolveUploadFolder 解析上传表单中的 folder_id 或 folder（路径，不存在时逐级创建），返回目标文件夹 ID；
+// 均未提供时为 nil（Bucket 根目录）。失败时已写入响应
+func resolveUploadFolder(c *gin.Context, db *gorm.DB, bucket string) (*uint64, bool) {
+	var folder *entity.Folder
+	var err error
+	rawID, hasID := c.GetPostForm("folder_id")
+	path, hasPath := c.GetPostForm("folder")
+	switch {
+	case hasID && hasPath:
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "folder and folder_id are mutually exclusive"})
+		return nil, false
+	case hasID:
+		id, perr := strconv.ParseUint(rawID, 10, 64)
+		if perr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "invalid folder_id"})
+			return nil, false
+		}
+		folder, err = service.FindFolder(db, bucket, id)
+	case hasPath:
+		folder, err = service.EnsureFolderPath(db, bucket, path, currentUploaderID(c))
+	}
+	if err != nil {
+		respondFolderError(c, err)
+		return nil, false
+	}
+	if folder == nil {
+		return nil, true
+	}
+	return &folder.ID, true
+}
+
+// listSubfolders 列出 parent（为空表示根目录）的直接子文件夹，按名称排序
+func listSubfolders(db *gorm.DB, bucket string, parent *entity.Folder) ([]entity.Folder, error) {
+	q := db.Where("bucket = ?", bucket)
+	if parent == nil {
+		q = q.Where("parent_id IS NULL")
+	} else {
+		q = q.Where("parent_id = ?", parent.ID)
+	}
+	folders := []entity.Folder{}
+	err := q.Order("name ASC").Order("id ASC").Find(&folders).Error
+	return folders, err
+}
+
+// loadFolder 按路径参数 id 加载文件夹并校验 need 权限；失败时已写入响应
+func loadFolder(c *gin.Context, db *gorm.DB, need string) (*entity.Folder, bool) {
+	var f entity.Folder
+	if err := db.First(&f, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "folder not found"})
+		return nil, false
+	}
+	if !ownerOrBucketRole(c, f.Bucket, f.OwnerID, need) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden"})
+		return nil, false
+	}
+	return &f, true
+}
+
+// CreateFolder 创建文件夹
+// @Summary 创建文件夹
+// @Description 在 Bucket 中创建虚拟目录（仅记录在数据库中）：指定 path 时自动创建缺失的上级文件夹，或指定 parent_id（为空表示根目录）与 name；
+// @Description 需要 Bucket writer 及以上角色，同名文件夹已存在时返回 409
+// @Tags Folders
+// @Accept json
+// @Produce json
+// @Param body body CreateFolderRequest true "文件夹"
+// @Success 200 {object} response.Response[entity.Folder]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/folders [post]
+func CreateFolder(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	store := storeI.(storage.ObjectStore)
+	var req CreateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if (req.Path == "") == (req.Name == "") || (req.Path != "" && req.ParentID != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "either path, or name with optional parent_id, is required"})
+		return
+	}
+	if !checkUploadBucket(c, store, req.Bucket) || !authorizeBucketWrite(c, db, store, req.Bucket) ||
+		!ensureUploadBucket(c, db, store, req.Bucket) {
+		return
+	}
+	ownerID := currentUploaderID(c)
+	var parent *entity.Folder
+	name := req.Name
+	var err error
+	if req.Path != "" {
+		names, serr := service.SplitFolderPath(req.Path)
+		if serr != nil || len(names) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "invalid folder path"})
+			return
+		}
+		name = names[len(names)-1]
+		parent, err = service.EnsureFolderPath(db, req.Bucket, strings.Join(names[:len(names)-1], "/"), ownerID)
+	} else if req.ParentID != nil {
+		parent, err = service.FindFolder(db, req.Bucket, *req.ParentID)
+	}
+	if err != nil {
+		respondFolderError(c, err)
+		return
+	}
+	folder, err := service.CreateFolder(db, req.Bucket, parent, name, ownerID)
+	if err != nil {
+		respondFolderError(c, err)
+		return
+	}
+	response.Success(c, folder)
+}
+
+// ListFolders 列出文件夹的直接子文件夹
+// @Summary 获取子文件夹列表
+// @Description 列出 Bucket 根目录或指定文件夹（parent_id 或 path 二选一）的直接子文件夹，按名称排序；需要 Bucket reader 及以上角色
+// @Tags Folders
+// @Produce json
+// @Param bucket query string true "Bucket 名称"
+// @Param parent_id query int false "父文件夹 ID"
+// @Param path query string false "父文件夹路径，如 docs/2024"
+// @Success 200 {object} response.Response[[]entity.Folder]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/folders [get]
+func ListFolders(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	if !okDB {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	var q struct {
+		Bucket   string  `form:"bucket" binding:"required"`
+		ParentID *uint64 `form:"parent_id"`
+		Path     *string `form:"path"`
+	}
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid query: %v", err)})
+		return
+	}
+	allowed, claimed, err := bucketAllows(c, db, q.Bucket, entity.BucketRoleReader)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("bucket acl error: %v", err)})
+		return
+	}
+	if claimed && !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "forbidden: no read access to bucket"})
+		return
+	}
+	parent, ok := resolveFolder(c, db, q.Bucket, q.ParentID, q.Path)
+	if !ok {
+		return
+	}
+	folders, err := listSubfolders(db, q.Bucket, parent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("query error: %v", err)})
+		return
+	}
+	response.Success(c, folders)
+}
+
+// RenameFolder 重命名文件夹
+// @Summary 重命名文件夹
+// @Description 修改文件夹名称并同步更新子文件夹路径，其中的文件不受影响；需要文件夹创建者或 Bucket writer 及以上角色，同级已有同名文件夹时返回 409
+// @Tags Folders
+// @Accept json
+// @Produce json
+// @Param id path int true "文件夹 ID"
+// @Param body body RenameFolderRequest true "新名称"
+// @Success 200 {object} response.Response[entity.Folder]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/folders/{id}/rename [post]
+func RenameFolder(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	if !okDB {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	var req RenameFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	folder, ok := loadFolder(c, db, entity.BucketRoleWriter)
+	if !ok {
+		return
+	}
+	if err := service.RenameFolder(db, folder, req.Name); err != nil {
+		respondFolderError(c, err)
+		return
+	}
+	response.Success(c, folder)
+}
+
+// MoveFolder 移动文件夹
+// @Summary 移动文件夹
+// @Description 将文件夹连同其子文件夹与文件移动到同一 Bucket 中的另一个文件夹下（parent_id 为空表示根目录）；
+// @Description 需要文件夹创建者或 Bucket writer 及以上角色，不能移动到自身或其子文件夹中，目标位置已有同名文件夹时返回 409
+// @Tags Folders
+// @Accept json
+// @Produce json
+// @Param id path int true "文件夹 ID"
+// @Param body body MoveFolderRequest true "目标父文件夹"
+// @Success 200 {object} response.Response[entity.Folder]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/folders/{id}/move [post]
+func MoveFolder(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	if !okDB {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	var req MoveFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	folder, ok := loadFolder(c, db, entity.BucketRoleWriter)
+	if !ok {
+		return
+	}
+	var parent *entity.Folder
+	if req.ParentID != nil {
+		p, err := service.FindFolder(db, folder.Bucket, *req.ParentID)
+		if err != nil {
+			respondFolderError(c, err)
+			return
+		}
+		parent = p
+	}
+	if err := service.MoveFolder(db, folder, parent); err != nil {
+		respondFolderError(c, err)
+		return
+	}
+	response.Success(c, folder)
+}
+
+// DeleteFolder 删除空文件夹
+// @Summary 删除文件夹
+// @Description 删除不含子文件夹与文件（包括回收站中的文件）的文件夹，否则返回 409；需要文件夹创建者或 Bucket writer 及以上角色
+// @Tags Folders
+// @Produce json
+// @Param id path int true "文件夹 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/folders/{id} [delete]
+func DeleteFolder(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	if !okDB {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	folder, ok := loadFolder(c, db, entity.BucketRoleWriter)
+	if !ok {
+		return
+	}
+	if err := service.DeleteFolder(db, folder); err != nil {
+		respondFolderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "deleted"})
+}
@@ -2,7 +2,7 @@ package file
 
 import "github.com/gin-gonic/gin"
 
-// RegisterRoutes 注册文件相关子路由，由 /api/v1 分组传入
+// RegisterRoutes 注册文件与文件夹相关子路由，由 /api/v1 分组传入
 func RegisterRoutes(v1 *gin.RouterGroup) {
     files := v1.Group("/files")
     {
@@ -12,18 +12,48 @@ func RegisterRoutes(v1 *gin.RouterGroup) {
         // 压缩包分块上传（解决大文件上传问题）
         files.POST("/archive/multipart/init", InitArchiveChunkUpload)
         files.POST("/archive/multipart/chunk", UploadArchiveChunk)
+        files.POST("/archive/multipart/complete", CompleteArchiveChunkUpload)
         files.GET(":id", GetFile)
+        // 修改文件标签与自定义元数据
+        files.PATCH(":id", UpdateFile)
         files.GET(":id/download", DownloadFile)
         // 大文件分块上传
         files.POST("/multipart/init", InitChunkUpload)
         files.POST("/multipart/chunk", UploadChunk)
+        // 显式完成分块上传（分片可并发、乱序上传）
+        files.POST("/multipart/complete", CompleteChunkUpload)
+        // 查询分块上传会话已接收的分片（断点续传）
+        files.GET("/multipart/:upload_id", GetChunkUploadStatus)
+        // 取消分块上传（中止存储后端的分块上传）
+        files.DELETE("/multipart/:upload_id", AbortChunkUpload)
         // 根据 bucketName 获取文件列表
         files.GET("/bucket/:bucket", ListFilesByBucket)
+        // 回收站：列出已软删除的文件
+        files.GET("/bucket/:bucket/trash", ListTrashByBucket)
         // 获取所有 Buckets 列表
         files.GET("/buckets", ListBuckets)
+        // 按文件名全文检索（Postgres）
+        files.GET("/search", SearchFiles)
         // 获取直连 MinIO 的预签名下载链接（用于提升下载速度）
         files.GET(":id/presigned", GetPresignedDownload)
         files.DELETE(":id", DeleteFile)
+        // 从回收站恢复软删除的文件
+        files.POST(":id/restore", RestoreFile)
         files.DELETE(":id/hard-delete", HardDeleteFile)
+        // 批量操作：逐个返回处理结果，数据库修改在同一事务中提交
+        files.POST("/batch/delete", BatchDeleteFiles)
+        files.POST("/batch/restore", BatchRestoreFiles)
+        files.POST("/batch/hard-delete", BatchHardDeleteFiles)
+        files.POST("/batch/move", BatchMoveFiles)
+        files.POST("/batch/tag", BatchTagFiles)
+    }
+    // 文件夹（虚拟目录）：按 folder / folder_id 浏览见 GET /files/bucket/:bucket
+    folders := v1.Group("/folders")
+    {
+        folders.POST("", CreateFolder)
+        folders.GET("", ListFolders)
+        folders.POST("/:id/rename", RenameFolder)
+        folders.POST("/:id/move", MoveFolder)
+        folders.DELETE("/:id", DeleteFolder)
     }
 }
\ No newline at end of file
@@ -0,0 +1,203 @@
+package file
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// fileLabels 上传时指定的标签与自定义元数据，写入文件记录并同步到对象
+type fileLabels struct {
+	Tags     entity.Tags
+	Metadata entity.Metadata
+}
+
+// parseLabelForm 解析上传表单中的 tags（逗号分隔或重复传入）与 metadata（JSON 对象）
+func parseLabelForm(c *gin.Context) (fileLabels, error) {
+	var raw []string
+	for _, v := range c.PostFormArray("tags") {
+		raw = append(raw, strings.Split(v, ",")...)
+	}
+	tags, err := service.NormalizeTags(raw)
+	if err != nil {
+		return fileLabels{}, err
+	}
+	rawMeta := map[string]string{}
+	if v := strings.TrimSpace(c.PostForm("metadata")); v != "" {
+		if err := json.Unmarshal([]byte(v), &rawMeta); err != nil {
+			return fileLabels{}, fmt.Errorf("metadata must be a JSON object of strings: %v", err)
+		}
+	}
+	meta, err := service.NormalizeMetadata(rawMeta)
+	if err != nil {
+		return fileLabels{}, err
+	}
+	return fileLabels{Tags: tags, Metadata: meta}, nil
+}
+
+// putOptions 生成写入对象的选项；去重 Bucket 的对象由多条文件记录共享，标签与元数据仅保存在数据库中
+func (l fileLabels) putOptions(c *gin.Context, bucket, contentType string) storage.PutOptions {
+	opts := storage.PutOptions{ContentType: contentType}
+	if !dedupEnabled(c, bucket) {
+		opts.UserMetadata = l.Metadata
+		opts.Tags = service.ObjectTags(l.Tags)
+	}
+	return opts
+}
+
+// UpdateFileRequest 修改文件标签与元数据，未提供的字段保持不变，提供时整体替换
+type UpdateFileRequest struct {
+	Tags     *[]string          `json:"tags"`
+	Metadata *map[string]string `json:"metadata"`
+}
+
+// UpdateFile 修改文件标签与自定义元数据
+// @Summary 修改文件标签与元数据
+// @Description 整体替换文件的 tags 和/或 metadata，并同步到对象标签与用户元数据（去重 Bucket 中的共享对象除外；开启版本控制的 Bucket 中元数据仅保存在数据库，避免重写对象留下历史版本）；同步失败时恢复原值并返回 500。需要上传者或 Bucket writer 及以上角色
+// @Tags Files
+// @Accept json
+// @Produce json
+// @Param id path int true "文件记录 ID"
+// @Param body body UpdateFileRequest true "标签与元数据"
+// @Success 200 {object} entity.File
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/{id} [patch]
+func UpdateFile(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+	store := storeI.(storage.ObjectStore)
+	var req UpdateFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if req.Tags == nil && req.Metadata == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "tags or metadata is required"})
+		return
+	}
+	updates := map[string]interface{}{}
+	var labels fileLabels
+	if req.Tags != nil {
+		tags, err := service.NormalizeTags(*req.Tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+			return
+		}
+		labels.Tags, updates["tags"] = tags, tags
+	}
+	if req.Metadata != nil {
+		meta, err := service.NormalizeMetadata(*req.Metadata)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+			return
+		}
+		labels.Metadata, updates["metadata"] = meta, meta
+	}
+
+	var rec entity.File
+	if err := db.First(&rec, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "file not found"})
+		return
+	}
+	if !authorizeFile(c, &rec, entity.BucketRoleWriter) {
+		return
+	}
+	if rec.IsDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "file not found"})
+		return
+	}
+
+	// 先提交数据库再同步对象，避免在存储往返期间持有行锁；同步失败时恢复对象标签与数据库中的旧值
+	prev := fileLabels{Tags: rec.Tags, Metadata: rec.Metadata}
+	if err := db.Model(&rec).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("update file error: %v", err)})
+		return
+	}
+	if !dedupEnabled(c, rec.Bucket) {
+		if err := syncObjectLabels(context.Background(), store, &rec, req.Tags != nil, req.Metadata != nil, labels, prev); err != nil {
+			if rerr := revertFileLabels(db, rec.ID, updates, labels, prev); rerr != nil {
+				err = fmt.Errorf("%w; revert labels: %v", err, rerr)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("update file error: %v", err)})
+			return
+		}
+	}
+	if req.Tags != nil {
+		rec.Tags = labels.Tags
+	}
+	if req.Metadata != nil {
+		rec.Metadata = labels.Metadata
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "updated", "data": rec})
+}
+
+// syncObjectLabels 将标签与元数据同步到对象；元数据写入失败时恢复已写入的对象标签。
+// 存储后端无法在不留下历史版本的情况下替换元数据时（ErrNotSupported），元数据仅保存在数据库中
+func syncObjectLabels(ctx context.Context, store storage.ObjectStore, rec *entity.File, setTags, setMeta bool, labels, prev fileLabels) error {
+	if setTags {
+		if err := store.SetObjectTags(ctx, rec.Bucket, rec.ObjectName, service.ObjectTags(labels.Tags)); err != nil {
+			return fmt.Errorf("set object tags: %w", err)
+		}
+	}
+	if setMeta {
+		err := store.SetObjectMetadata(ctx, rec.Bucket, rec.ObjectName, labels.Metadata)
+		if err != nil && !errors.Is(err, storage.ErrNotSupported) {
+			if setTags {
+				if terr := store.SetObjectTags(ctx, rec.Bucket, rec.ObjectName, service.ObjectTags(prev.Tags)); terr != nil {
+					return fmt.Errorf("set object metadata: %w; restore object tags: %v", err, terr)
+				}
+			}
+			return fmt.Errorf("set object metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// revertFileLabels 同步对象失败时将数据库中的标签与元数据恢复为旧值；期间已被其他请求修改的字段保持不变
+func revertFileLabels(db *gorm.DB, id uint64, updates map[string]interface{}, applied, prev fileLabels) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var cur entity.File
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "tags", "metadata").First(&cur, "id = ?", id).Error; err != nil {
+			return err
+		}
+		revert := map[string]interface{}{}
+		if _, ok := updates["tags"]; ok && sameJSON(cur.Tags, applied.Tags) {
+			revert["tags"] = prev.Tags
+		}
+		if _, ok := updates["metadata"]; ok && sameJSON(cur.Metadata, applied.Metadata) {
+			revert["metadata"] = prev.Metadata
+		}
+		if len(revert) == 0 {
+			return nil
+		}
+		return tx.Model(&entity.File{}).Where("id = ?", id).Updates(revert).Error
+	})
+}
+
+// sameJSON 按列的 JSON 编码比较标签或元数据（nil 与空值等价，元数据的键有序编码）
+func sameJSON(a, b driver.Valuer) bool {
+	av, aerr := a.Value()
+	bv, berr := b.Value()
+	return aerr == nil && berr == nil && av == bv
+}
@@ -0,0 +1,105 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/storage"
+)
+
+// labelStore 记录对象标签与元数据的写入，metaErr 非空时元数据写入失败
+type labelStore struct {
+	storage.ObjectStore
+	tags    map[string]map[string]string
+	meta    map[string]map[string]string
+	metaErr error
+}
+
+func (s *labelStore) SetObjectTags(ctx context.Context, bucket, key string, tags map[string]string) error {
+	s.tags[bucket+"/"+key] = tags
+	return s.ObjectStore.SetObjectTags(ctx, bucket, key, tags)
+}
+
+func (s *labelStore) SetObjectMetadata(ctx context.Context, bucket, key string, meta map[string]string) error {
+	if s.metaErr != nil {
+		return s.metaErr
+	}
+	s.meta[bucket+"/"+key] = meta
+	return s.ObjectStore.SetObjectMetadata(ctx, bucket, key, meta)
+}
+
+func newLabelEnv(t *testing.T) (*testEnv, *labelStore) {
+	var ls *labelStore
+	env := newTestEnv(t, func(s storage.ObjectStore) storage.ObjectStore {
+		ls = &labelStore{ObjectStore: s, tags: map[string]map[string]string{}, meta: map[string]map[string]string{}}
+		return ls
+	})
+	return env, ls
+}
+
+func TestUpdateFileSyncsLabels(t *testing.T) {
+	env, ls := newLabelEnv(t)
+	rec := env.putFile(t, "docs", "a.txt", []byte("a"), nil)
+	code, resp := env.do(t, http.MethodPatch, fmt.Sprintf("/api/v1/files/%d", rec.ID), map[string]interface{}{
+		"tags":     []string{"red", "blue"},
+		"metadata": map[string]string{"Project": "apollo"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	got := env.reload(t, rec.ID)
+	if !reflect.DeepEqual(got.Tags, entity.Tags{"red", "blue"}) || got.Metadata["project"] != "apollo" {
+		t.Fatalf("db labels = %v %v", got.Tags, got.Metadata)
+	}
+	if len(ls.tags["docs/a.txt"]) != 2 || ls.meta["docs/a.txt"]["project"] != "apollo" {
+		t.Fatalf("object labels = %v %v", ls.tags, ls.meta)
+	}
+	info, err := env.store.StatObject(context.Background(), "docs", "a.txt")
+	if err != nil || info.UserMetadata["project"] != "apollo" {
+		t.Fatalf("stat = %+v, %v", info, err)
+	}
+}
+
+func TestUpdateFileRevertsWhenMetadataSyncFails(t *testing.T) {
+	env, ls := newLabelEnv(t)
+	rec := env.putFile(t, "docs", "a.txt", []byte("a"), func(f *entity.File) {
+		f.Tags = entity.Tags{"old"}
+		f.Metadata = entity.Metadata{"stage": "draft"}
+	})
+	ls.metaErr = errors.New("boom")
+	code, _ := env.do(t, http.MethodPatch, fmt.Sprintf("/api/v1/files/%d", rec.ID), map[string]interface{}{
+		"tags":     []string{"new"},
+		"metadata": map[string]string{"stage": "final"},
+	})
+	if code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", code)
+	}
+	got := env.reload(t, rec.ID)
+	if !reflect.DeepEqual(got.Tags, entity.Tags{"old"}) || got.Metadata["stage"] != "draft" {
+		t.Fatalf("db labels not reverted: %v %v", got.Tags, got.Metadata)
+	}
+	// 已写入的对象标签恢复为旧值
+	if tags := ls.tags["docs/a.txt"]; !reflect.DeepEqual(tags, map[string]string{"old": ""}) {
+		t.Fatalf("object tags not restored: %v", tags)
+	}
+}
+
+func TestUpdateFileKeepsMetadataInDBWhenStoreCannotRewrite(t *testing.T) {
+	env, ls := newLabelEnv(t)
+	rec := env.putFile(t, "docs", "a.txt", []byte("a"), nil)
+	ls.metaErr = storage.ErrNotSupported
+	code, resp := env.do(t, http.MethodPatch, fmt.Sprintf("/api/v1/files/%d", rec.ID), map[string]interface{}{
+		"metadata": map[string]string{"stage": "final"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	if got := env.reload(t, rec.ID); got.Metadata["stage"] != "final" {
+		t.Fatalf("db metadata = %v", got.Metadata)
+	}
+}
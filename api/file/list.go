@@ -0,0 +1,288 @@
+package file
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/model/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// sortColumns 允许的排序字段与对应的 SQL 表达式；可空列使用 COALESCE 保证游标比较稳定
+var sortColumns = map[string]string{
+	"id":         "id",
+	"name":       "COALESCE(original_name, '')",
+	"size":       "COALESCE(size, 0)",
+	"created_at": "created_at",
+	"deleted_at": "deleted_at", // 仅回收站列表可用
+}
+
+// FileFilter 文件列表与搜索共用的筛选条件
+type FileFilter struct {
+	MimePrefix    string   `form:"mime_prefix"`    // MIME 类型前缀，如 image/
+	Name          string   `form:"name"`           // 原始文件名包含的子串（不区分大小写）
+	MinSize       *int64   `form:"min_size"`       // 最小字节数（含）
+	MaxSize       *int64   `form:"max_size"`       // 最大字节数（含）
+	CreatedAfter  string   `form:"created_after"`  // RFC3339 或 YYYY-MM-DD（含）
+	CreatedBefore string   `form:"created_before"` // RFC3339 或 YYYY-MM-DD（不含）
+	UploaderID    *uint64  `form:"uploader_id"`
+	Tags          []string `form:"tag"`  // 须同时包含的标签，可重复传入
+	Meta          []string `form:"meta"` // 须匹配的元数据，格式 key:value，可重复传入
+}
+
+// ListFilesQuery 文件列表的分页、筛选与排序参数
+type ListFilesQuery struct {
+	Limit  int    `form:"limit"`  // 每页数量，默认 50，最大 500
+	Offset int    `form:"offset"` // 偏移分页，与 cursor 二选一
+	Cursor string `form:"cursor"` // 游标分页：上一页返回的 next_cursor
+	Sort   string `form:"sort"`   // id | name | size | created_at（回收站另支持 deleted_at），默认 id（回收站默认 deleted_at）
+	Order  string `form:"order"`  // asc | desc，默认 desc
+	// 按文件夹浏览：仅返回直接位于该文件夹中的文件，并附带其子文件夹；folder 为路径（"/" 表示根目录），与 folder_id 二选一
+	Folder   *string `form:"folder"`
+	FolderID *uint64 `form:"folder_id"`
+	FileFilter
+}
+
+// errInvalidCursor 游标无法解析或与当前排序参数不一致
+var errInvalidCursor = errors.New("invalid cursor")
+
+// fileCursor 游标内容：上一页最后一条记录的排序值与 ID
+type fileCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    uint64 `json:"id"`
+}
+
+// bindListFilesQuery 解析并校验列表参数，填充默认值；trash 为 true 时用于回收站列表
+func bindListFilesQuery(c *gin.Context, trash bool) (*ListFilesQuery, error) {
+	var q ListFilesQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		return nil, fmt.Errorf("invalid query: %v", err)
+	}
+	if err := normalizePage(&q.Limit, q.Offset); err != nil {
+		return nil, err
+	}
+	if q.Sort == "" {
+		q.Sort = "id"
+		if trash {
+			q.Sort = "deleted_at"
+		}
+	}
+	if _, ok := sortColumns[q.Sort]; !ok || (q.Sort == "deleted_at" && !trash) {
+		if trash {
+			return nil, fmt.Errorf("invalid sort %q, expected id, name, size, created_at or deleted_at", q.Sort)
+		}
+		return nil, fmt.Errorf("invalid sort %q, expected id, name, size or created_at", q.Sort)
+	}
+	q.Order = strings.ToLower(q.Order)
+	if q.Order == "" {
+		q.Order = "desc"
+	}
+	if q.Order != "asc" && q.Order != "desc" {
+		return nil, fmt.Errorf("invalid order %q, expected asc or desc", q.Order)
+	}
+	return &q, nil
+}
+
+// normalizePage 填充默认每页数量并校验偏移量
+func normalizePage(limit *int, offset int) error {
+	if *limit <= 0 {
+		*limit = defaultPageSize
+	}
+	if *limit > maxPageSize {
+		*limit = maxPageSize
+	}
+	if offset < 0 {
+		return errors.New("offset must be >= 0")
+	}
+	return nil
+}
+
+// apply 追加筛选条件
+func (q *FileFilter) apply(db *gorm.DB) (*gorm.DB, error) {
+	if q.MinSize != nil && q.MaxSize != nil && *q.MinSize > *q.MaxSize {
+		return nil, errors.New("min_size must be <= max_size")
+	}
+	if q.MimePrefix != "" {
+		db = db.Where("mime_type LIKE ? ESCAPE '\\'", escapeLike(q.MimePrefix)+"%")
+	}
+	if q.Name != "" {
+		db = db.Where("LOWER(original_name) LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(q.Name))+"%")
+	}
+	if q.MinSize != nil {
+		db = db.Where("size >= ?", *q.MinSize)
+	}
+	if q.MaxSize != nil {
+		db = db.Where("size <= ?", *q.MaxSize)
+	}
+	if q.CreatedAfter != "" {
+		t, err := parseTimeParam(q.CreatedAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_after: %v", err)
+		}
+		db = db.Where("created_at >= ?", t)
+	}
+	if q.CreatedBefore != "" {
+		t, err := parseTimeParam(q.CreatedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid created_before: %v", err)
+		}
+		db = db.Where("created_at < ?", t)
+	}
+	if q.UploaderID != nil {
+		db = db.Where("uploader_id = ?", *q.UploaderID)
+	}
+	// 标签与元数据以 JSONB 包含（@>）匹配，可命中 GIN 索引
+	if len(q.Tags) > 0 {
+		b, _ := json.Marshal(q.Tags)
+		db = db.Where("tags @> ?::jsonb", string(b))
+	}
+	if len(q.Meta) > 0 {
+		meta := map[string]string{}
+		for _, kv := range q.Meta {
+			k, v, ok := strings.Cut(kv, ":")
+			if !ok || k == "" {
+				return nil, fmt.Errorf("invalid meta %q, expected key:value", kv)
+			}
+			meta[strings.ToLower(k)] = v
+		}
+		b, _ := json.Marshal(meta)
+		db = db.Where("metadata @> ?::jsonb", string(b))
+	}
+	return db, nil
+}
+
+// paginate 统计满足筛选条件的总数，并按排序与分页参数查询一页文件
+func (q *ListFilesQuery) paginate(db *gorm.DB) (*response.Page[entity.File], error) {
+	page := &response.Page[entity.File]{Items: []entity.File{}, Limit: q.Limit, Offset: q.Offset}
+	if err := db.Session(&gorm.Session{}).Model(&entity.File{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	col := sortColumns[q.Sort]
+	cmp, dir := "<", "DESC"
+	if q.Order == "asc" {
+		cmp, dir = ">", "ASC"
+	}
+	tx := db.Session(&gorm.Session{})
+	if q.Cursor != "" {
+		cur, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cur.Sort != q.Sort || cur.Order != q.Order {
+			return nil, fmt.Errorf("%w: sort or order changed", errInvalidCursor)
+		}
+		v, err := cursorValue(q.Sort, cur.Value)
+		if err != nil {
+			return nil, err
+		}
+		// 键集分页：(排序值, id) 严格位于上一页最后一条之后
+		if q.Sort == "id" {
+			tx = tx.Where("id "+cmp+" ?", cur.ID)
+		} else {
+			tx = tx.Where(fmt.Sprintf("(%s %s ?) OR (%s = ? AND id %s ?)", col, cmp, col, cmp), v, v, cur.ID)
+		}
+		page.Offset = 0
+	} else if q.Offset > 0 {
+		tx = tx.Offset(q.Offset)
+	}
+	if q.Sort != "id" {
+		tx = tx.Order(col + " " + dir)
+	}
+	// 多取一条用于判断是否还有下一页
+	if err := tx.Order("id " + dir).Limit(q.Limit + 1).Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
+	if len(page.Items) > q.Limit {
+		page.Items = page.Items[:q.Limit]
+		page.HasMore = true
+		last := page.Items[q.Limit-1]
+		page.NextCursor = encodeCursor(fileCursor{Sort: q.Sort, Order: q.Order, Value: sortValue(&last, q.Sort), ID: last.ID})
+	}
+	return page, nil
+}
+
+// sortValue 取出记录在排序列上的值，与 sortColumns 中的 COALESCE 保持一致
+func sortValue(f *entity.File, sort string) string {
+	switch sort {
+	case "name":
+		if f.OriginalName != nil {
+			return *f.OriginalName
+		}
+		return ""
+	case "size":
+		if f.Size != nil {
+			return strconv.FormatInt(*f.Size, 10)
+		}
+		return "0"
+	case "created_at":
+		return f.CreatedAt.Format(time.RFC3339Nano)
+	case "deleted_at":
+		if f.DeletedAt != nil {
+			return f.DeletedAt.Format(time.RFC3339Nano)
+		}
+		return ""
+	}
+	return strconv.FormatUint(f.ID, 10)
+}
+
+func encodeCursor(cur fileCursor) string {
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*fileCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cur fileCursor
+	if err := json.Unmarshal(b, &cur); err != nil {
+		return nil, errInvalidCursor
+	}
+	return &cur, nil
+}
+
+// cursorValue 将游标中的排序值还原为对应列的类型
+func cursorValue(sort, v string) (interface{}, error) {
+	switch sort {
+	case "size":
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		return n, nil
+	case "created_at", "deleted_at":
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		return t, nil
+	}
+	return v, nil
+}
+
+func parseTimeParam(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+// escapeLike 转义 LIKE 通配符，避免用户输入的 % 与 _ 被当作模式
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,74 @@
+package file
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/binhy/go-template/model/entity"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+	name := "report 2024.pdf"
+	size := int64(4096)
+	rec := &entity.File{ID: 42, OriginalName: &name, Size: &size, CreatedAt: created}
+	tests := []struct {
+		sort string
+		want interface{}
+	}{
+		{"id", "42"},
+		{"name", name},
+		{"size", size},
+		{"created_at", created},
+	}
+	for _, tt := range tests {
+		encoded := encodeCursor(fileCursor{Sort: tt.sort, Order: "desc", Value: sortValue(rec, tt.sort), ID: rec.ID})
+		cur, err := decodeCursor(encoded)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sort, err)
+		}
+		if cur.Sort != tt.sort || cur.Order != "desc" || cur.ID != rec.ID {
+			t.Fatalf("%s: decoded %+v", tt.sort, cur)
+		}
+		v, err := cursorValue(cur.Sort, cur.Value)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sort, err)
+		}
+		if got, ok := v.(time.Time); ok {
+			if !got.Equal(tt.want.(time.Time)) {
+				t.Errorf("%s: got %v, want %v", tt.sort, got, tt.want)
+			}
+		} else if v != tt.want {
+			t.Errorf("%s: got %v (%T), want %v (%T)", tt.sort, v, v, tt.want, tt.want)
+		}
+	}
+}
+
+func TestSortValueDefaults(t *testing.T) {
+	rec := &entity.File{ID: 7}
+	for sort, want := range map[string]string{"name": "", "size": "0", "deleted_at": "", "id": "7"} {
+		if got := sortValue(rec, sort); got != want {
+			t.Errorf("sortValue(%q) = %q, want %q", sort, got, want)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, s := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"id":"x"}`)),
+	} {
+		if _, err := decodeCursor(s); !errors.Is(err, errInvalidCursor) {
+			t.Errorf("decodeCursor(%q) error = %v, want errInvalidCursor", s, err)
+		}
+	}
+	if _, err := cursorValue("size", "abc"); !errors.Is(err, errInvalidCursor) {
+		t.Errorf("cursorValue(size) error = %v", err)
+	}
+	if _, err := cursorValue("created_at", "yesterday"); !errors.Is(err, errInvalidCursor) {
+		t.Errorf("cursorValue(created_at) error = %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/model/response"
+	"github.com/binhy/go-template/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead 表单字段与 multipart 边界的额外字节，限制请求体时在最大对象大小之上预留
+const multipartOverhead = 1 << 20
+
+func quotaConfig(c *gin.Context) config.QuotaConfig {
+	if v, ok := c.Get("config"); ok {
+		if cfg, ok := v.(*config.Config); ok && cfg != nil {
+			return cfg.Quota
+		}
+	}
+	return config.QuotaConfig{}
+}
+
+// parseLimitedForm 按全局最大对象大小限制请求体后解析 multipart 表单，超出时返回 413；
+// 须在首次读取表单字段之前调用，其余解析错误交由后续的 FormFile 处理
+func parseLimitedForm(c *gin.Context) bool {
+	if max := quotaConfig(c).MaxObjectSize; max > 0 {
+		if c.Request.ContentLength > max+multipartOverhead {
+			respondQuotaError(c, &service.QuotaError{Scope: service.QuotaScopeObject, Limit: max, Requested: c.Request.ContentLength}, nil)
+			return false
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max+multipartOverhead)
+	}
+	if _, err := c.MultipartForm(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			max := quotaConfig(c).MaxObjectSize
+			respondQuotaError(c, &service.QuotaError{Scope: service.QuotaScopeObject, Limit: max, Requested: tooLarge.Limit + 1}, nil)
+			return false
+		}
+	}
+	return true
+}
+
+// newQuotaTracker 读取上传到 bucket 时生效的限制与当前用量，出错时返回 500
+func newQuotaTracker(c *gin.Context, db *gorm.DB, bucket string, ownerID *uint64) (*service.QuotaTracker, bool) {
+	tracker, err := service.NewQuotaTracker(db, quotaConfig(c), bucket, ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("quota lookup error: %v", err)})
+		return nil, false
+	}
+	return tracker, true
+}
+
+// checkQuota 校验写入并在超限时返回 413
+func checkQuota(c *gin.Context, tracker *service.QuotaTracker, objectSize, delta int64) bool {
+	if err := tracker.Check(objectSize, delta); err != nil {
+		respondQuotaError(c, err, nil)
+		return false
+	}
+	return true
+}
+
+// checkChunkQuota 分块上传接收分片前校验：会话累计大小不得超过最大对象大小与初始化时声明的 total_size，
+// 新增字节不得超过配额（重传同一分片时只计差值）；返回生效的限制，供记录分片时在事务中再次校验配额
+func checkChunkQuota(c *gin.Context, db *gorm.DB, sess *entity.UploadSession, chunkIndex int, chunkSize int64) (service.QuotaLimits, bool) {
+	var received, previous int64
+	if err := db.Model(&entity.UploadPart{}).Where("session_id = ? AND part_number <> ?", sess.ID, chunkIndex).
+		Select("COALESCE(SUM(size), 0)").Scan(&received).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("query error: %v", err)})
+		return service.QuotaLimits{}, false
+	}
+	if err := db.Model(&entity.UploadPart{}).Where("session_id = ? AND part_number = ?", sess.ID, chunkIndex).
+		Select("COALESCE(SUM(size), 0)").Scan(&previous).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("query error: %v", err)})
+		return service.QuotaLimits{}, false
+	}
+	if sess.TotalSize != nil && received+chunkSize > *sess.TotalSize {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("chunks exceed declared total_size of %d bytes", *sess.TotalSize)})
+		return service.QuotaLimits{}, false
+	}
+	tracker, ok := newQuotaTracker(c, db, sess.Bucket, sess.OwnerID)
+	if !ok || !checkQuota(c, tracker, received+chunkSize, chunkSize-previous) {
+		return service.QuotaLimits{}, false
+	}
+	return tracker.Limits, true
+}
+
+// respondQuotaError 超出大小限制或配额时返回 413 及区分原因的业务码，extra 合并到 data 中；其余错误返回 false
+func respondQuotaError(c *gin.Context, err error, extra gin.H) bool {
+	var qe *service.QuotaError
+	if !errors.As(err, &qe) {
+		return false
+	}
+	code := response.CodeQuotaExceeded
+	if qe.Scope == service.QuotaScopeObject {
+		code = response.CodeObjectTooLarge
+	}
+	data := gin.H{
+		"scope":     qe.Scope,
+		"limit":     qe.Limit,
+		"used":      qe.Used,
+		"requested": qe.Requested,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"code": code, "msg": qe.Error(), "data": data})
+	return true
+}
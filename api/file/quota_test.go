@@ -0,0 +1,106 @@
+package file
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/model/response"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+)
+
+// putHookStore 在写入对象后执行 afterPut，用于模拟写入存储期间提交的并发上传
+type putHookStore struct {
+	storage.ObjectStore
+	afterPut func()
+}
+
+func (s *putHookStore) PutObject(ctx context.Context, bucket, key string, r io.Reader, size int64, opts storage.PutOptions) (storage.ObjectInfo, error) {
+	info, err := s.ObjectStore.PutObject(ctx, bucket, key, r, size, opts)
+	if err == nil && s.afterPut != nil {
+		s.afterPut()
+	}
+	return info, err
+}
+
+// occupy 写入一条占用 size 字节的文件记录，代表已提交的并发上传
+func (e *testEnv) occupy(t *testing.T, bucket string, size int64) {
+	t.Helper()
+	rec := &entity.File{Bucket: bucket, ObjectName: "concurrent", URL: "x", Size: &size}
+	if err := e.db.Create(rec).Error; err != nil {
+		t.Error(err)
+	}
+}
+
+func assertQuotaExceeded(t *testing.T, code int, resp map[string]interface{}) {
+	t.Helper()
+	if code != http.StatusRequestEntityTooLarge || resp["code"] != float64(response.CodeQuotaExceeded) {
+		t.Fatalf("status = %d, want 413: %v", code, resp)
+	}
+}
+
+func TestUploadQuotaRecheckedOnCommit(t *testing.T) {
+	var hook *putHookStore
+	env := newTestEnv(t, func(s storage.ObjectStore) storage.ObjectStore {
+		hook = &putHookStore{ObjectStore: s}
+		return hook
+	})
+	env.cfg.Quota.BucketQuota = 10
+	// 写入存储前的校验通过后，并发上传抢先占用了配额
+	hook.afterPut = func() { env.occupy(t, "docs", 8) }
+	code, resp := env.form(t, "/api/v1/files", map[string]string{"bucket": "docs"}, "file", []byte("hello"))
+	assertQuotaExceeded(t, code, resp)
+	if used, err := service.BucketUsage(env.db, "docs"); err != nil || used != 8 {
+		t.Fatalf("usage = %d, %v", used, err)
+	}
+	if n := env.objectCount(t, "docs"); n != 0 {
+		t.Fatalf("%d objects left in storage", n)
+	}
+}
+
+func TestChunkQuotaRecheckedOnCommit(t *testing.T) {
+	var hook *partHookStore
+	env := newTestEnv(t, func(s storage.ObjectStore) storage.ObjectStore {
+		hook = &partHookStore{ObjectStore: s}
+		return hook
+	})
+	env.cfg.Quota.BucketQuota = storage.MinPartSize + 8
+	id := env.initUpload(t, map[string]string{"total_chunks": "2"})
+	hook.afterPart = func() { env.occupy(t, "docs", 9) }
+	head, _ := twoChunks()
+	code, resp := env.uploadChunk(t, id, "1", "2", head, nil)
+	assertQuotaExceeded(t, code, resp)
+	var n int64
+	if err := env.db.Model(&entity.UploadPart{}).Where("session_id = ?", id).Count(&n).Error; err != nil || n != 0 {
+		t.Fatalf("recorded %d parts, %v", n, err)
+	}
+}
+
+func TestChunkUploadUsageNotDoubleCounted(t *testing.T) {
+	env := newTestEnv(t, nil)
+	head, tail := twoChunks()
+	total := int64(len(head) + len(tail))
+	// 配额恰好容纳本次上传：合并时分片与文件记录不会同时计入
+	env.cfg.Quota.BucketQuota = total
+	id := env.initUpload(t, nil)
+	if code, resp := env.uploadChunk(t, id, "1", "2", head, nil); code != http.StatusOK {
+		t.Fatalf("chunk 1 status = %d: %v", code, resp)
+	}
+	if used, err := service.BucketUsage(env.db, "docs"); err != nil || used != int64(len(head)) {
+		t.Fatalf("usage = %d, %v", used, err)
+	}
+	if code, resp := env.uploadChunk(t, id, "2", "2", tail, nil); code != http.StatusOK {
+		t.Fatalf("chunk 2 status = %d: %v", code, resp)
+	}
+	if sess := sessionByID(t, env, id); sess.Status != entity.UploadStatusCompleted {
+		t.Fatalf("session status = %s", sess.Status)
+	}
+	if used, err := service.BucketUsage(env.db, "docs"); err != nil || used != total {
+		t.Fatalf("usage = %d, %v, want %d", used, err, total)
+	}
+	code, resp := env.form(t, "/api/v1/files", map[string]string{"bucket": "docs"}, "file", []byte("x"))
+	assertQuotaExceeded(t, code, resp)
+}
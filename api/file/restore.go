@@ -0,0 +1,61 @@
+package file
+
+import (
+	"net/http"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RestoreFile 从回收站恢复软删除的文件
+// @Summary 恢复文件
+// @Description 将软删除的文件恢复为正常状态；权限要求与删除相同（上传者或 Bucket admin）。文件未删除时直接返回成功，已被永久清理或正在清理的文件返回 404
+// @Tags Files
+// @Param id path int true "文件记录 ID"
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/{id}/restore [post]
+func RestoreFile(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	if !okDB {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "database not initialized"})
+		return
+	}
+	db := dbI.(*gorm.DB)
+
+	var rec entity.File
+	if err := db.First(&rec, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "file not found"})
+		return
+	}
+	if !authorizeFile(c, &rec, entity.BucketRoleAdmin) {
+		return
+	}
+	if !rec.IsDeleted {
+		// 幂等处理：未删除直接返回成功
+		c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "not deleted", "data": rec})
+		return
+	}
+
+	// 按条件更新，与后台清理任务并发时以先完成者为准；清理任务已开始删除对象的文件不可恢复
+	res := db.Model(&entity.File{}).
+		Where("id = ? AND is_deleted = ? AND purging_at IS NULL", rec.ID, true).
+		Updates(map[string]interface{}{"is_deleted": false, "deleted_at": nil})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "update record error"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "file not found"})
+		return
+	}
+	rec.IsDeleted, rec.DeletedAt = false, nil
+	rec.URL = buildServerDownloadURL(c, rec.ID)
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "restored", "data": rec})
+}
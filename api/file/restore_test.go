@@ -0,0 +1,38 @@
+package file
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/binhy/go-template/model/entity"
+)
+
+func TestRestoreRefusesFileBeingPurged(t *testing.T) {
+	env := newTestEnv(t, nil)
+	deletedAt := time.Now().Add(-time.Hour)
+	purging := env.putFile(t, "docs", "a.txt", []byte("a"), func(f *entity.File) {
+		f.IsDeleted, f.DeletedAt, f.PurgingAt = true, &deletedAt, &deletedAt
+	})
+	trashed := env.putFile(t, "docs", "b.txt", []byte("b"), func(f *entity.File) {
+		f.IsDeleted, f.DeletedAt = true, &deletedAt
+	})
+
+	if code, resp := env.do(t, http.MethodPost, fmt.Sprintf("/api/v1/files/%d/restore", purging.ID), nil); code != http.StatusNotFound {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	if got := env.reload(t, purging.ID); !got.IsDeleted {
+		t.Fatal("purging file restored")
+	}
+	code, resp := env.do(t, http.MethodPost, "/api/v1/files/batch/restore", map[string]interface{}{"ids": []uint64{purging.ID, trashed.ID}})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	if got := env.reload(t, purging.ID); !got.IsDeleted {
+		t.Fatal("purging file restored by batch")
+	}
+	if got := env.reload(t, trashed.ID); got.IsDeleted {
+		t.Fatal("trashed file not restored")
+	}
+}
@@ -0,0 +1,192 @@
+package file
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/model/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxSearchTerms 单次检索最多使用的关键词数量
+const maxSearchTerms = 16
+
+var searchTermPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// SearchFilesQuery 全文检索参数
+type SearchFilesQuery struct {
+	Q      string `form:"q" binding:"required"` // 关键词，多个关键词需同时匹配，按前缀匹配
+	Bucket string `form:"bucket"`               // 限定 Bucket，为空时检索全部可读 Bucket
+	Limit  int    `form:"limit"`                // 每页数量，默认 50，最大 500
+	Offset int    `form:"offset"`
+	FileFilter
+}
+
+// SearchHit 检索结果：文件信息、相关度与高亮后的文件名（HTML 转义，匹配片段以 <mark> 包裹）
+type SearchHit struct {
+	entity.File
+	Rank      float64 `json:"rank"`
+	Highlight string  `json:"highlight"`
+}
+
+// searchRow 查询结果行，Rank 由 ts_rank 计算
+type searchRow struct {
+	entity.File `gorm:"embedded"`
+	Rank        float64
+}
+
+// SearchFiles 按文件名、标签与自定义元数据全文检索文件
+// @Summary 检索文件
+// @Description 基于 Postgres 全文检索（search_vector + GIN 索引）按原始文件名、标签与自定义元数据检索，文件名匹配权重最高，结果按相关度排序并高亮文件名中的匹配片段；
+// @Description 非管理员仅能检索自己上传的文件及拥有角色的 Bucket 中的文件。支持与列表接口相同的筛选条件
+// @Tags Files
+// @Param q query string true "关键词，多个关键词以空格分隔，需同时匹配（前缀匹配）"
+// @Param bucket query string false "限定 Bucket"
+// @Param limit query int false "每页数量，默认 50，最大 500"
+// @Param offset query int false "偏移量"
+// @Param mime_prefix query string false "MIME 类型前缀，如 image/"
+// @Param name query string false "文件名包含的子串（不区分大小写）"
+// @Param min_size query int false "最小字节数（含）"
+// @Param max_size query int false "最大字节数（含）"
+// @Param created_after query string false "创建时间下限（含），RFC3339 或 YYYY-MM-DD"
+// @Param created_before query string false "创建时间上限（不含），RFC3339 或 YYYY-MM-DD"
+// @Param uploader_id query int false "上传者用户 ID"
+// @Param tag query []string false "须同时包含的标签" collectionFormat(multi)
+// @Param meta query []string false "须匹配的元数据，格式 key:value" collectionFormat(multi)
+// @Produce json
+// @Success 200 {object} response.Response[response.Page[SearchHit]]
+// @Failure 400 {object} map[string]interface{}
+// @Failure 501 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/search [get]
+func SearchFiles(c *gin.Context) {
+	dbI, okDB := c.Get("db")
+	if !okDB {
+		response.Error(c, http.StatusInternalServerError, 500, "database not initialized")
+		return
+	}
+	db := dbI.(*gorm.DB)
+	if db.Dialector.Name() != "postgres" {
+		response.Error(c, http.StatusNotImplemented, 501, "full-text search requires PostgreSQL")
+		return
+	}
+	var params SearchFilesQuery
+	if err := c.ShouldBindQuery(&params); err != nil {
+		response.Error(c, http.StatusBadRequest, 400, fmt.Sprintf("invalid query: %v", err))
+		return
+	}
+	if err := normalizePage(&params.Limit, params.Offset); err != nil {
+		response.Error(c, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+	terms := searchTerms(params.Q)
+	if len(terms) == 0 {
+		response.Error(c, http.StatusBadRequest, 400, "q must contain at least one letter or digit")
+		return
+	}
+	tsquery := buildTSQuery(terms)
+
+	q := db.Model(&entity.File{}).
+		Where("is_deleted = ?", false).
+		Where("search_vector @@ to_tsquery('simple', ?)", tsquery)
+	if params.Bucket != "" {
+		q = q.Where("bucket = ?", params.Bucket)
+	}
+	q, err := scopeToReadable(c, db, q)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, 500, fmt.Sprintf("bucket acl error: %v", err))
+		return
+	}
+	if q, err = params.FileFilter.apply(q); err != nil {
+		response.Error(c, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	page := &response.Page[SearchHit]{Items: []SearchHit{}, Limit: params.Limit, Offset: params.Offset}
+	if err := q.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		response.Error(c, http.StatusInternalServerError, 500, fmt.Sprintf("query error: %v", err))
+		return
+	}
+	var rows []searchRow
+	err = q.Select("files.*, ts_rank(search_vector, to_tsquery('simple', ?)) AS rank", tsquery).
+		Order("rank DESC").Order("id DESC").
+		Offset(params.Offset).Limit(params.Limit).
+		Find(&rows).Error
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, 500, fmt.Sprintf("query error: %v", err))
+		return
+	}
+	for _, row := range rows {
+		row.URL = buildServerDownloadURL(c, row.ID)
+		name := ""
+		if row.OriginalName != nil {
+			name = *row.OriginalName
+		}
+		page.Items = append(page.Items, SearchHit{File: row.File, Rank: row.Rank, Highlight: highlightTerms(name, terms)})
+	}
+	page.HasMore = int64(params.Offset+len(page.Items)) < page.Total
+	response.Success(c, page)
+}
+
+// searchTerms 从输入中提取关键词（字母与数字序列），统一小写并去重
+func searchTerms(q string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, t := range searchTermPattern.FindAllString(strings.ToLower(q), -1) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		terms = append(terms, t)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// buildTSQuery 构造前缀匹配的 tsquery，关键词仅含字母与数字，无需额外转义
+func buildTSQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = t + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// highlightTerms 在文件名中以 <mark> 标出以关键词开头的词，与 search_vector 按非字母数字切分的口径一致
+func highlightTerms(name string, terms []string) string {
+	if name == "" || len(terms) == 0 {
+		return html.EscapeString(name)
+	}
+	// 长关键词优先，避免较短的前缀抢先匹配
+	sorted := append([]string(nil), terms...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	quoted := make([]string, len(sorted))
+	for i, t := range sorted {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	re, err := regexp.Compile(`(?i)(^|[^\p{L}\p{N}])(` + strings.Join(quoted, "|") + `)`)
+	if err != nil {
+		return html.EscapeString(name)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(name, -1) {
+		start, end := m[4], m[5]
+		b.WriteString(html.EscapeString(name[last:start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(name[start:end]))
+		b.WriteString("</mark>")
+		last = end
+	}
+	b.WriteString(html.EscapeString(name[last:]))
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package file
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		q    string
+		want []string
+	}{
+		{"", nil},
+		{"  ***  ", nil},
+		{"Report", []string{"report"}},
+		{"annual-report_2024.PDF", []string{"annual", "report", "2024", "pdf"}},
+		{"report REPORT Report", []string{"report"}},
+		{"a:* & b | !c", []string{"a", "b", "c"}},
+		{"季度报告 q3", []string{"季度报告", "q3"}},
+	}
+	for _, tt := range tests {
+		if got := searchTerms(tt.q); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("searchTerms(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+
+	words := make([]string, maxSearchTerms+5)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+	if got := searchTerms(strings.Join(words, " ")); len(got) != maxSearchTerms {
+		t.Errorf("got %d terms, want at most %d", len(got), maxSearchTerms)
+	}
+}
+
+func TestBuildTSQuery(t *testing.T) {
+	if got := buildTSQuery([]string{"annual", "rep"}); got != "annual:* & rep:*" {
+		t.Errorf("buildTSQuery = %q", got)
+	}
+}
+
+func TestHighlightTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+		want  string
+	}{
+		{"report.pdf", nil, "report.pdf"},
+		{"", []string{"x"}, ""},
+		{"Annual-Report.pdf", []string{"report"}, "Annual-<mark>Report</mark>.pdf"},
+		{"reports_2024.pdf", []string{"rep", "2024"}, "<mark>rep</mark>orts_<mark>2024</mark>.pdf"},
+		// 仅匹配词首，不匹配词中间
+		{"prereport.txt", []string{"report"}, "prereport.txt"},
+		// 长关键词优先
+		{"report.txt", []string{"rep", "report"}, "<mark>report</mark>.txt"},
+		{"<script>report.html", []string{"report"}, "&lt;script&gt;<mark>report</mark>.html"},
+	}
+	for _, tt := range tests {
+		if got := highlightTerms(tt.name, tt.terms); got != tt.want {
+			t.Errorf("highlightTerms(%q, %q) = %q, want %q", tt.name, tt.terms, got, tt.want)
+		}
+	}
+}
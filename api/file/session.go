@@ -0,0 +1,727 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/service"
+	"github.com/binhy/go-template/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	sessionKindFile    = entity.UploadKindFile
+	sessionKindArchive = entity.UploadKindArchive
+
+	// defaultSessionTTL 未配置 upload.session_ttl 时的会话有效期
+	defaultSessionTTL = 24 * time.Hour
+)
+
+var (
+	errSessionNotFound = errors.New("upload session not found")
+	// errSessionInactive 会话已进入合并或终态，不再接收分片
+	errSessionInactive = errors.New("upload session is no longer active")
+)
+
+// sessionDeps 从上下文获取会话处理所需的数据库与存储
+func sessionDeps(c *gin.Context) (*gorm.DB, storage.ObjectStore, bool) {
+	dbI, okDB := c.Get("db")
+	storeI, okStore := c.Get("storage")
+	if !okDB || !okStore {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "storage or database not initialized"})
+		return nil, nil, false
+	}
+	return dbI.(*gorm.DB), storeI.(storage.ObjectStore), true
+}
+
+// sessionTTL 返回会话有效期（自最后一次活动起计算），过期会话由后台清理任务回收
+func sessionTTL(c *gin.Context) time.Duration {
+	if v, ok := c.Get("config"); ok {
+		if cfg, ok := v.(*config.Config); ok && cfg.Upload.SessionTTL > 0 {
+			return cfg.Upload.SessionTTL
+		}
+	}
+	return defaultSessionTTL
+}
+
+// createSession 初始化会话：以 opts 在存储后端创建分块上传，并将会话写入数据库；调用前须确保 bucket 存在
+func createSession(db *gorm.DB, store storage.ObjectStore, sess *entity.UploadSession, opts storage.PutOptions, ttl time.Duration) error {
+	ctx := context.Background()
+	storageUploadID, err := store.NewMultipartUpload(ctx, sess.Bucket, sess.ObjectName, opts)
+	if err != nil {
+		return fmt.Errorf("init multipart upload error: %v", err)
+	}
+	sess.ID = uuid.New().String()
+	sess.StorageUploadID = storageUploadID
+	sess.Status = entity.UploadStatusActive
+	sess.ExpiresAt = time.Now().Add(ttl)
+	if err := db.Create(sess).Error; err != nil {
+		_ = store.AbortMultipartUpload(ctx, sess.Bucket, sess.ObjectName, storageUploadID)
+		return fmt.Errorf("init session error: %v", err)
+	}
+	return nil
+}
+
+// handleInit 解析初始化表单并创建会话；objectName 根据原始文件名生成目标对象名
+func handleInit(c *gin.Context, kind string, objectName func(filename string) string) {
+	db, store, ok := sessionDeps(c)
+	if !ok {
+		return
+	}
+	bucket := c.PostForm("bucket")
+	filename := c.PostForm("filename")
+	mimeType := c.PostForm("mime_type")
+	if bucket == "" || filename == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "bucket and filename are required"})
+		return
+	}
+	if !checkUploadBucket(c, store, bucket) || !authorizeBucketWrite(c, db, store, bucket) {
+		return
+	}
+	folderID, ok := resolveUploadFolder(c, db, bucket)
+	if !ok {
+		return
+	}
+	totalSize, totalChunks, err := parseSessionTotals(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	// 已声明总大小时在初始化阶段即校验大小限制与配额，分片上传过程中仍会逐片校验
+	if totalSize != nil {
+		tracker, ok := newQuotaTracker(c, db, bucket, currentUploaderID(c))
+		if !ok || !checkQuota(c, tracker, *totalSize, *totalSize) {
+			return
+		}
+	}
+	expected, err := parseChecksum(c.PostForm("checksum_algorithm"), c.PostForm("checksum"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	labels, err := parseLabelForm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	preserve := false
+	if kind == sessionKindArchive {
+		if preserve, err = parsePreservePaths(c); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+			return
+		}
+	}
+	sess := &entity.UploadSession{
+		Kind:          kind,
+		Bucket:        bucket,
+		FolderID:      folderID,
+		PreservePaths: preserve,
+		Filename:      filename,
+		ObjectName:    objectName(filename),
+		TotalSize:     totalSize,
+		TotalChunks:   totalChunks,
+		Tags:          labels.Tags,
+		Metadata:      labels.Metadata,
+		OwnerID:       currentUploaderID(c),
+	}
+	if mimeType != "" {
+		sess.MimeType = &mimeType
+	}
+	if expected != nil {
+		sess.ChecksumAlgo = &expected.Algorithm
+		sess.Checksum = &expected.Value
+	}
+	// 普通文件在初始化时即携带标签与元数据；压缩包的暂存对象完成后即删除，标签在解压入库时写入各文件
+	opts := storage.PutOptions{ContentType: safeContentType(mimeType)}
+	if kind == sessionKindFile {
+		opts = labels.putOptions(c, bucket, opts.ContentType)
+	}
+	if !ensureUploadBucket(c, db, store, bucket) {
+		return
+	}
+	if err := createSession(db, store, sess, opts, sessionTTL(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "ok", "data": gin.H{"upload_id": sess.ID, "expires_at": sess.ExpiresAt}})
+}
+
+// parseSessionTotals 解析初始化时可选的 total_size / total_chunks
+func parseSessionTotals(c *gin.Context) (*int64, *int, error) {
+	var totalSize *int64
+	var totalChunks *int
+	if v := c.PostForm("total_size"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return nil, nil, fmt.Errorf("invalid total_size")
+		}
+		totalSize = &n
+	}
+	if v := c.PostForm("total_chunks"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, nil, fmt.Errorf("invalid total_chunks")
+		}
+		totalChunks = &n
+	}
+	return totalSize, totalChunks, nil
+}
+
+// loadSession 读取仍处于进行中的会话
+func loadSession(db *gorm.DB, uploadID, kind string) (*entity.UploadSession, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return nil, errSessionNotFound
+	}
+	var sess entity.UploadSession
+	q := db.Where("id = ?", uploadID)
+	if kind != "" {
+		q = q.Where("kind = ?", kind)
+	}
+	if err := q.First(&sess).Error; err != nil {
+		return nil, errSessionNotFound
+	}
+	return &sess, nil
+}
+
+// listSessionParts 返回会话已接收的分片（按分片号升序）
+func listSessionParts(db *gorm.DB, sessionID string) ([]entity.UploadPart, error) {
+	var parts []entity.UploadPart
+	err := db.Where("session_id = ?", sessionID).Order("part_number ASC").Find(&parts).Error
+	return parts, err
+}
+
+// missingParts 返回 1..totalChunks 中尚未接收的分片序号
+func missingParts(parts []entity.UploadPart, totalChunks int) []int {
+	have := make(map[int]bool, len(parts))
+	for _, p := range parts {
+		have[p.PartNumber] = true
+	}
+	missing := make([]int, 0)
+	for i := 1; i <= totalChunks; i++ {
+		if !have[i] {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// completeParts 取 1..totalChunks 的分片并按序排列，忽略超出范围的多余分片
+func completeParts(parts []entity.UploadPart, totalChunks int) []storage.Part {
+	res := make([]storage.Part, 0, totalChunks)
+	for _, p := range parts {
+		if p.PartNumber >= 1 && p.PartNumber <= totalChunks {
+			res = append(res, storage.Part{Number: p.PartNumber, Size: p.Size, ETag: p.ETag})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Number < res[j].Number })
+	return res
+}
+
+// acquireCompleteLock 通过条件更新 active -> completing 抢占合并权，保证多实例下同一会话只会被合并一次
+func acquireCompleteLock(db *gorm.DB, sess *entity.UploadSession) bool {
+	res := db.Model(&entity.UploadSession{}).
+		Where("id = ? AND status = ?", sess.ID, entity.UploadStatusActive).
+		Update("status", entity.UploadStatusCompleting)
+	if res.Error != nil || res.RowsAffected != 1 {
+		return false
+	}
+	sess.Status = entity.UploadStatusCompleting
+	return true
+}
+
+// savePart 记录已写入存储的分片并顺延会话有效期，run 非空时同时推进会话的整体摘要。
+// 与 acquireCompleteLock 争用同一会话行：仅当会话仍为 active 时写入，否则返回 errSessionInactive，
+// 保证合并开始后到达的分片不会改写已被合并读取的分片记录；分片新增的字节按 limits 在同一事务中校验配额
+func savePart(db *gorm.DB, sess *entity.UploadSession, rec *entity.UploadPart, limits service.QuotaLimits, expiresAt time.Time, run *runningDigest) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&entity.UploadSession{}).
+			Where("id = ? AND status = ?", sess.ID, entity.UploadStatusActive).
+			Update("expires_at", expiresAt)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return errSessionInactive
+		}
+		var prev entity.UploadPart
+		if err := tx.Where("session_id = ? AND part_number = ?", sess.ID, rec.PartNumber).Limit(1).Find(&prev).Error; err != nil {
+			return err
+		}
+		if err := service.EnforceQuota(tx, limits, sess.Bucket, sess.OwnerID, rec.Size-prev.Size); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}, {Name: "part_number"}},
+			DoUpdates: clause.AssignmentColumns([]string{"size", "e_tag", "sha256", "created_at"}),
+		}).Create(rec).Error; err != nil {
+			return err
+		}
+		if prev.ID != 0 && prev.SHA256 != rec.SHA256 {
+			// 已累积的分片被不同内容覆盖，整体摘要作废，须从第 1 片起按序重传才能重新累积
+			if err := tx.Model(&entity.UploadSession{}).
+				Where("id = ? AND hashed_parts >= ?", sess.ID, rec.PartNumber).
+				Updates(map[string]interface{}{"hashed_parts": 0, "hash_state": nil, "checksum_state": nil}).Error; err != nil {
+				return err
+			}
+		}
+		if run == nil {
+			return nil
+		}
+		return run.save(tx, sess.ID)
+	})
+}
+
+// lockedParts 持有合并锁后重新读取会话与分片记录：抢锁前提交的分片及整体摘要均可见，之后的分片不会再写入；
+// 失败时释放合并锁并写入响应
+func lockedParts(c *gin.Context, db *gorm.DB, sess *entity.UploadSession) ([]entity.UploadPart, bool) {
+	parts, err := listSessionParts(db, sess.ID)
+	if err == nil {
+		err = db.First(sess, "id = ?", sess.ID).Error
+	}
+	if err != nil {
+		releaseCompleteLock(db, sess)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("list parts error: %v", err)})
+		return nil, false
+	}
+	return parts, true
+}
+
+// runningDigest 按分片顺序累积的整个文件摘要。会话保存第 1..HashedParts 片累积后的中间状态，
+// 下一片到达时在校验分片的同一次读取中继续累积，完成时无需回读拼接后的对象；乱序到达的分片不参与累积
+type runningDigest struct {
+	from  int    // 累积本分片前的分片数
+	base  []byte // 累积本分片前的 SHA-256 状态，保存时据此判断会话状态未被并发修改
+	sha   hash.Hash
+	extra hash.Hash
+}
+
+// nextRunningDigest 分片恰为下一个待累积的分片时恢复会话中的中间状态，否则返回 nil。
+// 压缩包会话在解析时会完整读取暂存对象，不做累积
+func nextRunningDigest(sess *entity.UploadSession, chunkIndex int) (*runningDigest, error) {
+	if sess.Kind != sessionKindFile || chunkIndex != sess.HashedParts+1 {
+		return nil, nil
+	}
+	sha, err := restoreHash(checksumSHA256, sess.HashState)
+	if err != nil {
+		return nil, err
+	}
+	run := &runningDigest{from: sess.HashedParts, base: sess.HashState, sha: sha}
+	if algo := sessionExtraAlgo(sess); algo != "" {
+		if run.extra, err = restoreHash(algo, sess.ChecksumState); err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+func (r *runningDigest) Write(p []byte) (int, error) {
+	r.sha.Write(p)
+	if r.extra != nil {
+		r.extra.Write(p)
+	}
+	return len(p), nil
+}
+
+// save 条件更新会话的中间状态：仅当会话仍停留在累积本分片前的状态时推进，
+// 并发重传同一分片或期间摘要被作废时放弃（本分片可按序重传后再累积）
+func (r *runningDigest) save(tx *gorm.DB, sessionID string) error {
+	state, err := hashState(r.sha)
+	if err != nil {
+		return err
+	}
+	updates := map[string]interface{}{"hashed_parts": r.from + 1, "hash_state": state, "checksum_state": nil}
+	if r.extra != nil {
+		if updates["checksum_state"], err = hashState(r.extra); err != nil {
+			return err
+		}
+	}
+	q := tx.Model(&entity.UploadSession{}).Where("id = ? AND hashed_parts = ?", sessionID, r.from)
+	if r.from > 0 {
+		q = q.Where("hash_state = ?", r.base)
+	}
+	return q.Updates(updates).Error
+}
+
+// sessionExtraAlgo 返回需要与 SHA-256 一同累积的算法（初始化时声明的 md5 / crc32c），无需时返回空
+func sessionExtraAlgo(sess *entity.UploadSession) string {
+	if sess.ChecksumAlgo == nil || *sess.ChecksumAlgo == checksumSHA256 {
+		return ""
+	}
+	return *sess.ChecksumAlgo
+}
+
+// sessionDigester 由会话累积的中间状态得出整个文件的摘要；第 1..totalChunks 片未全部按序累积时返回 nil
+func sessionDigester(sess *entity.UploadSession, totalChunks int) (*digester, error) {
+	if sess.HashedParts != totalChunks {
+		return nil, nil
+	}
+	d := newDigester(sessionChecksum(sess))
+	var err error
+	if d.sha, err = restoreHash(checksumSHA256, sess.HashState); err != nil {
+		return nil, err
+	}
+	if d.extra != nil {
+		if d.extra, err = restoreHash(d.expected.Algorithm, sess.ChecksumState); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// releaseCompleteLock 合并失败时恢复为 active，允许客户端重试 complete
+func releaseCompleteLock(db *gorm.DB, sess *entity.UploadSession) {
+	_ = db.Model(&entity.UploadSession{}).
+		Where("id = ? AND status = ?", sess.ID, entity.UploadStatusCompleting).
+		Update("status", entity.UploadStatusActive).Error
+	sess.Status = entity.UploadStatusActive
+}
+
+// finishSession 将会话标记为终态并删除分片记录
+func finishSession(db *gorm.DB, sess *entity.UploadSession, status string) {
+	_ = db.Where("session_id = ?", sess.ID).Delete(&entity.UploadPart{}).Error
+	_ = db.Model(&entity.UploadSession{}).Where("id = ?", sess.ID).Update("status", status).Error
+	sess.Status = status
+}
+
+// sessionChecksum 返回会话声明的整个文件摘要；未声明时返回 nil
+func sessionChecksum(sess *entity.UploadSession) *expectedChecksum {
+	if sess.ChecksumAlgo == nil || sess.Checksum == nil || *sess.Checksum == "" {
+		return nil
+	}
+	return &expectedChecksum{Algorithm: *sess.ChecksumAlgo, Value: *sess.Checksum}
+}
+
+// sessionFinalizer 在全部分片到齐并持有合并锁后完成会话，负责写入响应
+type sessionFinalizer func(c *gin.Context, sess *entity.UploadSession, parts []storage.Part)
+
+// handleChunk 接收单个分片并直接写入存储后端的分块上传；全部到齐后调用 finalize
+func handleChunk(c *gin.Context, kind string, finalize sessionFinalizer) {
+	db, store, ok := sessionDeps(c)
+	if !ok || !parseLimitedForm(c) {
+		return
+	}
+
+	uploadID := c.PostForm("upload_id")
+	chunkIndexStr := c.PostForm("chunk_index")
+	totalChunksStr := c.PostForm("total_chunks")
+	if uploadID == "" || chunkIndexStr == "" || totalChunksStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "upload_id, chunk_index, total_chunks are required"})
+		return
+	}
+	chunkIndex, _ := strconv.Atoi(chunkIndexStr)
+	totalChunks, _ := strconv.Atoi(totalChunksStr)
+	if totalChunks < 1 || chunkIndex < 1 || chunkIndex > totalChunks {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "chunk_index must be within [1, total_chunks]"})
+		return
+	}
+	sess, err := loadSession(db, uploadID, kind)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "invalid upload_id"})
+		return
+	}
+	if !authorizeSession(c, sess) {
+		return
+	}
+	if sess.Status != entity.UploadStatusActive {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": fmt.Sprintf("upload session is %s", sess.Status)})
+		return
+	}
+	if time.Now().After(sess.ExpiresAt) {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "upload session is expired"})
+		return
+	}
+	if sess.TotalChunks != nil && *sess.TotalChunks != totalChunks {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("total_chunks mismatch, session expects %d", *sess.TotalChunks)})
+		return
+	}
+
+	fh, err := c.FormFile("chunk")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("chunk fetch error: %v", err)})
+		return
+	}
+	// 与 S3 限制一致：除最后一个分片外，每个分片不得小于 MinPartSize
+	if chunkIndex < totalChunks && fh.Size < storage.MinPartSize {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("chunk too small: every chunk except the last must be at least %d bytes", storage.MinPartSize)})
+		return
+	}
+	limits, ok := checkChunkQuota(c, db, sess, chunkIndex, fh.Size)
+	if !ok {
+		return
+	}
+	// 写入存储前校验分片摘要，损坏的分片直接拒绝，客户端可重传该分片
+	expected, err := parseChecksum(c.PostForm("checksum_algorithm"), c.PostForm("checksum"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	// 分片恰为下一个待累积的分片时，在同一次读取中继续累积整个文件的摘要
+	run, err := nextRunningDigest(sess, chunkIndex)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("restore digest error: %v", err)})
+		return
+	}
+	var tee []io.Writer
+	if run != nil {
+		tee = append(tee, run)
+	}
+	src, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk open error: %v", err)})
+		return
+	}
+	digest, err := digestReader(src, expected, tee...)
+	_ = src.Close()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk read error: %v", err)})
+		return
+	}
+	if err := digest.Verify(); err != nil {
+		respondChecksumError(c, err)
+		return
+	}
+	src, err = fh.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk open error: %v", err)})
+		return
+	}
+	defer src.Close()
+
+	// 校验与读取分片期间会话可能已开始合并，写入存储前再确认一次
+	if err := db.Model(&entity.UploadSession{}).Select("status").Where("id = ?", sess.ID).Scan(&sess.Status).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("load session error: %v", err)})
+		return
+	}
+	if sess.Status != entity.UploadStatusActive {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": fmt.Sprintf("upload session is %s", sess.Status)})
+		return
+	}
+
+	// 分片号即 chunk_index；重复上传同一分片（断点续传重试）会覆盖旧分片
+	ctx := context.Background()
+	part, err := store.PutObjectPart(ctx, sess.Bucket, sess.ObjectName, sess.StorageUploadID, chunkIndex, src, fh.Size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("chunk write error: %v", err)})
+		return
+	}
+	rec := entity.UploadPart{SessionID: sess.ID, PartNumber: chunkIndex, Size: part.Size, ETag: part.ETag, SHA256: digest.SHA256()}
+	if err := savePart(db, sess, &rec, limits, time.Now().Add(sessionTTL(c)), run); err != nil {
+		if respondQuotaError(c, err, nil) {
+			return
+		}
+		if errors.Is(err, errSessionInactive) {
+			// 合并已按此前的分片记录进行；本次写入不予记录，客户端可在合并失败、会话恢复 active 后重传
+			c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": fmt.Sprintf("%v, chunk discarded", err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("save part error: %v", err)})
+		return
+	}
+
+	// 检查所有分片是否到齐（不依赖最后一个分片最后到达）；未到齐则返回进度
+	parts, err := listSessionParts(db, sess.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("list parts error: %v", err)})
+		return
+	}
+	missing := missingParts(parts, totalChunks)
+	if len(missing) > 0 {
+		c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "chunk received", "data": gin.H{"received": chunkIndex, "total": totalChunks, "missing": len(missing)}})
+		return
+	}
+	// 全部到齐：并发上传时只有拿到合并锁的请求负责合并，其余请求仅确认分片已接收
+	if !acquireCompleteLock(db, sess) {
+		c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "chunk received", "data": gin.H{"received": chunkIndex, "total": totalChunks, "missing": 0, "completing": true}})
+		return
+	}
+	parts, ok = lockedParts(c, db, sess)
+	if !ok {
+		return
+	}
+	finalize(c, sess, completeParts(parts, totalChunks))
+}
+
+// handleComplete 显式完成会话：校验分片完整性并获取合并锁后调用 finalize
+func handleComplete(c *gin.Context, kind string, finalize sessionFinalizer) {
+	db, _, ok := sessionDeps(c)
+	if !ok {
+		return
+	}
+
+	uploadID := c.PostForm("upload_id")
+	totalChunks, _ := strconv.Atoi(c.PostForm("total_chunks"))
+	if uploadID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "upload_id is required"})
+		return
+	}
+	sess, err := loadSession(db, uploadID, kind)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": err.Error()})
+		return
+	}
+	if !authorizeSession(c, sess) {
+		return
+	}
+	if sess.Status == entity.UploadStatusActive && time.Now().After(sess.ExpiresAt) {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "upload session is expired"})
+		return
+	}
+	// 完成时可提供（或覆盖初始化时声明的）整个文件摘要
+	expected, err := parseChecksum(c.PostForm("checksum_algorithm"), c.PostForm("checksum"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+	// 普通文件的整体摘要在分片到达时累积，md5 / crc32c 只有在初始化时声明才会一同累积
+	if expected != nil && kind == sessionKindFile && expected.Algorithm != checksumSHA256 &&
+		(sess.ChecksumAlgo == nil || *sess.ChecksumAlgo != expected.Algorithm) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("%s checksum must be declared at init, or use sha256", expected.Algorithm)})
+		return
+	}
+	if expected != nil {
+		sess.ChecksumAlgo = &expected.Algorithm
+		sess.Checksum = &expected.Value
+		if err := db.Model(&entity.UploadSession{}).Where("id = ?", sess.ID).
+			Updates(map[string]interface{}{"checksum_algo": expected.Algorithm, "checksum": expected.Value}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("save checksum error: %v", err)})
+			return
+		}
+	}
+	if totalChunks < 1 && sess.TotalChunks != nil {
+		totalChunks = *sess.TotalChunks
+	}
+	if totalChunks < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "total_chunks is required"})
+		return
+	}
+	parts, err := listSessionParts(db, sess.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("list parts error: %v", err)})
+		return
+	}
+	if missing := missingParts(parts, totalChunks); len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "upload incomplete", "data": gin.H{"missing": missing}})
+		return
+	}
+	if !acquireCompleteLock(db, sess) {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": fmt.Sprintf("upload session is %s", sess.Status)})
+		return
+	}
+	if parts, ok = lockedParts(c, db, sess); !ok {
+		return
+	}
+	finalize(c, sess, completeParts(parts, totalChunks))
+}
+
+// GetChunkUploadStatus 查询分块上传会话已接收的分片，用于断点续传
+// @Summary 查询分块上传进度
+// @Description 返回会话状态与已接收的分片序号、大小，客户端据此只补传缺失的分片；普通文件与压缩包分块会话均适用；hashed_parts 为已按序累积到整体摘要中的分片数
+// @Tags Files
+// @Produce json
+// @Param upload_id path string true "初始化返回的会话ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/multipart/{upload_id} [get]
+func GetChunkUploadStatus(c *gin.Context) {
+	db, _, ok := sessionDeps(c)
+	if !ok {
+		return
+	}
+	sess, err := loadSession(db, c.Param("upload_id"), "")
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": err.Error()})
+		return
+	}
+	if !authorizeSession(c, sess) {
+		return
+	}
+	parts, err := listSessionParts(db, sess.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("list parts error: %v", err)})
+		return
+	}
+	received := make([]gin.H, 0, len(parts))
+	var receivedBytes int64
+	for _, p := range parts {
+		received = append(received, gin.H{"index": p.PartNumber, "size": p.Size, "sha256": p.SHA256})
+		receivedBytes += p.Size
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": gin.H{
+		"upload_id":      sess.ID,
+		"kind":           sess.Kind,
+		"bucket":         sess.Bucket,
+		"filename":       sess.Filename,
+		"status":         sess.Status,
+		"total_size":     sess.TotalSize,
+		"total_chunks":   sess.TotalChunks,
+		"expires_at":     sess.ExpiresAt,
+		"received":       received,
+		"received_bytes": receivedBytes,
+		"hashed_parts":   sess.HashedParts,
+	}})
+}
+
+// AbortChunkUpload 取消分块上传：中止存储后端的分块上传并结束会话
+// @Summary 取消分块上传
+// @Description 中止存储后端的分块上传（释放已上传的分片）并结束会话；普通文件与压缩包分块会话均适用
+// @Tags Files
+// @Produce json
+// @Param upload_id path string true "初始化返回的会话ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/files/multipart/{upload_id} [delete]
+func AbortChunkUpload(c *gin.Context) {
+	db, store, ok := sessionDeps(c)
+	if !ok {
+		return
+	}
+	sess, err := loadSession(db, c.Param("upload_id"), "")
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": err.Error()})
+		return
+	}
+	if !authorizeSession(c, sess) {
+		return
+	}
+	if !acquireCompleteLock(db, sess) {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": fmt.Sprintf("upload session is %s", sess.Status)})
+		return
+	}
+	if err := store.AbortMultipartUpload(context.Background(), sess.Bucket, sess.ObjectName, sess.StorageUploadID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		releaseCompleteLock(db, sess)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": fmt.Sprintf("abort multipart upload error: %v", err)})
+		return
+	}
+	finishSession(db, sess, entity.UploadStatusAborted)
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "aborted"})
+}
+
+// objectNameFor 生成对象名，保留原扩展名
+func objectNameFor(filename string) string {
+	objectName := uuid.New().String()
+	if ext := strings.ToLower(filepath.Ext(filename)); ext != "" {
+		objectName += ext
+	}
+	return objectName
+}
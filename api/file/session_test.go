@@ -0,0 +1,272 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/storage"
+)
+
+// partHookStore 在写入分片后执行 afterPart，用于模拟与合并并发的分片写入
+type partHookStore struct {
+	storage.ObjectStore
+	afterPart func()
+}
+
+func (s *partHookStore) PutObjectPart(ctx context.Context, bucket, key, uploadID string, number int, r io.Reader, size int64) (storage.Part, error) {
+	part, err := s.ObjectStore.PutObjectPart(ctx, bucket, key, uploadID, number, r, size)
+	if err == nil && s.afterPart != nil {
+		s.afterPart()
+	}
+	return part, err
+}
+
+// initUpload 初始化分块上传会话，返回 upload_id
+func (e *testEnv) initUpload(t *testing.T, fields map[string]string) string {
+	t.Helper()
+	form := map[string]string{"bucket": "docs", "filename": "big.bin"}
+	for k, v := range fields {
+		form[k] = v
+	}
+	code, resp := e.form(t, "/api/v1/files/multipart/init", form, "", nil)
+	if code != http.StatusOK {
+		t.Fatalf("init status = %d: %v", code, resp)
+	}
+	return resp["data"].(map[string]interface{})["upload_id"].(string)
+}
+
+func (e *testEnv) uploadChunk(t *testing.T, uploadID, index, total string, data []byte, extra map[string]string) (int, map[string]interface{}) {
+	t.Helper()
+	fields := map[string]string{"upload_id": uploadID, "chunk_index": index, "total_chunks": total}
+	for k, v := range extra {
+		fields[k] = v
+	}
+	return e.form(t, "/api/v1/files/multipart/chunk", fields, "chunk", data)
+}
+
+func TestUploadChunkSingleChunkCompletes(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.initUpload(t, nil)
+	code, resp := env.uploadChunk(t, id, "1", "1", []byte("hello"), nil)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	var sess entity.UploadSession
+	if err := env.db.First(&sess, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sess.Status != entity.UploadStatusCompleted {
+		t.Fatalf("session status = %s", sess.Status)
+	}
+	var rec entity.File
+	if err := env.db.First(&rec, "bucket = ? AND object_name = ?", sess.Bucket, sess.ObjectName).Error; err != nil {
+		t.Fatal(err)
+	}
+	if rec.Size == nil || *rec.Size != 5 {
+		t.Fatalf("file size = %v", rec.Size)
+	}
+}
+
+func TestUploadChunkDiscardedOnceCompletionStarts(t *testing.T) {
+	var hook *partHookStore
+	env := newTestEnv(t, func(s storage.ObjectStore) storage.ObjectStore {
+		hook = &partHookStore{ObjectStore: s}
+		return hook
+	})
+	id := env.initUpload(t, map[string]string{"total_chunks": "2"})
+	var before entity.UploadSession
+	if err := env.db.First(&before, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 分片写入存储后、记录前，另一请求抢到了合并锁
+	hook.afterPart = func() {
+		if err := env.db.Model(&entity.UploadSession{}).Where("id = ?", id).
+			Update("status", entity.UploadStatusCompleting).Error; err != nil {
+			t.Error(err)
+		}
+	}
+	code, resp := env.uploadChunk(t, id, "2", "2", []byte("tail"), nil)
+	if code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %v", code, resp)
+	}
+	var n int64
+	if err := env.db.Model(&entity.UploadPart{}).Where("session_id = ?", id).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("recorded %d parts for a completing session", n)
+	}
+	var after entity.UploadSession
+	if err := env.db.First(&after, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if after.Status != entity.UploadStatusCompleting || !after.ExpiresAt.Equal(before.ExpiresAt) {
+		t.Fatalf("session = %s, expires %v -> %v", after.Status, before.ExpiresAt, after.ExpiresAt)
+	}
+
+	// 会话已不在 active 时直接拒绝，不写入存储
+	hook.afterPart = func() { t.Error("part written to a completing session") }
+	if code, _ := env.uploadChunk(t, id, "2", "2", []byte("tail"), nil); code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", code)
+	}
+}
+
+// readCountStore 统计对象回读次数
+type readCountStore struct {
+	storage.ObjectStore
+	reads int
+}
+
+func (s *readCountStore) GetObject(ctx context.Context, bucket, key string, opts storage.GetOptions) (io.ReadCloser, error) {
+	s.reads++
+	return s.ObjectStore.GetObject(ctx, bucket, key, opts)
+}
+
+// twoChunks 返回两个分片：第一片恰为最小分片大小
+func twoChunks() ([]byte, []byte) {
+	head := bytes.Repeat([]byte("a"), storage.MinPartSize)
+	return head, []byte("tail")
+}
+
+func sessionByID(t *testing.T, env *testEnv, id string) *entity.UploadSession {
+	t.Helper()
+	var sess entity.UploadSession
+	if err := env.db.First(&sess, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &sess
+}
+
+func completedFile(t *testing.T, env *testEnv, sess *entity.UploadSession) *entity.File {
+	t.Helper()
+	var rec entity.File
+	if err := env.db.First(&rec, "bucket = ? AND object_name = ?", sess.Bucket, sess.ObjectName).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &rec
+}
+
+func TestChunkUploadDigestWithoutReread(t *testing.T) {
+	var rc *readCountStore
+	env := newTestEnv(t, func(s storage.ObjectStore) storage.ObjectStore {
+		rc = &readCountStore{ObjectStore: s}
+		return rc
+	})
+	head, tail := twoChunks()
+	whole := sha256.Sum256(append(append([]byte{}, head...), tail...))
+	md5sum := md5.Sum(append(append([]byte{}, head...), tail...))
+	id := env.initUpload(t, map[string]string{"checksum_algorithm": "md5", "checksum": hex.EncodeToString(md5sum[:])})
+	if code, resp := env.uploadChunk(t, id, "1", "2", head, nil); code != http.StatusOK {
+		t.Fatalf("chunk 1 status = %d: %v", code, resp)
+	}
+	if code, resp := env.uploadChunk(t, id, "2", "2", tail, nil); code != http.StatusOK {
+		t.Fatalf("chunk 2 status = %d: %v", code, resp)
+	}
+	sess := sessionByID(t, env, id)
+	if sess.Status != entity.UploadStatusCompleted {
+		t.Fatalf("session status = %s", sess.Status)
+	}
+	rec := completedFile(t, env, sess)
+	if rec.SHA256 == nil || *rec.SHA256 != hex.EncodeToString(whole[:]) {
+		t.Fatalf("sha256 = %v", rec.SHA256)
+	}
+	if rc.reads != 0 {
+		t.Fatalf("assembled object read back %d times", rc.reads)
+	}
+}
+
+func TestChunkUploadChecksumMismatchAborts(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.initUpload(t, map[string]string{"checksum": strings.Repeat("0", 64)})
+	code, resp := env.uploadChunk(t, id, "1", "1", []byte("hello"), nil)
+	if code != http.StatusBadRequest || resp["msg"] != "checksum mismatch" {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+	if sess := sessionByID(t, env, id); sess.Status != entity.UploadStatusAborted {
+		t.Fatalf("session status = %s", sess.Status)
+	}
+}
+
+func TestChunkUploadOutOfOrder(t *testing.T) {
+	head, tail := twoChunks()
+	whole := sha256.Sum256(append(append([]byte{}, head...), tail...))
+
+	t.Run("without checksum", func(t *testing.T) {
+		env := newTestEnv(t, nil)
+		id := env.initUpload(t, nil)
+		env.uploadChunk(t, id, "2", "2", tail, nil)
+		if code, resp := env.uploadChunk(t, id, "1", "2", head, nil); code != http.StatusOK {
+			t.Fatalf("status = %d: %v", code, resp)
+		}
+		sess := sessionByID(t, env, id)
+		if rec := completedFile(t, env, sess); rec.SHA256 != nil {
+			t.Fatalf("sha256 = %s, want unknown", *rec.SHA256)
+		}
+	})
+
+	t.Run("with checksum", func(t *testing.T) {
+		env := newTestEnv(t, nil)
+		id := env.initUpload(t, map[string]string{"checksum": hex.EncodeToString(whole[:])})
+		env.uploadChunk(t, id, "2", "2", tail, nil)
+		code, resp := env.uploadChunk(t, id, "1", "2", head, nil)
+		if code != http.StatusConflict || resp["data"].(map[string]interface{})["hashed_parts"] != float64(1) {
+			t.Fatalf("status = %d: %v", code, resp)
+		}
+		if sess := sessionByID(t, env, id); sess.Status != entity.UploadStatusActive {
+			t.Fatalf("session status = %s", sess.Status)
+		}
+		// 按序重传第 2 片后完成
+		if code, resp := env.uploadChunk(t, id, "2", "2", tail, nil); code != http.StatusOK {
+			t.Fatalf("status = %d: %v", code, resp)
+		}
+		sess := sessionByID(t, env, id)
+		if rec := completedFile(t, env, sess); rec.SHA256 == nil || *rec.SHA256 != hex.EncodeToString(whole[:]) {
+			t.Fatalf("sha256 = %v", rec.SHA256)
+		}
+	})
+}
+
+func TestChunkUploadOverwriteResetsDigest(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.initUpload(t, map[string]string{"total_chunks": "3"})
+	head, _ := twoChunks()
+	env.uploadChunk(t, id, "1", "3", head, nil)
+	env.uploadChunk(t, id, "2", "3", head, nil)
+	if sess := sessionByID(t, env, id); sess.HashedParts != 2 {
+		t.Fatalf("hashed_parts = %d, want 2", sess.HashedParts)
+	}
+	// 重传相同内容不影响累积
+	env.uploadChunk(t, id, "2", "3", head, nil)
+	if sess := sessionByID(t, env, id); sess.HashedParts != 2 {
+		t.Fatalf("hashed_parts = %d after identical retry, want 2", sess.HashedParts)
+	}
+	// 以不同内容覆盖已累积的分片后，从第 1 片起重新累积
+	other := bytes.Repeat([]byte("b"), storage.MinPartSize)
+	env.uploadChunk(t, id, "2", "3", other, nil)
+	if sess := sessionByID(t, env, id); sess.HashedParts != 0 || sess.HashState != nil {
+		t.Fatalf("hashed_parts = %d after overwrite, want 0", sess.HashedParts)
+	}
+	env.uploadChunk(t, id, "1", "3", head, nil)
+	if sess := sessionByID(t, env, id); sess.HashedParts != 1 {
+		t.Fatalf("hashed_parts = %d, want 1", sess.HashedParts)
+	}
+}
+
+func TestCompleteRejectsUndeclaredChecksumAlgorithm(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.initUpload(t, nil)
+	code, resp := env.form(t, "/api/v1/files/multipart/complete", map[string]string{
+		"upload_id": id, "total_chunks": "1", "checksum_algorithm": "crc32c", "checksum": "00000000",
+	}, "", nil)
+	if code != http.StatusBadRequest {
+		t.Fatalf("status = %d: %v", code, resp)
+	}
+}
@@ -1,9 +1,12 @@
 package api
 
 import (
+    apiAuth "github.com/binhy/go-template/api/auth"
+    apiBucket "github.com/binhy/go-template/api/bucket"
     apiFile "github.com/binhy/go-template/api/file"
     apiHealth "github.com/binhy/go-template/api/health"
     apiSwagger "github.com/binhy/go-template/api/swagger"
+    "github.com/binhy/go-template/middleware"
     "github.com/gin-gonic/gin"
 )
 
@@ -16,9 +19,13 @@ func RegisterRoutes(r *gin.Engine) {
     // 根路径模块（如健康检查）
     apiHealth.RegisterRoutes(r)
 
-    // API v1 分组，将其余业务路由交由各模块处理
+    // API v1 分组：登录保持公开，其余业务路由需携带 Bearer JWT 或 ApiKey（健康检查与 Swagger 保持公开）
     v1 := r.Group("/api/v1")
+    apiAuth.RegisterPublicRoutes(v1)
+    secured := v1.Group("", middleware.Auth())
     {
-        apiFile.RegisterRoutes(v1)
+        apiAuth.RegisterRoutes(secured)
+        apiFile.RegisterRoutes(secured)
+        apiBucket.RegisterRoutes(secured)
     }
 }
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -14,6 +15,12 @@ type Config struct {
 	MinIO    MinIOConfig    `mapstructure:"minio"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Server   ServerConfig   `mapstructure:"server"`
+	Storage  StorageConfig  `mapstructure:"storage"`
+	Upload   UploadConfig   `mapstructure:"upload"`
+	Auth     AuthConfig     `mapstructure:"auth"`
+	Quota    QuotaConfig    `mapstructure:"quota"`
+	Trash    TrashConfig    `mapstructure:"trash"`
+	Archive  ArchiveConfig  `mapstructure:"archive"`
 }
 
 type MinIOConfig struct {
@@ -23,6 +30,80 @@ type MinIOConfig struct {
 	Secure    bool   `mapstructure:"secure"`
 }
 
+// StorageConfig 对象存储驱动配置
+type StorageConfig struct {
+	// Driver 存储驱动：minio（默认，使用 [minio] 配置）或 local（本地磁盘，适合开发与 CI）
+	Driver string `mapstructure:"driver"`
+	// LocalRoot local 驱动的数据根目录，每个 Bucket 对应其下的一个子目录
+	LocalRoot string `mapstructure:"local_root"`
+	// DedupBuckets 开启内容去重的 Bucket 列表，"*" 表示全部；相同内容复用同一对象并按引用计数删除
+	DedupBuckets []string `mapstructure:"dedup_buckets"`
+	// AutoCreateBuckets 上传到不存在的 Bucket 时是否自动创建；关闭后需先通过 POST /api/v1/buckets 创建
+	AutoCreateBuckets bool `mapstructure:"auto_create_buckets"`
+}
+
+// DedupEnabled 判断指定 Bucket 是否开启内容去重
+func (s StorageConfig) DedupEnabled(bucket string) bool {
+	for _, b := range s.DedupBuckets {
+		if b == "*" || b == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadConfig 分块上传会话配置
+type UploadConfig struct {
+	// SessionTTL 会话自最后一次活动（初始化或接收分片）起的有效期，过期后由后台清理任务回收
+	SessionTTL time.Duration `mapstructure:"session_ttl"`
+	// JanitorInterval 后台清理任务的执行间隔，<= 0 表示不启动
+	JanitorInterval time.Duration `mapstructure:"janitor_interval"`
+}
+
+// TrashConfig 回收站配置：软删除的文件保留 Retention 后由后台任务永久删除（对象与记录）
+type TrashConfig struct {
+	// Retention 软删除文件的保留时长，<= 0 表示永不自动清理
+	Retention time.Duration `mapstructure:"retention"`
+	// PurgeInterval 后台清理任务的执行间隔，<= 0 表示不启动
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+// ArchiveConfig 压缩包解析限制，防止压缩炸弹耗尽存储；0 表示不限制
+type ArchiveConfig struct {
+	// MaxEntries 单个压缩包的最大条目数（含目录与被跳过的条目），超出时中止解析并回滚已入库的文件
+	MaxEntries int `mapstructure:"max_entries"`
+	// MaxTotalSize 单个压缩包解压后的最大总字节数，超出时中止解析并回滚已入库的文件
+	MaxTotalSize int64 `mapstructure:"max_total_size"`
+	// MaxEntrySize 单个条目解压后的最大字节数，超出的条目被跳过
+	MaxEntrySize int64 `mapstructure:"max_entry_size"`
+	// MaxRatio 最大压缩比（解压后 / 压缩后）：超出的 zip 条目被跳过，整个压缩包解压总量超出该倍数时中止解析；
+	// 仅 zip 记录条目的压缩后大小，tar、单文件压缩流与 7z 只按整个压缩包校验。解压总量不足 1MiB 时不校验，避免误拒高度可压缩的小文件
+	MaxRatio int64 `mapstructure:"max_ratio"`
+}
+
+// QuotaConfig 容量配额与大小限制（字节），0 表示不限制
+type QuotaConfig struct {
+	// MaxObjectSize 单个对象的最大大小，为全局上限；Bucket 的 max_object_size 只能在此之下收紧
+	MaxObjectSize int64 `mapstructure:"max_object_size"`
+	// BucketQuota 每个 Bucket 的默认容量配额，可被 Bucket 的 quota_bytes 覆盖
+	BucketQuota int64 `mapstructure:"bucket_quota"`
+	// UserQuota 每个用户的默认容量配额，可被用户的 quota_bytes 覆盖
+	UserQuota int64 `mapstructure:"user_quota"`
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	// JWTSecret HS256 签名密钥；为空时不接受 JWT（登录不可用），仅能通过 API Key 认证
+	JWTSecret string `mapstructure:"jwt_secret"`
+	// JWTExpiration 签发令牌的有效期（秒）
+	JWTExpiration int `mapstructure:"jwt_expiration"`
+	// AdminUsername/AdminPassword 启动时若 users 表为空则以此创建初始管理员；密码为空时不创建
+	AdminUsername string `mapstructure:"admin_username"`
+	AdminPassword string `mapstructure:"admin_password"`
+	// Disabled 关闭认证（仅用于本地开发）：未携带凭据的请求以匿名管理员身份执行；默认 false，未认证的请求一律返回 401
+	Disabled bool `mapstructure:"disabled"`
+}
+
 type DatabaseConfig struct {
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
@@ -56,6 +137,28 @@ func Default() *Config {
 			Port: 8080,
 			Host: "0.0.0.0",
 		},
+		Storage: StorageConfig{
+			Driver:            "minio",
+			LocalRoot:         "cache/storage",
+			AutoCreateBuckets: true,
+		},
+		Upload: UploadConfig{
+			SessionTTL:      24 * time.Hour,
+			JanitorInterval: 10 * time.Minute,
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Archive: ArchiveConfig{
+			MaxEntries:   10000,
+			MaxTotalSize: 10 << 30,
+			MaxRatio:     100,
+		},
+		Auth: AuthConfig{
+			JWTExpiration: 3600,
+			AdminUsername: "admin",
+		},
 	}
 }
 
@@ -97,6 +200,32 @@ func Load(path string) (*Config, error) {
 	_ = v.BindEnv("minio.access_key", "MINIO_ACCESS_KEY")
 	_ = v.BindEnv("minio.secret_key", "MINIO_SECRET_KEY")
 	_ = v.BindEnv("minio.secure", "MINIO_SECURE")
+	// 存储驱动环境变量
+	_ = v.BindEnv("storage.driver", "STORAGE_DRIVER")
+	_ = v.BindEnv("storage.local_root", "STORAGE_LOCAL_ROOT")
+	_ = v.BindEnv("storage.dedup_buckets", "STORAGE_DEDUP_BUCKETS")
+	_ = v.BindEnv("storage.auto_create_buckets", "STORAGE_AUTO_CREATE_BUCKETS")
+	// 分块上传会话环境变量
+	_ = v.BindEnv("upload.session_ttl", "UPLOAD_SESSION_TTL")
+	_ = v.BindEnv("upload.janitor_interval", "UPLOAD_JANITOR_INTERVAL")
+	// 认证环境变量
+	_ = v.BindEnv("auth.jwt_secret", "JWT_SECRET")
+	_ = v.BindEnv("auth.jwt_expiration", "JWT_EXPIRATION")
+	_ = v.BindEnv("auth.admin_username", "AUTH_ADMIN_USERNAME")
+	_ = v.BindEnv("auth.admin_password", "AUTH_ADMIN_PASSWORD")
+	_ = v.BindEnv("auth.disabled", "AUTH_DISABLED")
+	// 配额环境变量
+	_ = v.BindEnv("quota.max_object_size", "QUOTA_MAX_OBJECT_SIZE")
+	_ = v.BindEnv("quota.bucket_quota", "QUOTA_BUCKET_QUOTA")
+	_ = v.BindEnv("quota.user_quota", "QUOTA_USER_QUOTA")
+	// 回收站环境变量
+	_ = v.BindEnv("trash.retention", "TRASH_RETENTION")
+	_ = v.BindEnv("trash.purge_interval", "TRASH_PURGE_INTERVAL")
+	// 压缩包解析限制环境变量
+	_ = v.BindEnv("archive.max_entries", "ARCHIVE_MAX_ENTRIES")
+	_ = v.BindEnv("archive.max_total_size", "ARCHIVE_MAX_TOTAL_SIZE")
+	_ = v.BindEnv("archive.max_entry_size", "ARCHIVE_MAX_ENTRY_SIZE")
+	_ = v.BindEnv("archive.max_ratio", "ARCHIVE_MAX_RATIO")
 
 	// 以默认值为基底，文件与环境变量进行覆盖
 	cfg := Default()
@@ -130,6 +259,32 @@ func Save(cfg *Config, path string) error {
 	v.Set("server.port", cfg.Server.Port)
 	v.Set("server.host", cfg.Server.Host)
 
+	v.Set("storage.driver", cfg.Storage.Driver)
+	v.Set("storage.local_root", cfg.Storage.LocalRoot)
+	v.Set("storage.dedup_buckets", cfg.Storage.DedupBuckets)
+	v.Set("storage.auto_create_buckets", cfg.Storage.AutoCreateBuckets)
+
+	v.Set("upload.session_ttl", cfg.Upload.SessionTTL.String())
+	v.Set("upload.janitor_interval", cfg.Upload.JanitorInterval.String())
+
+	v.Set("auth.jwt_secret", cfg.Auth.JWTSecret)
+	v.Set("auth.jwt_expiration", cfg.Auth.JWTExpiration)
+	v.Set("auth.admin_username", cfg.Auth.AdminUsername)
+	v.Set("auth.admin_password", cfg.Auth.AdminPassword)
+	v.Set("auth.disabled", cfg.Auth.Disabled)
+
+	v.Set("quota.max_object_size", cfg.Quota.MaxObjectSize)
+	v.Set("quota.bucket_quota", cfg.Quota.BucketQuota)
+	v.Set("quota.user_quota", cfg.Quota.UserQuota)
+
+	v.Set("trash.retention", cfg.Trash.Retention.String())
+	v.Set("trash.purge_interval", cfg.Trash.PurgeInterval.String())
+
+	v.Set("archive.max_entries", cfg.Archive.MaxEntries)
+	v.Set("archive.max_total_size", cfg.Archive.MaxTotalSize)
+	v.Set("archive.max_entry_size", cfg.Archive.MaxEntrySize)
+	v.Set("archive.max_ratio", cfg.Archive.MaxRatio)
+
 	dest := path
 	if dest == "" {
 		dest = "config.local.toml"
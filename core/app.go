@@ -4,8 +4,8 @@ import (
     "gorm.io/gorm"
 
     "github.com/binhy/go-template/config"
+    "github.com/binhy/go-template/storage"
     "go.uber.org/zap"
-    "github.com/minio/minio-go/v7"
 )
 
 // App 聚合应用运行所需的上下文（配置、数据库等）
@@ -14,6 +14,6 @@ type App struct {
     DB     *gorm.DB
     // Logger 使用 Zap 的 SugaredLogger 提供结构化日志能力
     Logger *zap.SugaredLogger
-    // MinIO 客户端
-    Minio  *minio.Client
-}
\ No newline at end of file
+    // Storage 对象存储（MinIO / 本地磁盘），由 storage.driver 决定
+    Storage storage.ObjectStore
+}
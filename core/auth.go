@@ -0,0 +1,36 @@
+package core
+
+import (
+    "github.com/binhy/go-template/config"
+    "github.com/binhy/go-template/middleware"
+    "github.com/binhy/go-template/model/entity"
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+)
+
+// EnsureAdminUser users 表为空且配置了 auth.admin_password 时创建初始管理员，返回是否创建
+func EnsureAdminUser(db *gorm.DB, cfg *config.Config) (bool, error) {
+    if db == nil || cfg == nil || cfg.Auth.AdminPassword == "" || cfg.Auth.AdminUsername == "" {
+        return false, nil
+    }
+    var count int64
+    if err := db.Model(&entity.User{}).Count(&count).Error; err != nil {
+        return false, err
+    }
+    if count > 0 {
+        return false, nil
+    }
+    hash, err := bcrypt.GenerateFromPassword([]byte(cfg.Auth.AdminPassword), bcrypt.DefaultCost)
+    if err != nil {
+        return false, err
+    }
+    user := &entity.User{
+        Username:     cfg.Auth.AdminUsername,
+        PasswordHash: string(hash),
+        Scopes:       middleware.ScopeRead + "," + middleware.ScopeWrite + "," + middleware.ScopeAdmin,
+    }
+    if err := db.Create(user).Error; err != nil {
+        return false, err
+    }
+    return true, nil
+}
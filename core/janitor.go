@@ -0,0 +1,106 @@
+package core
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/binhy/go-template/model/entity"
+    "github.com/binhy/go-template/storage"
+)
+
+// StartUploadJanitor 启动后台清理任务：按 upload.janitor_interval 周期回收过期的分块上传会话，
+// 中止存储端未完成的分块上传并删除已接收分片记录；ctx 取消时退出
+func StartUploadJanitor(ctx context.Context, app *App) {
+    if app == nil || app.Config == nil || app.DB == nil || app.Storage == nil {
+        return
+    }
+    interval := app.Config.Upload.JanitorInterval
+    if interval <= 0 {
+        return
+    }
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            reapExpiredUploads(ctx, app)
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+            }
+        }
+    }()
+    if app.Logger != nil {
+        app.Logger.Infow("upload janitor started", "interval", interval.String(), "session_ttl", app.Config.Upload.SessionTTL.String())
+    }
+}
+
+// reapExpiredUploads 执行一轮清理；单个会话失败不影响其余会话
+func reapExpiredUploads(ctx context.Context, app *App) {
+    var sessions []entity.UploadSession
+    err := app.DB.WithContext(ctx).
+        Where("status IN ? AND expires_at < ?", []string{entity.UploadStatusActive, entity.UploadStatusCompleting}, time.Now()).
+        Find(&sessions).Error
+    if err != nil {
+        if app.Logger != nil {
+            app.Logger.Errorw("upload janitor: query expired sessions failed", "error", err)
+        }
+        return
+    }
+
+    var reclaimedSessions int
+    var reclaimedBytes int64
+    for _, sess := range sessions {
+        // 条件更新抢占会话，避免与正在进行的合并或其他实例的清理任务冲突
+        res := app.DB.WithContext(ctx).Model(&entity.UploadSession{}).
+            Where("id = ? AND status = ? AND expires_at < ?", sess.ID, sess.Status, time.Now()).
+            Update("status", entity.UploadStatusExpired)
+        if res.Error != nil || res.RowsAffected != 1 {
+            continue
+        }
+        if err := app.Storage.AbortMultipartUpload(ctx, sess.Bucket, sess.ObjectName, sess.StorageUploadID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+            if app.Logger != nil {
+                app.Logger.Warnw("upload janitor: abort multipart upload failed",
+                    "upload_id", sess.ID, "bucket", sess.Bucket, "object", sess.ObjectName, "error", err)
+            }
+        }
+        var size int64
+        app.DB.WithContext(ctx).Model(&entity.UploadPart{}).
+            Where("session_id = ?", sess.ID).
+            Select("COALESCE(SUM(size), 0)").Scan(&size)
+        if err := app.DB.WithContext(ctx).Where("session_id = ?", sess.ID).Delete(&entity.UploadPart{}).Error; err != nil {
+            if app.Logger != nil {
+                app.Logger.Warnw("upload janitor: delete parts failed", "upload_id", sess.ID, "error", err)
+            }
+        }
+        reclaimedSessions++
+        reclaimedBytes += size
+        if app.Logger != nil {
+            app.Logger.Infow("upload janitor: session expired",
+                "upload_id", sess.ID, "kind", sess.Kind, "bucket", sess.Bucket, "object", sess.ObjectName, "bytes", size)
+        }
+    }
+
+    // 结束态会话仅保留一个 TTL 周期，供客户端查询最终状态
+    var purged int64
+    if ttl := app.Config.Upload.SessionTTL; ttl > 0 {
+        res := app.DB.WithContext(ctx).
+            Where("status IN ? AND updated_at < ?",
+                []string{entity.UploadStatusCompleted, entity.UploadStatusAborted, entity.UploadStatusExpired},
+                time.Now().Add(-ttl)).
+            Delete(&entity.UploadSession{})
+        if res.Error != nil {
+            if app.Logger != nil {
+                app.Logger.Warnw("upload janitor: purge finished sessions failed", "error", res.Error)
+            }
+        } else {
+            purged = res.RowsAffected
+        }
+    }
+
+    if app.Logger != nil && (reclaimedSessions > 0 || purged > 0) {
+        app.Logger.Infow("upload janitor: reclaimed",
+            "expired_sessions", reclaimedSessions, "bytes", reclaimedBytes, "purged_records", purged)
+    }
+}
@@ -1,13 +1,73 @@
 package core
 
 import (
+    "time"
+
     "github.com/binhy/go-template/model/entity"
     "gorm.io/gorm"
 )
 
+// fileSearchIndex 全文检索 GIN 索引名，检索文档定义变化时递增版本号，迁移会据此重建 search_vector 列
+const fileSearchIndex = "idx_files_search_v2"
+
+// fileSearchDocument 文件全文检索文档：原始文件名整体分词，并按非字母数字字符切分后再分词，
+// 便于按文件名中的片段检索；其次为标签与自定义元数据（键与值）。使用 simple 配置，不做词干化
+const fileSearchDocument = `setweight(to_tsvector('simple', coalesce(original_name, '')), 'A') || ` +
+    `setweight(to_tsvector('simple', regexp_replace(coalesce(original_name, ''), '[^[:alnum:]]+', ' ', 'g')), 'A') || ` +
+    `setweight(jsonb_to_tsvector('simple', coalesce(tags, '[]'::jsonb), '["string"]'), 'B') || ` +
+    `setweight(jsonb_to_tsvector('simple', coalesce(metadata, '{}'::jsonb), '["key", "string"]'), 'C')`
+
 // RunMigrations 统一执行数据库迁移
 func RunMigrations(db *gorm.DB) error {
-    return db.AutoMigrate(
+    if err := db.AutoMigrate(
         &entity.File{},
-    )
-}
\ No newline at end of file
+        &entity.UploadSession{},
+        &entity.UploadPart{},
+        &entity.Blob{},
+        &entity.User{},
+        &entity.ApiKey{},
+        &entity.Bucket{},
+        &entity.BucketACL{},
+        &entity.QuotaLock{},
+        &entity.Folder{},
+    ); err != nil {
+        return err
+    }
+    // 引入 deleted_at 之前软删除的文件以迁移时间作为删除时间，从此刻起计算回收站保留期
+    if err := db.Model(&entity.File{}).
+        Where("is_deleted = ? AND deleted_at IS NULL", true).
+        Update("deleted_at", time.Now()).Error; err != nil {
+        return err
+    }
+    if db.Dialector.Name() == "postgres" {
+        return migrateFileIndexes(db)
+    }
+    return nil
+}
+
+// migrateFileIndexes 维护 Postgres 专用的索引：标签 / 元数据的 GIN 索引，以及 files.search_vector 生成列与其 GIN 索引
+func migrateFileIndexes(db *gorm.DB) error {
+    for _, stmt := range []string{
+        "CREATE INDEX IF NOT EXISTS idx_files_tags ON files USING GIN (tags)",
+        "CREATE INDEX IF NOT EXISTS idx_files_metadata ON files USING GIN (metadata jsonb_path_ops)",
+    } {
+        if err := db.Exec(stmt).Error; err != nil {
+            return err
+        }
+    }
+    if db.Migrator().HasIndex("files", fileSearchIndex) {
+        return nil
+    }
+    return db.Transaction(func(tx *gorm.DB) error {
+        for _, stmt := range []string{
+            "ALTER TABLE files DROP COLUMN IF EXISTS search_vector",
+            "ALTER TABLE files ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (" + fileSearchDocument + ") STORED",
+            "CREATE INDEX " + fileSearchIndex + " ON files USING GIN (search_vector)",
+        } {
+            if err := tx.Exec(stmt).Error; err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+    "context"
     "log"
     "time"
 
@@ -32,13 +33,13 @@ func Serve() *gin.Engine {
 		sugar.Infow("logger initialized")
 	}
 	if cfg != nil {
-		// 初始化 MinIO 客户端
-		if mc, err := InitMinIO(&cfg.MinIO); err != nil {
-			log.Printf("[WARN] MinIO 初始化失败: %v", err)
+		// 初始化对象存储（MinIO 或本地磁盘）
+		if store, err := InitStorage(cfg); err != nil {
+			log.Printf("[WARN] 存储初始化失败: %v", err)
 		} else {
-			app.Minio = mc
+			app.Storage = store
 			if app.Logger != nil {
-				app.Logger.Infow("minio initialized", "endpoint", cfg.MinIO.Endpoint)
+				app.Logger.Infow("storage initialized", "driver", store.Driver())
 			}
 		}
 
@@ -59,8 +60,30 @@ func Serve() *gin.Engine {
 					app.Logger.Infow("database migrations completed")
 				}
 				log.Printf("[INFO] 数据库迁移完成")
+				if created, err := EnsureAdminUser(db, cfg); err != nil {
+					log.Printf("[WARN] 创建初始管理员失败: %v", err)
+				} else if created && app.Logger != nil {
+					app.Logger.Infow("initial admin user created", "username", cfg.Auth.AdminUsername)
+				}
 			}
 		}
+
+		if cfg.Auth.Disabled {
+			log.Printf("[WARN] auth.disabled 已开启，未携带凭据的 /api/v1 请求将以匿名管理员身份执行，请勿在生产环境使用")
+			if app.Logger != nil {
+				app.Logger.Warnw("auth disabled: anonymous requests run with admin scope")
+			}
+		} else if cfg.Auth.JWTSecret == "" {
+			log.Printf("[WARN] auth.jwt_secret 未配置，登录与 JWT 认证不可用，/api/v1 接口仅接受 API Key")
+			if app.Logger != nil {
+				app.Logger.Warnw("jwt authentication unavailable: auth.jwt_secret is empty")
+			}
+		}
+
+		// 后台回收过期的分块上传会话
+		StartUploadJanitor(context.Background(), app)
+		// 后台永久删除回收站中超过保留期的文件
+		StartTrashPurger(context.Background(), app)
 	}
 
 	// 初始化 Gin
@@ -68,16 +91,17 @@ func Serve() *gin.Engine {
 	// 先注入 app，以便后续中间件能取到 Logger 等上下文
 	r.Use(func(c *gin.Context) {
 		c.Set("app", app)
+		if app.Config != nil {
+			c.Set("config", app.Config)
+		}
 		if app.Logger != nil {
 			c.Set("logger", app.Logger)
 		}
 		if app.DB != nil {
 			c.Set("db", app.DB)
 		}
-		if app.Minio != nil {
-			c.Set("minio", app.Minio)
-			c.Set("minio_endpoint", app.Config.MinIO.Endpoint)
-			c.Set("minio_secure", app.Config.MinIO.Secure)
+		if app.Storage != nil {
+			c.Set("storage", app.Storage)
 		}
 		c.Next()
 	})
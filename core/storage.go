@@ -0,0 +1,24 @@
+package core
+
+import (
+    "fmt"
+
+    "github.com/binhy/go-template/config"
+    "github.com/binhy/go-template/storage"
+)
+
+// InitStorage 根据 storage.driver 选择对象存储驱动（默认 MinIO）
+func InitStorage(cfg *config.Config) (storage.ObjectStore, error) {
+    switch cfg.Storage.Driver {
+    case "", storage.DriverMinIO:
+        mc, err := InitMinIO(&cfg.MinIO)
+        if err != nil {
+            return nil, err
+        }
+        return storage.NewMinioStore(mc), nil
+    case storage.DriverLocal:
+        return storage.NewLocalStore(cfg.Storage.LocalRoot)
+    default:
+        return nil, fmt.Errorf("unknown storage driver: %s", cfg.Storage.Driver)
+    }
+}
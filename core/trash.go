@@ -0,0 +1,124 @@
+package core
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/binhy/go-template/model/entity"
+    "github.com/binhy/go-template/service"
+    "github.com/binhy/go-template/storage"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// trashPurgeBatch 每批处理的文件数
+const trashPurgeBatch = 100
+
+// StartTrashPurger 启动后台清理任务：按 trash.purge_interval 周期永久删除回收站中超过 trash.retention 的文件；ctx 取消时退出
+func StartTrashPurger(ctx context.Context, app *App) {
+    if app == nil || app.Config == nil || app.DB == nil || app.Storage == nil {
+        return
+    }
+    retention, interval := app.Config.Trash.Retention, app.Config.Trash.PurgeInterval
+    if retention <= 0 || interval <= 0 {
+        return
+    }
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            purgeExpiredTrash(ctx, app, time.Now().Add(-retention))
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+            }
+        }
+    }()
+    if app.Logger != nil {
+        app.Logger.Infow("trash purger started", "interval", interval.String(), "retention", retention.String())
+    }
+}
+
+// purgeExpiredTrash 执行一轮清理：逐个调用 purgeFile，单个文件失败不影响其余文件，留待下一轮重试
+func purgeExpiredTrash(ctx context.Context, app *App, cutoff time.Time) {
+    var purged int
+    var purgedBytes int64
+    var lastID uint64
+    for {
+        var files []entity.File
+        err := app.DB.WithContext(ctx).
+            Where("is_deleted = ? AND deleted_at < ? AND id > ?", true, cutoff, lastID).
+            Order("id ASC").Limit(trashPurgeBatch).
+            Find(&files).Error
+        if err != nil {
+            if app.Logger != nil {
+                app.Logger.Errorw("trash purger: query expired files failed", "error", err)
+            }
+            return
+        }
+        for _, f := range files {
+            lastID = f.ID
+            ok, err := purgeFile(ctx, app, &f, cutoff)
+            if err != nil {
+                if app.Logger != nil {
+                    app.Logger.Warnw("trash purger: purge file failed, will retry",
+                        "file_id", f.ID, "bucket", f.Bucket, "object", f.ObjectName, "error", err)
+                }
+                continue
+            }
+            if !ok {
+                continue
+            }
+            purged++
+            if f.Size != nil {
+                purgedBytes += *f.Size
+            }
+        }
+        if len(files) < trashPurgeBatch || ctx.Err() != nil {
+            break
+        }
+    }
+    if app.Logger != nil && purged > 0 {
+        app.Logger.Infow("trash purger: purged", "files", purged, "bytes", purgedBytes)
+    }
+}
+
+// purgeFile 永久删除回收站中的单个文件，返回是否已删除（文件已被恢复或删除时返回 false）。
+// 先在事务中锁定记录并释放去重引用：对象仍被其他文件引用时一并删除记录；否则将记录标记为清理中（不可再恢复），
+// 删除对象成功后才删除记录，删除失败时保留记录，下一轮重试（引用已释放，重试只会再次删除对象）
+func purgeFile(ctx context.Context, app *App, f *entity.File, cutoff time.Time) (bool, error) {
+    var remove bool
+    err := app.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        var rec entity.File
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+            Where("id = ? AND is_deleted = ? AND deleted_at < ?", f.ID, true, cutoff).
+            First(&rec).Error; err != nil {
+            return err
+        }
+        var err error
+        if remove, err = service.ReleaseBlob(tx, rec.Bucket, rec.ObjectName); err != nil {
+            return err
+        }
+        if !remove {
+            return tx.Delete(&rec).Error
+        }
+        return tx.Model(&rec).Update("purging_at", time.Now()).Error
+    })
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    if remove {
+        if err := app.Storage.RemoveObject(ctx, f.Bucket, f.ObjectName); err != nil && !errors.Is(err, storage.ErrNotFound) {
+            return false, err
+        }
+        if err := app.DB.WithContext(ctx).Delete(&entity.File{}, f.ID).Error; err != nil {
+            return false, err
+        }
+    }
+    return true, nil
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/binhy/go-template/internal/dbtest"
+    "github.com/binhy/go-template/model/entity"
+    "github.com/binhy/go-template/storage"
+)
+
+// flakyRemoveStore removeErr 非空时删除对象失败
+type flakyRemoveStore struct {
+    storage.ObjectStore
+    removeErr error
+}
+
+func (s *flakyRemoveStore) RemoveObject(ctx context.Context, bucket, key string) error {
+    if s.removeErr != nil {
+        return s.removeErr
+    }
+    return s.ObjectStore.RemoveObject(ctx, bucket, key)
+}
+
+func newTrashApp(t *testing.T) (*App, *flakyRemoveStore) {
+    t.Helper()
+    local, err := storage.NewLocalStore(t.TempDir())
+    if err != nil {
+        t.Fatal(err)
+    }
+    store := &flakyRemoveStore{ObjectStore: local}
+    return &App{DB: dbtest.Open(t), Storage: store}, store
+}
+
+// trashedFile 写入对象及一条已在 deletedAt 软删除的文件记录
+func trashedFile(t *testing.T, app *App, key string, deletedAt time.Time) *entity.File {
+    t.Helper()
+    ctx := context.Background()
+    if _, err := storage.EnsureBucket(ctx, app.Storage, "trash"); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := app.Storage.PutObject(ctx, "trash", key, bytes.NewReader([]byte("x")), 1, storage.PutOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    rec := &entity.File{Bucket: "trash", ObjectName: key, URL: key, IsDeleted: true, DeletedAt: &deletedAt}
+    if err := app.DB.Create(rec).Error; err != nil {
+        t.Fatal(err)
+    }
+    return rec
+}
+
+func objectExists(t *testing.T, app *App, key string) bool {
+    t.Helper()
+    _, err := app.Storage.StatObject(context.Background(), "trash", key)
+    if errors.Is(err, storage.ErrNotFound) {
+        return false
+    }
+    if err != nil {
+        t.Fatal(err)
+    }
+    return true
+}
+
+func TestPurgeKeepsRecordUntilObjectRemoved(t *testing.T) {
+    app, store := newTrashApp(t)
+    old := time.Now().Add(-48 * time.Hour)
+    rec := trashedFile(t, app, "a.bin", old)
+    recent := trashedFile(t, app, "b.bin", time.Now())
+
+    store.removeErr = errors.New("storage unavailable")
+    purgeExpiredTrash(context.Background(), app, time.Now().Add(-24*time.Hour))
+    var got entity.File
+    if err := app.DB.First(&got, "id = ?", rec.ID).Error; err != nil {
+        t.Fatalf("record deleted before its object: %v", err)
+    }
+    if got.PurgingAt == nil {
+        t.Fatal("record not marked as purging")
+    }
+    if !objectExists(t, app, "a.bin") {
+        t.Fatal("object missing")
+    }
+
+    // 下一轮重试成功后记录与对象均被删除，未过期的文件不受影响
+    store.removeErr = nil
+    purgeExpiredTrash(context.Background(), app, time.Now().Add(-24*time.Hour))
+    var n int64
+    app.DB.Model(&entity.File{}).Where("id = ?", rec.ID).Count(&n)
+    if n != 0 || objectExists(t, app, "a.bin") {
+        t.Fatalf("records = %d, object exists = %v", n, objectExists(t, app, "a.bin"))
+    }
+    app.DB.Model(&entity.File{}).Where("id = ?", recent.ID).Count(&n)
+    if n != 1 || !objectExists(t, app, "b.bin") {
+        t.Fatal("unexpired file purged")
+    }
+}
+
+func TestPurgeSharedBlobKeepsObject(t *testing.T) {
+    app, _ := newTrashApp(t)
+    old := time.Now().Add(-48 * time.Hour)
+    rec := trashedFile(t, app, "shared.bin", old)
+    live := &entity.File{Bucket: "trash", ObjectName: "shared.bin", URL: "shared.bin"}
+    if err := app.DB.Create(live).Error; err != nil {
+        t.Fatal(err)
+    }
+    blob := &entity.Blob{Bucket: "trash", SHA256: "abc", ObjectName: "shared.bin", Size: 1, RefCount: 2}
+    if err := app.DB.Create(blob).Error; err != nil {
+        t.Fatal(err)
+    }
+
+    purgeExpiredTrash(context.Background(), app, time.Now().Add(-24*time.Hour))
+    var n int64
+    app.DB.Model(&entity.File{}).Where("id = ?", rec.ID).Count(&n)
+    if n != 0 {
+        t.Fatal("expired record not purged")
+    }
+    if err := app.DB.First(blob, blob.ID).Error; err != nil || blob.RefCount != 1 {
+        t.Fatalf("blob = %+v, err = %v", blob, err)
+    }
+    if !objectExists(t, app, "shared.bin") {
+        t.Fatal("shared object removed")
+    }
+}
@@ -15,86 +15,80 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/files": {
-            "post": {
-                "description": "上传文件到指定 Bucket，自动创建不存在的 Bucket，并返回文件元数据",
-                "consumes": [
-                    "multipart/form-data"
+        "/api/v1/auth/keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "返回当前用户的 API Key（不含明文）；管理员可通过 user_id 查询其他用户",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "上传文件",
+                "summary": "API Key 列表",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "MinIO Bucket 名称",
-                        "name": "bucket",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "要上传的文件",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "type": "integer",
+                        "description": "用户 ID（管理员）",
+                        "name": "user_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.File"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.ApiKey"
+                            }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/files/archive": {
+            },
             "post": {
-                "description": "接收 zip 压缩包，解压后将位于根目录或一级目录内的文件上传到指定 Bucket；多级嵌套（深层目录）文件将被跳过。",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "为当前用户（管理员可通过 user_id 指定用户）创建 API Key；scopes 不能超出所属用户与调用方（当前 JWT / API Key）的权限范围，缺省为两者的交集。明文 key 仅在本次响应中返回",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "上传压缩包并存储其中的文件（跳过深层目录）",
+                "summary": "创建 API Key",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "MinIO Bucket 名称",
-                        "name": "bucket",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "zip 压缩包文件",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "description": "API Key 信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.CreateApiKeyRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -112,8 +106,15 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -122,58 +123,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/files/archive/multipart/chunk": {
-            "post": {
-                "consumes": [
-                    "multipart/form-data"
+        "/api/v1/auth/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "记录吊销时间，之后使用该 key 的请求返回 401；所属用户或管理员可操作",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "上传压缩包分片",
+                "summary": "吊销 API Key",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "初始化返回的会话ID",
-                        "name": "upload_id",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "当前分片序号（从1开始）",
-                        "name": "chunk_index",
-                        "in": "formData",
-                        "required": true
-                    },
                     {
                         "type": "integer",
-                        "description": "分片总数",
-                        "name": "total_chunks",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "分片文件",
-                        "name": "chunk",
-                        "in": "formData",
+                        "description": "API Key ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Bucket（可冗余）",
-                        "name": "bucket",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "string",
-                        "description": "原始文件名（可冗余）",
-                        "name": "filename",
-                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -183,43 +157,46 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/api/v1/files/archive/multipart/init": {
+        "/api/v1/auth/login": {
             "post": {
-                "description": "返回会话ID，后续使用 /api/v1/files/archive/multipart/chunk 上传分片",
+                "description": "校验用户名密码并签发 JWT（HS256，有效期 auth.jwt_expiration 秒），后续请求携带 Authorization: Bearer \u003ctoken\u003e",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "初始化压缩包分块上传",
+                "summary": "登录",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Bucket 名称",
-                        "name": "bucket",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "原始压缩包文件名",
-                        "name": "filename",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "MIME 类型",
-                        "name": "mime_type",
-                        "in": "formData"
+                        "description": "登录信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -229,128 +206,151 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/api/v1/files/bucket/{bucket}": {
+        "/api/v1/auth/me": {
             "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回当前调用方的身份与权限范围；对应用户存在时一并返回用户信息",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
-                ],
-                "summary": "根据 Bucket 获取文件列表",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Bucket 名称",
-                        "name": "bucket",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Auth"
                 ],
+                "summary": "当前用户",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/entity.File"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/api/v1/files/buckets": {
+        "/api/v1/auth/users": {
             "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要 admin 权限",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "获取所有 Buckets 列表",
+                "summary": "用户列表",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "object",
-                                "additionalProperties": true
+                                "$ref": "#/definitions/entity.User"
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/files/multipart/chunk": {
+            },
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要 admin 权限；scopes 可选 read / write / admin，默认 read,write",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "上传分片",
+                "summary": "创建用户",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "初始化返回的会话ID",
-                        "name": "upload_id",
-                        "in": "formData",
-                        "required": true
+                        "description": "用户信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
                     },
-                    {
-                        "type": "integer",
-                        "description": "当前分片序号（从1开始）",
-                        "name": "chunk_index",
-                        "in": "formData",
-                        "required": true
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "type": "integer",
-                        "description": "分片总数",
-                        "name": "total_chunks",
-                        "in": "formData",
-                        "required": true
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "type": "file",
-                        "description": "分片文件",
-                        "name": "chunk",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Bucket（可冗余）",
-                        "name": "bucket",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "string",
-                        "description": "原始文件名（可冗余）",
-                        "name": "filename",
-                        "in": "formData"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -359,66 +359,77 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/files/multipart/init": {
-            "post": {
-                "description": "返回会话ID，前端每个分片携带该ID上传，服务端缓存分片后合并",
-                "consumes": [
-                    "multipart/form-data"
+        "/api/v1/auth/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "管理员可查询任意用户，普通用户只能查询自己",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "初始化分块上传",
+                "summary": "获取用户",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Bucket 名称",
-                        "name": "bucket",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "原始文件名",
-                        "name": "filename",
-                        "in": "formData",
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "MIME 类型",
-                        "name": "mime_type",
-                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/files/{id}": {
-            "get": {
-                "description": "根据文件记录 ID，返回存储的文件元信息",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要 admin 权限；同时删除该用户的所有 API Key，已上传的文件保留",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "获取文件元数据",
+                "summary": "删除用户",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "文件记录 ID",
+                        "description": "用户 ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -428,7 +439,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.File"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "404": {
@@ -440,41 +459,67 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "根据文件记录 ID，将其标记为已删除；已删除的文件下载接口将返回 410",
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "普通用户只能修改自己的密码；管理员可修改任意用户的密码、权限范围、容量配额与禁用状态（禁用或降级后立即对其已签发的 JWT 与 API Key 生效）",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "删除文件（软删除）",
+                "summary": "更新用户",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "文件记录 ID",
+                        "description": "用户 ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "更新内容",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.UpdateUserRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -483,17 +528,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/files/{id}/download": {
+        "/api/v1/auth/users/{id}/usage": {
             "get": {
-                "description": "根据文件记录 ID，从 MinIO 流式下载文件",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "管理员可查询任意用户，普通用户只能查询自己；used_bytes 包含软删除的文件与进行中分块上传已接收的分片，去重复用已有对象的文件同样按完整大小计入；quota_bytes 为 0 表示不限制",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Files"
+                    "Auth"
                 ],
-                "summary": "下载文件",
+                "summary": "用户用量",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "文件记录 ID",
+                        "description": "用户 ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -502,26 +558,20 @@ const docTemplate = `{
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {
-                            "type": "file"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "410": {
-                        "description": "Gone",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -530,23 +580,66 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/files/{id}/hard-delete": {
-            "delete": {
-                "description": "根据文件记录 ID，从 MinIO 删除对象，并删除数据库记录（不可恢复）",
+        "/api/v1/buckets": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "管理员返回存储中的全部 Bucket；其余调用方仅返回被授予角色的 Bucket。显式创建的 Bucket 附带创建选项",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Buckets"
                 ],
-                "summary": "物理删除文件",
+                "summary": "Bucket 列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按 S3 命名规则校验名称后创建 Bucket，可选区域、版本控制、对象锁定与默认保留策略、容量配额与最大对象大小；创建者成为该 Bucket 的 owner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Buckets"
+                ],
+                "summary": "创建 Bucket",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "文件记录 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Bucket 信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/bucket.CreateBucketRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -557,15 +650,15 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -574,55 +667,3088 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/files/{id}/presigned": {
+        "/api/v1/buckets/{bucket}": {
             "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要该 Bucket 的 reader 及以上角色或全局 admin 权限",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Buckets"
                 ],
-                "summary": "获取预签名下载链接",
+                "summary": "Bucket 详情",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "文件记录 ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     },
                     {
-                        "type": "integer",
-                        "description": "过期时间秒，默认600",
-                        "name": "expiry",
-                        "in": "query"
+                        "ApiKeyAuth": []
                     }
                 ],
-                "responses": {}
-            }
-        },
-        "/healthz": {
-            "get": {
-                "description": "返回服务运行状态",
+                "description": "需要该 Bucket 的 owner 角色或全局 admin 权限；Bucket 中仍有文件（含软删除）或进行中的分块上传时返回 409",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Health"
+                    "Buckets"
+                ],
+                "summary": "删除 Bucket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "健康检查",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
-                    }
-                }
-            }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/buckets/{bucket}/acl": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要该 Bucket 的 admin / owner 角色或全局 admin 权限",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Buckets"
+                ],
+                "summary": "查询 Bucket 授权",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.BucketACL"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Bucket admin 可授予 reader / writer / admin；授予 owner（转移所有权，原 owner 降级为 admin）或修改 owner 需由 owner 或全局管理员操作",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Buckets"
+                ],
+                "summary": "授予 Bucket 角色",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "授权信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/bucket.GrantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.BucketACL"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/buckets/{bucket}/acl/{user_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要该 Bucket 的 admin / owner 角色或全局 admin 权限；owner 不能被撤销，需先转移所有权",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Buckets"
+                ],
+                "summary": "撤销 Bucket 角色",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "用户 ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/buckets/{bucket}/quota": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要全局 admin 权限；0 表示恢复为 quota.bucket_quota / quota.max_object_size，最大对象大小不能超过全局上限",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Buckets"
+                ],
+                "summary": "设置 Bucket 配额",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "配额",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/bucket.QuotaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/buckets/{bucket}/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要该 Bucket 的 reader 及以上角色或全局 admin 权限；used_bytes 包含软删除的文件与进行中分块上传已接收的分片，去重复用已有对象的文件同样按完整大小计入；限制为 0 表示不限制",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Buckets"
+                ],
+                "summary": "Bucket 用量",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "上传文件到指定 Bucket 并返回文件元数据；Bucket 名称须符合 S3 命名规则，不存在的 Bucket 仅在 storage.auto_create_buckets 开启时自动创建（否则返回 404）；Bucket 开启去重（storage.dedup_buckets）时相同内容复用已有对象",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "上传文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MinIO Bucket 名称",
+                        "name": "bucket",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "要上传的文件",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件摘要（hex 或 base64），提供时服务端校验，不一致返回 400",
+                        "name": "checksum",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "摘要算法：sha256（默认）、md5、crc32c",
+                        "name": "checksum_algorithm",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签，逗号分隔或重复传入，最多 10 个",
+                        "name": "tags",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "自定义元数据，字符串值的 JSON 对象，如 {\\",
+                        "name": "metadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "目标文件夹路径，如 docs/2024，不存在时逐级创建；与 folder_id 二选一，均未提供时位于 Bucket 根目录",
+                        "name": "folder",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "目标文件夹 ID",
+                        "name": "folder_id",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.File"
+                        }
+                    },
+                    "400": {
+                        "description": "Bucket 名称不合法、缺少文件或摘要不一致",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Bucket 不存在且未开启 storage.auto_create_buckets",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "超出最大对象大小（code 41301）或容量配额（code 41302）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/archive": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "接收压缩包（按文件头魔数识别 zip、7z、tar 及 gzip / bzip2 / xz / zstd 压缩的 tar；非 tar 的压缩流作为单个文件存储），解压后将位于根目录或一级目录内的文件上传到指定 Bucket，多级嵌套（深层目录）文件将被跳过；\npreserve_paths=true 时上传任意深度的文件，目录在目标文件夹下逐级创建为文件夹，并作为对象 key 前缀。\n响应 data.skipped 为未入库的条目列表，每项包含 name、reason（nested_directory、invalid_path、too_large、read_error、storage_error、database_error、unsupported_entry、encrypted、path_traversal、symlink、compression_ratio）与 detail；\n条目数或解压总量超出 [archive] 配置的限制时中止解析，返回 400（code 40004）；中止时回滚已入库的文件（已创建的文件夹保留），响应 data.uploaded 为空。",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "上传压缩包并存储其中的文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MinIO Bucket 名称",
+                        "name": "bucket",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "压缩包文件：zip、7z、tar、tar.gz / tgz、tar.bz2、tar.xz、tar.zst，或 .gz / .bz2 / .xz / .zst 单文件",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "压缩包摘要（hex 或 base64），提供时服务端校验，不一致返回 400",
+                        "name": "checksum",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "摘要算法：sha256（默认）、md5、crc32c",
+                        "name": "checksum_algorithm",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签，逗号分隔或重复传入，应用到解压出的每个文件",
+                        "name": "tags",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "自定义元数据（JSON 对象），应用到解压出的每个文件",
+                        "name": "metadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "解压出的文件所在文件夹路径，不存在时逐级创建；与 folder_id 二选一",
+                        "name": "folder",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "解压出的文件所在文件夹 ID",
+                        "name": "folder_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "保留压缩包内的目录结构，默认 false",
+                        "name": "preserve_paths",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "无法识别（code 40001）、已加密（code 40002）、损坏（code 40003）或超出解析限制（code 40004）的压缩包",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "压缩包或解压出的文件超出最大对象大小（code 41301），或超出容量配额（code 41302）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "存储、数据库等服务端错误（code 500）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/archive/multipart/chunk": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "除最后一个分片外每片至少 5MiB；当所有分片到齐时自动完成解析，也可显式调用 /api/v1/files/archive/multipart/complete",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "上传压缩包分片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "初始化返回的会话ID",
+                        "name": "upload_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "当前分片序号（从1开始）",
+                        "name": "chunk_index",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片总数",
+                        "name": "total_chunks",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "分片文件",
+                        "name": "chunk",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "该分片的摘要（hex 或 base64），写入前校验，不一致返回 400，可重传该分片",
+                        "name": "checksum",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "摘要算法：sha256（默认）、md5、crc32c",
+                        "name": "checksum_algorithm",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "会话已开始合并或已结束，本次分片未被记录",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "超出最大对象大小（code 41301）或容量配额（code 41302）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/archive/multipart/complete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "所有分片（可并发、乱序）上传完成后调用；缺少分片时返回 400 及缺失的分片序号",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "完成压缩包分块上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "初始化返回的会话ID",
+                        "name": "upload_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片总数",
+                        "name": "total_chunks",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "整个文件摘要（hex 或 base64），覆盖初始化时声明的摘要；拼接后校验，不一致返回 400 并结束会话",
+                        "name": "checksum",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "摘要算法：sha256（默认）、md5、crc32c",
+                        "name": "checksum_algorithm",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "缺少分片、摘要不一致，或无法识别（code 40001）、已加密（code 40002）、损坏（code 40003）或超出解析限制（code 40004）的压缩包",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "超出最大对象大小（code 41301）或容量配额（code 41302）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "存储、数据库等服务端错误（code 500）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/archive/multipart/init": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回会话ID，后续使用 /api/v1/files/archive/multipart/chunk 上传分片；分片写入存储后端的暂存对象",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "初始化压缩包分块上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "原始压缩包文件名",
+                        "name": "filename",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "MIME 类型",
+                        "name": "mime_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "压缩包总大小（字节）",
+                        "name": "total_size",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片总数；提供后分片与完成请求的 total_chunks 须与之一致",
+                        "name": "total_chunks",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "整个文件摘要（hex 或 base64），完成时校验；也可在完成请求中提供",
+                        "name": "checksum",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "摘要算法：sha256（默认）、md5、crc32c",
+                        "name": "checksum_algorithm",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签，应用到解压出的每个文件",
+                        "name": "tags",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "自定义元数据（JSON 对象），应用到解压出的每个文件",
+                        "name": "metadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "解压出的文件所在文件夹路径，不存在时逐级创建；与 folder_id 二选一",
+                        "name": "folder",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "解压出的文件所在文件夹 ID",
+                        "name": "folder_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "解压时保留压缩包内的目录结构，默认 false",
+                        "name": "preserve_paths",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "超出最大对象大小（code 41301）或容量配额（code 41302）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/batch/delete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "批量将文件移入回收站，逐个返回处理结果；所有修改在同一事务中提交，atomic 为 true 时任一文件失败则全部回滚。\n权限要求与单个删除相同，已删除的文件视为成功",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "批量删除文件",
+                "parameters": [
+                    {
+                        "description": "文件 ID 列表（最多 1000 个）",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-file_BatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/batch/hard-delete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "批量删除文件记录（不可恢复），逐个返回处理结果；记录在同一事务中删除，提交后通过存储后端的批量删除接口（S3 DeleteObjects）移除对象，\n去重对象仅在最后一个引用释放时删除。atomic 为 true 时任一文件失败则全部回滚；权限要求与单个物理删除相同",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "批量物理删除文件",
+                "parameters": [
+                    {
+                        "description": "文件 ID 列表（最多 1000 个）",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-file_BatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/batch/move": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "将文件移动到目标 Bucket 的 folder_id 文件夹（为空表示根目录）。同一 Bucket 内仅修改所在文件夹；\n跨 Bucket 时先在事务外复制对象再更新记录，提交后删除原 Bucket 中已无引用的对象，未移动成功的文件释放其副本；目标 Bucket 开启去重时相同内容复用已有对象。\n需要源文件的删除权限与目标 Bucket 的 writer 及以上角色，移动计入目标 Bucket 的配额；已删除的文件不可移动。\n所有记录修改在同一事务中提交，atomic 为 true 时任一文件失败则全部回滚",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "批量移动文件",
+                "parameters": [
+                    {
+                        "description": "文件 ID 列表（最多 1000 个）、目标 Bucket 与文件夹",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.BatchMoveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-file_BatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/batch/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "批量恢复软删除的文件，逐个返回处理结果；所有修改在同一事务中提交，atomic 为 true 时任一文件失败则全部回滚。\n权限要求与单个恢复相同，未删除的文件视为成功，已被永久清理的文件返回 file not found",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "批量恢复文件",
+                "parameters": [
+                    {
+                        "description": "文件 ID 列表（最多 1000 个）",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-file_BatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/batch/tag": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "对每个文件先移除 remove 中的标签再追加 add 中的标签（合并后最多 10 个），记录提交后同步到对象标签（去重 Bucket 中的共享对象除外），同步失败的文件恢复原标签；\n需要上传者或 Bucket writer 及以上角色，已删除的文件不可修改。所有记录修改在同一事务中提交，atomic 为 true 时任一文件失败则全部回滚",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "批量修改文件标签",
+                "parameters": [
+                    {
+                        "description": "文件 ID 列表（最多 1000 个）与要增删的标签",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.BatchTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-file_BatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/bucket/{bucket}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "支持偏移分页（offset）或游标分页（cursor，取上一页的 next_cursor），按 MIME 前缀、文件名子串、大小范围、创建时间范围与上传者筛选，total 为筛选后的总数；\n指定 folder 或 folder_id 时按目录浏览：仅返回直接位于该文件夹中的文件，并在 folder / folders 中返回当前文件夹与其直接子文件夹",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "根据 Bucket 获取文件列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 50，最大 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "偏移量，与 cursor 二选一",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，取上一页返回的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：id、name、size、created_at，默认 id",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序方向：asc、desc，默认 desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "按文件夹浏览：文件夹路径，如 docs/2024，\\",
+                        "name": "folder",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "按文件夹浏览：文件夹 ID",
+                        "name": "folder_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "MIME 类型前缀，如 image/",
+                        "name": "mime_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件名包含的子串（不区分大小写）",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最小字节数（含）",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最大字节数（含）",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间下限（含），RFC3339 或 YYYY-MM-DD",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间上限（不含），RFC3339 或 YYYY-MM-DD",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "上传者用户 ID",
+                        "name": "uploader_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "须同时包含的标签（Postgres）",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "须匹配的元数据，格式 key:value（Postgres）",
+                        "name": "meta",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-file_FolderListing"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/bucket/{bucket}/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "列出已软删除的文件，分页、筛选与排序参数同文件列表，另支持按 deleted_at 排序（默认 deleted_at desc）；超过 trash.retention 的文件会被后台任务永久删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "获取 Bucket 回收站文件列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 50，最大 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "偏移量，与 cursor 二选一",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，取上一页返回的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：id、name、size、created_at、deleted_at，默认 deleted_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序方向：asc、desc，默认 desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "按文件夹浏览：文件夹路径，如 docs/2024，\\",
+                        "name": "folder",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "按文件夹浏览：文件夹 ID",
+                        "name": "folder_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "MIME 类型前缀，如 image/",
+                        "name": "mime_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件名包含的子串（不区分大小写）",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最小字节数（含）",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最大字节数（含）",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间下限（含），RFC3339 或 YYYY-MM-DD",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间上限（不含），RFC3339 或 YYYY-MM-DD",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "上传者用户 ID",
+                        "name": "uploader_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "须同时包含的标签（Postgres）",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "须匹配的元数据，格式 key:value（Postgres）",
+                        "name": "meta",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-file_FolderListing"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/buckets": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "管理员返回存储中的全部 Bucket；其余调用方仅返回被授予角色的 Bucket，并附带自己的角色",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "获取 Buckets 列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/multipart/chunk": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "分片作为存储后端分块上传的一个 part 直接写入（除最后一个分片外每片至少 5MiB）；当所有分片到齐时自动完成，也可显式调用 /api/v1/files/multipart/complete",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "上传分片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "初始化返回的会话ID",
+                        "name": "upload_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "当前分片序号（从1开始）",
+                        "name": "chunk_index",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片总数",
+                        "name": "total_chunks",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "分片文件",
+                        "name": "chunk",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "该分片的摘要（hex 或 base64），写入前校验，不一致返回 400，可重传该分片",
+                        "name": "checksum",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "摘要算法：sha256（默认）、md5、crc32c",
+                        "name": "checksum_algorithm",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "会话已开始合并或已结束，本次分片未被记录",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "超出最大对象大小（code 41301）或容量配额（code 41302）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/multipart/complete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "所有分片（可并发、乱序）上传完成后调用；缺少分片时返回 400 及缺失的分片序号。整个文件的 SHA-256 由按序到达的分片累积得出，不回读拼接后的对象；有分片乱序到达时不记录 sha256、不参与去重，若要求校验整体摘要则返回 409 及 hashed_parts，按序重传其后的分片后再完成",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "完成分块上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "初始化返回的会话ID",
+                        "name": "upload_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片总数",
+                        "name": "total_chunks",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "整个文件摘要（hex 或 base64），覆盖初始化时声明的摘要；拼接前校验，不一致返回 400 并结束会话",
+                        "name": "checksum",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "摘要算法：sha256（默认）、md5、crc32c",
+                        "name": "checksum_algorithm",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.File"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/multipart/init": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回会话ID与过期时间，前端每个分片携带该ID上传；会话持久化在数据库中，服务重启或多实例部署下均可续传；分片直接写入存储后端的原生分块上传，不落 API 服务器磁盘",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "初始化分块上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "原始文件名",
+                        "name": "filename",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "MIME 类型",
+                        "name": "mime_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "文件总大小（字节）",
+                        "name": "total_size",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片总数；提供后分片与完成请求的 total_chunks 须与之一致",
+                        "name": "total_chunks",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "整个文件摘要（hex 或 base64），由按序到达的分片累积得出并在完成时校验；也可在完成请求中提供（md5 / crc32c 须在初始化时声明）",
+                        "name": "checksum",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "摘要算法：sha256（默认）、md5、crc32c",
+                        "name": "checksum_algorithm",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签，逗号分隔或重复传入，最多 10 个",
+                        "name": "tags",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "自定义元数据，字符串值的 JSON 对象",
+                        "name": "metadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "目标文件夹路径，不存在时逐级创建；与 folder_id 二选一",
+                        "name": "folder",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "目标文件夹 ID",
+                        "name": "folder_id",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "413": {
+                        "description": "超出最大对象大小（code 41301）或容量配额（code 41302）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/multipart/{upload_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回会话状态与已接收的分片序号、大小，客户端据此只补传缺失的分片；普通文件与压缩包分块会话均适用；hashed_parts 为已按序累积到整体摘要中的分片数",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "查询分块上传进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "初始化返回的会话ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "中止存储后端的分块上传（释放已上传的分片）并结束会话；普通文件与压缩包分块会话均适用",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "取消分块上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "初始化返回的会话ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "基于 Postgres 全文检索（search_vector + GIN 索引）按原始文件名、标签与自定义元数据检索，文件名匹配权重最高，结果按相关度排序并高亮文件名中的匹配片段；\n非管理员仅能检索自己上传的文件及拥有角色的 Bucket 中的文件。支持与列表接口相同的筛选条件",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "检索文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "关键词，多个关键词以空格分隔，需同时匹配（前缀匹配）",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "限定 Bucket",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 50，最大 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "偏移量",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "MIME 类型前缀，如 image/",
+                        "name": "mime_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件名包含的子串（不区分大小写）",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最小字节数（含）",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最大字节数（含）",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间下限（含），RFC3339 或 YYYY-MM-DD",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间上限（不含），RFC3339 或 YYYY-MM-DD",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "上传者用户 ID",
+                        "name": "uploader_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "须同时包含的标签",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "须匹配的元数据，格式 key:value",
+                        "name": "meta",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-response_Page-file_SearchHit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "根据文件记录 ID，返回存储的文件元信息",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "获取文件元数据",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件记录 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.File"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "根据文件记录 ID，将其标记为已删除并记录删除时间；已删除的文件下载接口将返回 410，\n可通过 POST /api/v1/files/{id}/restore 恢复，超过 trash.retention 后由后台任务永久删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "删除文件（软删除）",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件记录 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "整体替换文件的 tags 和/或 metadata，并同步到对象标签与用户元数据（去重 Bucket 中的共享对象除外；开启版本控制的 Bucket 中元数据仅保存在数据库，避免重写对象留下历史版本）；同步失败时恢复原值并返回 500。需要上传者或 Bucket writer 及以上角色",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "修改文件标签与元数据",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件记录 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "标签与元数据",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.UpdateFileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.File"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/{id}/download": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "根据文件记录 ID，从 MinIO 流式下载文件",
+                "tags": [
+                    "Files"
+                ],
+                "summary": "下载文件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件记录 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT，供无法携带请求头的浏览器直链使用（仅本接口接受）；分享链接建议使用预签名 URL",
+                        "name": "access_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/{id}/hard-delete": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "根据文件记录 ID，从 MinIO 删除对象，并删除数据库记录（不可恢复）；开启去重的 Bucket 中对象被多条记录引用时仅减少引用计数",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "物理删除文件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件记录 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/{id}/presigned": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "获取预签名下载链接",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件记录 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "过期时间秒，默认600",
+                        "name": "expiry",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/files/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "将软删除的文件恢复为正常状态；权限要求与删除相同（上传者或 Bucket admin）。文件未删除时直接返回成功，已被永久清理或正在清理的文件返回 404",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "恢复文件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件记录 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/folders": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "列出 Bucket 根目录或指定文件夹（parent_id 或 path 二选一）的直接子文件夹，按名称排序；需要 Bucket reader 及以上角色",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Folders"
+                ],
+                "summary": "获取子文件夹列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bucket 名称",
+                        "name": "bucket",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "父文件夹 ID",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "父文件夹路径，如 docs/2024",
+                        "name": "path",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-array_entity_Folder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "在 Bucket 中创建虚拟目录（仅记录在数据库中）：指定 path 时自动创建缺失的上级文件夹，或指定 parent_id（为空表示根目录）与 name；\n需要 Bucket writer 及以上角色，同名文件夹已存在时返回 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Folders"
+                ],
+                "summary": "创建文件夹",
+                "parameters": [
+                    {
+                        "description": "文件夹",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.CreateFolderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-entity_Folder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/folders/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除不含子文件夹与文件（包括回收站中的文件）的文件夹，否则返回 409；需要文件夹创建者或 Bucket writer 及以上角色",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Folders"
+                ],
+                "summary": "删除文件夹",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件夹 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/folders/{id}/move": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "将文件夹连同其子文件夹与文件移动到同一 Bucket 中的另一个文件夹下（parent_id 为空表示根目录）；\n需要文件夹创建者或 Bucket writer 及以上角色，不能移动到自身或其子文件夹中，目标位置已有同名文件夹时返回 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Folders"
+                ],
+                "summary": "移动文件夹",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件夹 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "目标父文件夹",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.MoveFolderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-entity_Folder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/folders/{id}/rename": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "修改文件夹名称并同步更新子文件夹路径，其中的文件不受影响；需要文件夹创建者或 Bucket writer 及以上角色，同级已有同名文件夹时返回 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Folders"
+                ],
+                "summary": "重命名文件夹",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件夹 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "新名称",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/file.RenameFolderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response-entity_Folder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "返回服务运行状态",
+                "tags": [
+                    "Health"
+                ],
+                "summary": "健康检查",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
+        "auth.CreateApiKeyRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "expires_in": {
+                    "description": "有效期（秒），0 表示永不过期",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "description": "仅管理员可为其他用户创建",
+                    "type": "integer"
+                }
+            }
+        },
+        "auth.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "disabled": {
+                    "type": "boolean"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "quota_bytes": {
+                    "description": "0 表示恢复为 quota.user_quota",
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "bucket.CreateBucketRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "max_object_size": {
+                    "description": "0 表示使用 quota.max_object_size",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "object_lock": {
+                    "type": "boolean"
+                },
+                "quota_bytes": {
+                    "description": "0 表示使用 quota.bucket_quota",
+                    "type": "integer"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "retention_days": {
+                    "type": "integer"
+                },
+                "retention_mode": {
+                    "description": "GOVERNANCE / COMPLIANCE，需开启 object_lock",
+                    "type": "string"
+                },
+                "versioning": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "bucket.GrantRequest": {
+            "type": "object",
+            "required": [
+                "role",
+                "user_id"
+            ],
+            "properties": {
+                "role": {
+                    "description": "reader / writer / admin / owner",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "bucket.QuotaRequest": {
+            "type": "object",
+            "properties": {
+                "max_object_size": {
+                    "type": "integer"
+                },
+                "quota_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ApiKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "明文前缀，便于识别",
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "逗号分隔，不超过所属用户的权限范围",
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.BucketACL": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "grantedBy": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "integer"
+                }
+            }
+        },
         "entity.File": {
             "type": "object",
             "properties": {
@@ -632,12 +3758,320 @@ const docTemplate = `{
                 "createdAt": {
                     "type": "string"
                 },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "folderID": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isDeleted": {
+                    "type": "boolean"
+                },
+                "metadata": {
+                    "$ref": "#/definitions/entity.Metadata"
+                },
+                "mimeType": {
+                    "type": "string"
+                },
+                "objectName": {
+                    "type": "string"
+                },
+                "originalName": {
+                    "type": "string"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "uploaderID": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Folder": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "ownerID": {
+                    "type": "integer"
+                },
+                "parentID": {
+                    "type": "integer"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entity.Metadata": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "entity.User": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "disabledAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "quotaBytes": {
+                    "description": "为空表示使用 quota.user_quota",
+                    "type": "integer"
+                },
+                "scopes": {
+                    "description": "逗号分隔：read / write / admin",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "file.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "file": {
+                    "$ref": "#/definitions/entity.File"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ok": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "file.BatchMoveRequest": {
+            "type": "object",
+            "required": [
+                "bucket",
+                "ids"
+            ],
+            "properties": {
+                "atomic": {
+                    "type": "boolean"
+                },
+                "bucket": {
+                    "description": "目标 Bucket，可与文件所在 Bucket 相同",
+                    "type": "string"
+                },
+                "folder_id": {
+                    "description": "目标 Bucket 中的文件夹，为空表示根目录",
+                    "type": "integer"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "file.BatchRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "atomic": {
+                    "type": "boolean"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "file.BatchResult": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/file.BatchItemResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "file.BatchTagRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "add": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "atomic": {
+                    "type": "boolean"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "remove": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "file.CreateFolderRequest": {
+            "type": "object",
+            "required": [
+                "bucket"
+            ],
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "file.FolderListing": {
+            "type": "object",
+            "properties": {
+                "folder": {
+                    "$ref": "#/definitions/entity.Folder"
+                },
+                "folders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Folder"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.File"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "file.MoveFolderRequest": {
+            "type": "object",
+            "properties": {
+                "parent_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "file.RenameFolderRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "file.SearchHit": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "folderID": {
+                    "type": "integer"
+                },
+                "highlight": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "isDeleted": {
                     "type": "boolean"
                 },
+                "metadata": {
+                    "$ref": "#/definitions/entity.Metadata"
+                },
                 "mimeType": {
                     "type": "string"
                 },
@@ -647,9 +4081,21 @@ const docTemplate = `{
                 "originalName": {
                     "type": "string"
                 },
+                "rank": {
+                    "type": "number"
+                },
+                "sha256": {
+                    "type": "string"
+                },
                 "size": {
                     "type": "integer"
                 },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "uploaderID": {
                     "type": "integer"
                 },
@@ -657,6 +4103,136 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "file.UpdateFileRequest": {
+            "type": "object",
+            "properties": {
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "response.Page-file_SearchHit": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/file.SearchHit"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "response.Response-array_entity_Folder": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entity.Folder"
+                    }
+                },
+                "msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.Response-entity_Folder": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/entity.Folder"
+                },
+                "msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.Response-file_BatchResult": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/file.BatchResult"
+                },
+                "msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.Response-file_FolderListing": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/file.FolderListing"
+                },
+                "msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.Response-response_Page-file_SearchHit": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/response.Page-file_SearchHit"
+                },
+                "msg": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "格式：ApiKey \u003ckey\u003e",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "格式：Bearer \u003cJWT\u003e",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
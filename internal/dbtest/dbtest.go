@@ -0,0 +1,51 @@
+// Package dbtest 为需要数据库的单元测试提供基于 SQLite 的临时库（纯 Go 驱动，无需启动 Postgres）
+package dbtest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// tables 使用 bigint 自增主键的表：SQLite 仅支持 INTEGER PRIMARY KEY AUTOINCREMENT，需在 AutoMigrate 之前建表
+var tables = []string{"files", "upload_parts", "blobs", "users", "api_keys", "buckets", "bucket_acls", "folders"}
+
+// Open 在测试的临时目录中创建 SQLite 数据库并迁移全部实体；事务以 IMMEDIATE 方式开启并设置忙等待，
+// 使并发测试中的写事务排队执行而不是立即返回 SQLITE_BUSY
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_txlock=immediate&_pragma=busy_timeout(10000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range tables {
+		if err := db.Exec("CREATE TABLE " + table + " (id integer PRIMARY KEY AUTOINCREMENT)").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.AutoMigrate(
+		&entity.File{},
+		&entity.UploadSession{},
+		&entity.UploadPart{},
+		&entity.Blob{},
+		&entity.User{},
+		&entity.ApiKey{},
+		&entity.Bucket{},
+		&entity.BucketACL{},
+		&entity.Folder{},
+		&entity.QuotaLock{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
@@ -6,6 +6,14 @@ package main
 // @host localhost:8080
 // @BasePath /
 // @schemes http
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description 格式：Bearer <JWT>
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description 格式：ApiKey <key>
 
 import (
     "fmt"
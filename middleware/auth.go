@@ -0,0 +1,321 @@
+package middleware
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/binhy/go-template/config"
+    "github.com/binhy/go-template/model/entity"
+    "github.com/gin-gonic/gin"
+    "github.com/golang-jwt/jwt/v5"
+    "gorm.io/gorm"
+)
+
+// identityKey Identity 在 gin.Context 中的键
+const identityKey = "identity"
+
+// 权限范围：read 允许 GET/HEAD，write 允许其余写操作，admin 拥有全部权限并可访问所有用户的资源。
+// 不携带任何权限范围的令牌（如外部签发的 JWT）视为普通用户，不做方法限制
+const (
+    ScopeRead  = "read"
+    ScopeWrite = "write"
+    ScopeAdmin = "admin"
+)
+
+// queryTokenRoute 唯一接受 access_token 查询参数的路由（浏览器直接打开的下载链接），其余接口须通过请求头认证，
+// 避免长期有效的令牌出现在访问日志、代理与浏览器历史中；分享链接应优先使用预签名 URL 接口
+const queryTokenRoute = "/api/v1/files/:id/download"
+
+// apiKeyTouchInterval API Key 最近使用时间的最小更新间隔，避免每个请求都写库
+const apiKeyTouchInterval = time.Minute
+
+// Identity 已认证的调用方身份
+type Identity struct {
+    // UserID 用户 ID，取自 JWT 的 sub（数字）
+    UserID   uint64
+    Subject  string
+    Username string
+    Scopes   []string
+}
+
+// HasScope 判断身份是否拥有指定权限范围
+func (id *Identity) HasScope(scope string) bool {
+    for _, s := range id.Scopes {
+        if s == scope {
+            return true
+        }
+    }
+    return false
+}
+
+// IsAdmin 判断身份是否为管理员
+func (id *Identity) IsAdmin() bool { return id.HasScope(ScopeAdmin) }
+
+// Grants 判断身份能否将 scope 授予新的凭据（如创建 API Key）：admin 可授予全部权限范围，write 可授予 read，其余须自身拥有该权限范围
+func (id *Identity) Grants(scope string) bool {
+    if id.IsAdmin() || id.HasScope(scope) {
+        return true
+    }
+    return scope == ScopeRead && id.HasScope(ScopeWrite)
+}
+
+// allowsMethod 按权限范围判断是否允许该 HTTP 方法
+func (id *Identity) allowsMethod(method string) bool {
+    if len(id.Scopes) == 0 || id.IsAdmin() {
+        return true
+    }
+    switch method {
+    case http.MethodGet, http.MethodHead, http.MethodOptions:
+        return id.HasScope(ScopeRead) || id.HasScope(ScopeWrite)
+    }
+    return id.HasScope(ScopeWrite)
+}
+
+// ParseScopes 解析逗号分隔的权限范围
+func ParseScopes(s string) []string {
+    scopes := make([]string, 0)
+    for _, p := range strings.Split(s, ",") {
+        if p = strings.TrimSpace(p); p != "" {
+            scopes = append(scopes, p)
+        }
+    }
+    return scopes
+}
+
+// ValidScope 判断是否为受支持的权限范围
+func ValidScope(scope string) bool {
+    return scope == ScopeRead || scope == ScopeWrite || scope == ScopeAdmin
+}
+
+// HashAPIKey 计算 API Key 的存储摘要（SHA-256 hex）
+func HashAPIKey(key string) string {
+    sum := sha256.Sum256([]byte(key))
+    return hex.EncodeToString(sum[:])
+}
+
+// Claims JWT 载荷：标准声明之外携带用户名与权限范围
+type Claims struct {
+    Username string   `json:"username,omitempty"`
+    Scopes   []string `json:"scopes,omitempty"`
+    jwt.RegisteredClaims
+}
+
+// Auth 认证中间件：支持 Authorization: Bearer <JWT>（HS256，auth.jwt_secret 签名）与
+// Authorization: ApiKey <key>（api_keys 表），并将调用方身份写入 gin.Context（通过 CurrentIdentity 获取）。
+// 浏览器直接打开的下载链接无法携带请求头，因此 GET /api/v1/files/{id}/download 也接受 access_token 查询参数（JWT）。
+// 未携带有效凭据的请求一律返回 401；auth.jwt_secret 为空时不接受 JWT。仅当显式设置 auth.disabled（本地开发）时，
+// 未携带凭据的请求以匿名管理员身份放行，携带的凭据仍会被校验
+func Auth() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        cfg := authConfig(c)
+        scheme, credential := authorization(c)
+        var id *Identity
+        var err error
+        switch {
+        case scheme == "" && cfg.Disabled:
+            id = anonymousIdentity()
+        case strings.EqualFold(scheme, "ApiKey"):
+            id, err = authenticateAPIKey(c, credential)
+        case strings.EqualFold(scheme, "Bearer"):
+            if cfg.JWTSecret == "" {
+                abortUnauthorized(c, "jwt authentication is not configured")
+                return
+            }
+            if id, err = ParseToken(cfg.JWTSecret, credential); err == nil {
+                err = refreshTokenIdentity(c, id)
+            }
+        case scheme == "":
+            abortUnauthorized(c, "missing bearer token or api key")
+            return
+        default:
+            abortUnauthorized(c, "unsupported authorization scheme")
+            return
+        }
+        if err != nil {
+            abortUnauthorized(c, "invalid credentials: "+err.Error())
+            return
+        }
+        if !id.allowsMethod(c.Request.Method) {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": 403, "msg": "insufficient scope"})
+            return
+        }
+        c.Set(identityKey, id)
+        c.Next()
+    }
+}
+
+// anonymousIdentity auth.disabled 时未携带凭据的调用方：不对应任何用户，拥有 admin 权限范围
+func anonymousIdentity() *Identity {
+    return &Identity{Subject: "anonymous", Username: "anonymous", Scopes: []string{ScopeAdmin}}
+}
+
+// CurrentIdentity 获取当前请求的调用方身份；未经过 Auth 中间件时返回 false，调用方应将其视为无权限
+func CurrentIdentity(c *gin.Context) (*Identity, bool) {
+    if v, ok := c.Get(identityKey); ok {
+        if id, ok := v.(*Identity); ok {
+            return id, true
+        }
+    }
+    return nil, false
+}
+
+// IssueToken 使用 HS256 签发令牌，ttl <= 0 时不设置过期时间
+func IssueToken(secret string, ttl time.Duration, id Identity) (string, error) {
+    if secret == "" {
+        return "", errors.New("jwt secret is empty")
+    }
+    now := time.Now()
+    subject := id.Subject
+    if subject == "" && id.UserID != 0 {
+        subject = strconv.FormatUint(id.UserID, 10)
+    }
+    claims := Claims{
+        Username: id.Username,
+        Scopes:   id.Scopes,
+        RegisteredClaims: jwt.RegisteredClaims{
+            Subject:  subject,
+            IssuedAt: jwt.NewNumericDate(now),
+        },
+    }
+    if ttl > 0 {
+        claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+    }
+    return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseToken 校验令牌签名与有效期并解析出调用方身份
+func ParseToken(secret, token string) (*Identity, error) {
+    var claims Claims
+    _, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+        return []byte(secret), nil
+    }, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+    if err != nil {
+        return nil, err
+    }
+    if claims.Subject == "" {
+        return nil, errors.New("token has no subject")
+    }
+    id := &Identity{Subject: claims.Subject, Username: claims.Username, Scopes: claims.Scopes}
+    if uid, err := strconv.ParseUint(claims.Subject, 10, 64); err == nil {
+        id.UserID = uid
+    }
+    return id, nil
+}
+
+// authConfig 读取 [auth] 配置；缺少配置时返回零值（不接受 JWT、不关闭认证）
+func authConfig(c *gin.Context) config.AuthConfig {
+    if v, ok := c.Get("config"); ok {
+        if cfg, ok := v.(*config.Config); ok && cfg != nil {
+            return cfg.Auth
+        }
+    }
+    return config.AuthConfig{}
+}
+
+// authorization 解析 Authorization 请求头；下载路由缺省时回退到 access_token 查询参数（视为 Bearer）
+func authorization(c *gin.Context) (scheme, credential string) {
+    if h := strings.TrimSpace(c.GetHeader("Authorization")); h != "" {
+        scheme, credential, _ = strings.Cut(h, " ")
+        return scheme, strings.TrimSpace(credential)
+    }
+    if c.Request.Method != http.MethodGet || c.FullPath() != queryTokenRoute {
+        return "", ""
+    }
+    if token := c.Query("access_token"); token != "" {
+        return "Bearer", token
+    }
+    return "", ""
+}
+
+// refreshTokenIdentity 按数据库中的用户状态校验 JWT 身份：用户须存在且未被禁用，权限范围取令牌与用户当前权限范围的交集
+// （令牌未携带权限范围时使用用户当前的权限范围），使禁用与降级在令牌过期前即生效。sub 非数字的外部令牌不对应本地用户，不做校验
+func refreshTokenIdentity(c *gin.Context, id *Identity) error {
+    if id.UserID == 0 {
+        return nil
+    }
+    dbI, ok := c.Get("db")
+    if !ok {
+        return errors.New("user lookup unavailable")
+    }
+    var user entity.User
+    if err := dbI.(*gorm.DB).First(&user, "id = ?", id.UserID).Error; err != nil {
+        return errors.New("user not found")
+    }
+    if user.DisabledAt != nil {
+        return errors.New("user disabled")
+    }
+    userScopes := &Identity{Scopes: ParseScopes(user.Scopes)}
+    if len(id.Scopes) == 0 {
+        id.Scopes = userScopes.Scopes
+    } else {
+        scopes := make([]string, 0, len(id.Scopes))
+        for _, s := range id.Scopes {
+            if userScopes.HasScope(s) {
+                scopes = append(scopes, s)
+            }
+        }
+        if len(scopes) == 0 {
+            return errors.New("token has no effective scopes")
+        }
+        id.Scopes = scopes
+    }
+    id.Username = user.Username
+    return nil
+}
+
+// authenticateAPIKey 校验 API Key：未吊销、未过期且所属用户未被禁用；
+// 身份的权限范围为 Key 与用户权限范围的交集
+func authenticateAPIKey(c *gin.Context, key string) (*Identity, error) {
+    dbI, ok := c.Get("db")
+    if !ok || key == "" {
+        return nil, errors.New("api key authentication unavailable")
+    }
+    db := dbI.(*gorm.DB)
+    var apiKey entity.ApiKey
+    if err := db.Where("key_hash = ?", HashAPIKey(key)).First(&apiKey).Error; err != nil {
+        return nil, errors.New("unknown api key")
+    }
+    now := time.Now()
+    if apiKey.RevokedAt != nil {
+        return nil, errors.New("api key revoked")
+    }
+    if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+        return nil, errors.New("api key expired")
+    }
+    var user entity.User
+    if err := db.First(&user, "id = ?", apiKey.UserID).Error; err != nil {
+        return nil, errors.New("api key owner not found")
+    }
+    if user.DisabledAt != nil {
+        return nil, errors.New("user disabled")
+    }
+    userScopes := &Identity{Scopes: ParseScopes(user.Scopes)}
+    scopes := make([]string, 0)
+    for _, s := range ParseScopes(apiKey.Scopes) {
+        if userScopes.HasScope(s) {
+            scopes = append(scopes, s)
+        }
+    }
+    if len(scopes) == 0 {
+        return nil, errors.New("api key has no effective scopes")
+    }
+    if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+        _ = db.Model(&entity.ApiKey{}).Where("id = ?", apiKey.ID).Update("last_used_at", now).Error
+    }
+    return &Identity{
+        UserID:   user.ID,
+        Subject:  strconv.FormatUint(user.ID, 10),
+        Username: user.Username,
+        Scopes:   scopes,
+    }, nil
+}
+
+func abortUnauthorized(c *gin.Context, msg string) {
+    c.Header("WWW-Authenticate", `Bearer realm="api", ApiKey realm="api"`)
+    c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": msg})
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/internal/dbtest"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const testSecret = "test-secret"
+
+func init() { gin.SetMode(gin.TestMode) }
+
+// newAuthRouter 挂载 Auth 中间件，受保护的路由返回解析出的调用方身份
+func newAuthRouter(t *testing.T, auth config.AuthConfig) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	db := dbtest.Open(t)
+	cfg := config.Default()
+	cfg.Auth = auth
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("db", db)
+		c.Set("config", cfg)
+		c.Next()
+	})
+	echo := func(c *gin.Context) {
+		id, ok := CurrentIdentity(c)
+		if !ok {
+			c.JSON(http.StatusOK, gin.H{})
+			return
+		}
+		c.JSON(http.StatusOK, id)
+	}
+	v1 := r.Group("/api/v1", Auth())
+	v1.GET("/files", echo)
+	v1.POST("/files", echo)
+	v1.GET("/files/:id/download", echo)
+	return r, db
+}
+
+func createUser(t *testing.T, db *gorm.DB, username, scopes string) *entity.User {
+	t.Helper()
+	u := &entity.User{Username: username, PasswordHash: "x", Scopes: scopes}
+	if err := db.Create(u).Error; err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func createKey(t *testing.T, db *gorm.DB, userID uint64, plain, scopes string, mut func(*entity.ApiKey)) {
+	t.Helper()
+	k := &entity.ApiKey{UserID: userID, Name: plain, Prefix: plain, KeyHash: HashAPIKey(plain), Scopes: scopes}
+	if mut != nil {
+		mut(k)
+	}
+	if err := db.Create(k).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func bearer(t *testing.T, id Identity) string {
+	t.Helper()
+	token, err := IssueToken(testSecret, time.Hour, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+func request(r *gin.Engine, method, target, authz string) (int, *Identity) {
+	req := httptest.NewRequest(method, target, nil)
+	if authz != "" {
+		req.Header.Set("Authorization", authz)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+	var id Identity
+	_ = json.Unmarshal(w.Body.Bytes(), &id)
+	return w.Code, &id
+}
+
+func TestAuthFailsClosed(t *testing.T) {
+	tests := []struct {
+		name  string
+		auth  config.AuthConfig
+		authz string
+	}{
+		{name: "no secret, no credentials", auth: config.AuthConfig{}},
+		{name: "secret, no credentials", auth: config.AuthConfig{JWTSecret: testSecret}},
+		{name: "no secret, bearer token", auth: config.AuthConfig{}, authz: "Bearer whatever"},
+		{name: "unknown scheme", auth: config.AuthConfig{JWTSecret: testSecret}, authz: "Basic dXNlcjpwYXNz"},
+		{name: "bad signature", auth: config.AuthConfig{JWTSecret: "other"}, authz: "Bearer x.y.z"},
+		{name: "unknown api key", auth: config.AuthConfig{JWTSecret: testSecret}, authz: "ApiKey gtk_missing"},
+		{name: "disabled, unknown api key", auth: config.AuthConfig{Disabled: true}, authz: "ApiKey gtk_missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newAuthRouter(t, tt.auth)
+			if code, _ := request(r, http.MethodGet, "/api/v1/files", tt.authz); code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401", code)
+			}
+		})
+	}
+}
+
+func TestAuthDisabledUsesAnonymousAdmin(t *testing.T) {
+	r, db := newAuthRouter(t, config.AuthConfig{Disabled: true})
+	code, id := request(r, http.MethodPost, "/api/v1/files", "")
+	if code != http.StatusOK || id.Subject != "anonymous" || id.UserID != 0 || !id.IsAdmin() {
+		t.Fatalf("status = %d, identity = %+v", code, id)
+	}
+
+	// 携带的凭据仍按正常流程校验
+	u := createUser(t, db, "alice", "read")
+	createKey(t, db, u.ID, "gtk_alice", "read", nil)
+	if code, id := request(r, http.MethodGet, "/api/v1/files", "ApiKey gtk_alice"); code != http.StatusOK || id.UserID != u.ID || id.IsAdmin() {
+		t.Fatalf("status = %d, identity = %+v", code, id)
+	}
+}
+
+func TestAuthBearerChecksUser(t *testing.T) {
+	r, db := newAuthRouter(t, config.AuthConfig{JWTSecret: testSecret})
+	alice := createUser(t, db, "alice", "read,write")
+	bob := createUser(t, db, "bob", "read")
+
+	code, id := request(r, http.MethodGet, "/api/v1/files", bearer(t, Identity{UserID: alice.ID, Username: "stale", Scopes: []string{"read", "write", "admin"}}))
+	if code != http.StatusOK || id.Username != "alice" || !reflect.DeepEqual(id.Scopes, []string{"read", "write"}) {
+		t.Fatalf("status = %d, identity = %+v", code, id)
+	}
+
+	// 令牌未携带权限范围时取用户当前的权限范围；只读用户不能写
+	bobToken := bearer(t, Identity{UserID: bob.ID})
+	if code, id := request(r, http.MethodGet, "/api/v1/files", bobToken); code != http.StatusOK || !reflect.DeepEqual(id.Scopes, []string{"read"}) {
+		t.Fatalf("status = %d, identity = %+v", code, id)
+	}
+	if code, _ := request(r, http.MethodPost, "/api/v1/files", bobToken); code != http.StatusForbidden {
+		t.Fatalf("read-only POST status = %d, want 403", code)
+	}
+
+	// 令牌与用户权限范围无交集
+	if code, _ := request(r, http.MethodGet, "/api/v1/files", bearer(t, Identity{UserID: bob.ID, Scopes: []string{"admin"}})); code != http.StatusUnauthorized {
+		t.Fatalf("no effective scopes status = %d, want 401", code)
+	}
+
+	now := time.Now()
+	if err := db.Model(bob).Update("disabled_at", now).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := request(r, http.MethodGet, "/api/v1/files", bobToken); code != http.StatusUnauthorized {
+		t.Fatalf("disabled user status = %d, want 401", code)
+	}
+	if err := db.Delete(alice).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := request(r, http.MethodGet, "/api/v1/files", bearer(t, Identity{UserID: alice.ID})); code != http.StatusUnauthorized {
+		t.Fatalf("deleted user status = %d, want 401", code)
+	}
+}
+
+func TestAuthAPIKey(t *testing.T) {
+	r, db := newAuthRouter(t, config.AuthConfig{})
+	u := createUser(t, db, "carol", "read,write")
+	past := time.Now().Add(-time.Hour)
+	createKey(t, db, u.ID, "gtk_rw", "read,write", nil)
+	createKey(t, db, u.ID, "gtk_ro", "read", nil)
+	createKey(t, db, u.ID, "gtk_admin", "read,admin", nil)
+	createKey(t, db, u.ID, "gtk_revoked", "read", func(k *entity.ApiKey) { k.RevokedAt = &past })
+	createKey(t, db, u.ID, "gtk_expired", "read", func(k *entity.ApiKey) { k.ExpiresAt = &past })
+
+	if code, id := request(r, http.MethodPost, "/api/v1/files", "ApiKey gtk_rw"); code != http.StatusOK || id.UserID != u.ID {
+		t.Fatalf("status = %d, identity = %+v", code, id)
+	}
+	if code, _ := request(r, http.MethodPost, "/api/v1/files", "ApiKey gtk_ro"); code != http.StatusForbidden {
+		t.Fatalf("read-only key POST status = %d, want 403", code)
+	}
+	// Key 的权限范围不能超出所属用户
+	if code, id := request(r, http.MethodGet, "/api/v1/files", "ApiKey gtk_admin"); code != http.StatusOK || id.IsAdmin() {
+		t.Fatalf("status = %d, identity = %+v", code, id)
+	}
+	for _, key := range []string{"gtk_revoked", "gtk_expired"} {
+		if code, _ := request(r, http.MethodGet, "/api/v1/files", "ApiKey "+key); code != http.StatusUnauthorized {
+			t.Fatalf("%s status = %d, want 401", key, code)
+		}
+	}
+}
+
+func TestAuthQueryTokenOnlyOnDownload(t *testing.T) {
+	r, db := newAuthRouter(t, config.AuthConfig{JWTSecret: testSecret})
+	u := createUser(t, db, "dave", "read")
+	token, err := IssueToken(testSecret, time.Hour, Identity{UserID: u.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, id := request(r, http.MethodGet, "/api/v1/files/1/download?access_token="+token, ""); code != http.StatusOK || id.UserID != u.ID {
+		t.Fatalf("download status = %d, identity = %+v", code, id)
+	}
+	if code, _ := request(r, http.MethodGet, "/api/v1/files?access_token="+token, ""); code != http.StatusUnauthorized {
+		t.Fatalf("list status = %d, want 401", code)
+	}
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// Blob 映射到数据库表 `blobs`，记录开启去重的 Bucket 中按内容寻址的对象，
+// RefCount 为指向该对象的 File 记录数，归零时才删除存储中的对象
+type Blob struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement;type:bigint"`
+	Bucket     string    `gorm:"size:100;not null;uniqueIndex:idx_blob_hash;uniqueIndex:idx_blob_object"`
+	SHA256     string    `gorm:"column:sha256;size:64;not null;uniqueIndex:idx_blob_hash"`
+	ObjectName string    `gorm:"size:1024;not null;uniqueIndex:idx_blob_object"`
+	Size       int64     `gorm:"type:bigint;not null"`
+	RefCount   int64     `gorm:"type:bigint;not null;default:0"`
+	CreatedAt  time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"type:timestamp;autoUpdateTime"`
+}
+
+func (Blob) TableName() string { return "blobs" }
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Bucket 映射到数据库表 `buckets`，记录通过 POST /api/v1/buckets 显式创建的 Bucket 及其选项；
+// 上传时隐式创建的 Bucket 仅在设置配额后才有对应记录
+type Bucket struct {
+	ID            uint64    `gorm:"primaryKey;autoIncrement;type:bigint"`
+	Name          string    `gorm:"size:63;not null;uniqueIndex"`
+	Region        string    `gorm:"size:64;not null;default:''"`
+	Versioning    bool      `gorm:"not null;default:false"`
+	ObjectLocking bool      `gorm:"not null;default:false"`
+	RetentionMode *string   `gorm:"size:16"` // GOVERNANCE / COMPLIANCE
+	RetentionDays *int      `gorm:"type:int"`
+	QuotaBytes    *int64    `gorm:"type:bigint"` // 为空表示使用 quota.bucket_quota
+	MaxObjectSize *int64    `gorm:"type:bigint"` // 为空表示使用 quota.max_object_size
+	CreatedBy     *uint64   `gorm:"type:bigint"`
+	CreatedAt     time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"type:timestamp;autoUpdateTime"`
+}
+
+func (Bucket) TableName() string { return "buckets" }
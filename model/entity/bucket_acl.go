@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// Bucket 角色：owner 唯一且拥有全部权限，admin 可管理授权与删除他人文件，writer 可上传，reader 可查看与下载
+const (
+	BucketRoleReader = "reader"
+	BucketRoleWriter = "writer"
+	BucketRoleAdmin  = "admin"
+	BucketRoleOwner  = "owner"
+)
+
+// BucketACL 映射到数据库表 `bucket_acls`，记录用户在 Bucket 上的角色；
+// 没有任何记录的 Bucket 视为未认领；上传时新建的 Bucket 由上传者认领为 owner，每个 Bucket 至多一个 owner
+type BucketACL struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement;type:bigint"`
+	Bucket    string    `gorm:"size:100;not null;uniqueIndex:idx_bucket_acl_user;uniqueIndex:idx_bucket_acl_owner,where:role = 'owner'"`
+	UserID    uint64    `gorm:"type:bigint;not null;uniqueIndex:idx_bucket_acl_user;index"`
+	Role      string    `gorm:"size:16;not null"`
+	GrantedBy *uint64   `gorm:"type:bigint"`
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"type:timestamp;autoUpdateTime"`
+}
+
+func (BucketACL) TableName() string { return "bucket_acls" }
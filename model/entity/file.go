@@ -8,27 +8,38 @@ import "time"
 //
 //	id BIGINT AUTO_INCREMENT PRIMARY KEY,
 //	bucket VARCHAR(100) NOT NULL,
-//	object_name VARCHAR(255) NOT NULL,
+//	object_name VARCHAR(1024) NOT NULL, -- 对象 key，保留压缩包目录结构时带有相对路径前缀
 //	original_name VARCHAR(255),
 //	url TEXT NOT NULL,
 //	size BIGINT,
 //	mime_type VARCHAR(100),
 //	uploader_id BIGINT,
+//	folder_id BIGINT, -- 所在文件夹，为空表示 Bucket 根目录
+//	sha256 VARCHAR(64), -- 内容摘要（hex），上传完成时由服务端计算
+//	tags JSONB NOT NULL DEFAULT '[]', -- 用户标签
+//	metadata JSONB NOT NULL DEFAULT '{}', -- 用户自定义键值元数据
 //	is_deleted BOOLEAN DEFAULT FALSE,
+//	deleted_at TIMESTAMP, -- 软删除时间，回收站按此计算保留期
 //	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 //
 // );
 type File struct {
-	ID           uint64    `gorm:"primaryKey;autoIncrement;type:bigint"`
-	Bucket       string    `gorm:"size:100;not null"`
-	ObjectName   string    `gorm:"size:255;not null"`
-	OriginalName *string   `gorm:"size:255"`
-	URL          string    `gorm:"type:text;not null"`
-	Size         *int64    `gorm:"type:bigint"`
-	MimeType     *string   `gorm:"size:100"`
-	UploaderID   *uint64   `gorm:"type:bigint"`
-	IsDeleted    bool      `gorm:"not null;default:false"`
-	CreatedAt    time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
+	ID           uint64     `gorm:"primaryKey;autoIncrement;type:bigint"`
+	Bucket       string     `gorm:"size:100;not null"`
+	ObjectName   string     `gorm:"size:1024;not null"`
+	OriginalName *string    `gorm:"size:255"`
+	URL          string     `gorm:"type:text;not null"`
+	Size         *int64     `gorm:"type:bigint"`
+	MimeType     *string    `gorm:"size:100"`
+	UploaderID   *uint64    `gorm:"type:bigint"`
+	FolderID     *uint64    `gorm:"type:bigint;index"`
+	SHA256       *string    `gorm:"column:sha256;size:64;index"`
+	Tags         Tags       `gorm:"not null;default:'[]'"`
+	Metadata     Metadata   `gorm:"not null;default:'{}'"`
+	IsDeleted    bool       `gorm:"not null;default:false"`
+	DeletedAt    *time.Time `gorm:"type:timestamp;index"`
+	PurgingAt    *time.Time `gorm:"type:timestamp" json:"-"` // 回收站清理开始删除对象的时间，非空时文件不可再恢复
+	CreatedAt    time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
 }
 
 func (File) TableName() string { return "files" }
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// Folder 映射到数据库表 `folders`，Bucket 中的虚拟目录（仅存在于数据库，对象存储中无对应对象）；
+// ParentID 为空表示位于 Bucket 根目录，Path 为自根目录起以 / 分隔的完整路径（冗余存储，便于按路径查找）
+type Folder struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement;type:bigint"`
+	Bucket    string    `gorm:"size:100;not null;uniqueIndex:idx_folder_path"`
+	ParentID  *uint64   `gorm:"type:bigint;index"`
+	Name      string    `gorm:"size:255;not null"`
+	Path      string    `gorm:"size:1024;not null;uniqueIndex:idx_folder_path"`
+	OwnerID   *uint64   `gorm:"type:bigint"`
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"type:timestamp;autoUpdateTime"`
+}
+
+func (Folder) TableName() string { return "folders" }
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Tags 文件标签，以 JSON 数组存储（Postgres 为 jsonb，可用 @> 检索）
+type Tags []string
+
+// Metadata 文件自定义键值元数据，以 JSON 对象存储（Postgres 为 jsonb）
+type Metadata map[string]string
+
+func (Tags) GormDataType() string { return "json" }
+
+func (Tags) GormDBDataType(db *gorm.DB, field *schema.Field) string { return jsonColumnType(db) }
+
+// Value 空值写为 []，保证列非空
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]string(t))
+	return string(b), err
+}
+
+func (t *Tags) Scan(src interface{}) error {
+	return scanJSON(src, t)
+}
+
+func (Metadata) GormDataType() string { return "json" }
+
+func (Metadata) GormDBDataType(db *gorm.DB, field *schema.Field) string { return jsonColumnType(db) }
+
+// Value 空值写为 {}，保证列非空
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(map[string]string(m))
+	return string(b), err
+}
+
+func (m *Metadata) Scan(src interface{}) error {
+	return scanJSON(src, m)
+}
+
+func jsonColumnType(db *gorm.DB) string {
+	if db.Dialector.Name() == "postgres" {
+		return "jsonb"
+	}
+	return "json"
+}
+
+func scanJSON(src interface{}, dest interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return fmt.Errorf("unsupported json column type %T", src)
+	}
+}
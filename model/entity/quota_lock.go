@@ -0,0 +1,10 @@
+package entity
+
+// QuotaLock 映射到数据库表 `quota_locks`，每个参与配额校验的 Bucket / 用户一行，首次校验时创建；
+// 写入占用配额的记录时在同一事务中锁定对应行，使同一 Bucket / 用户的配额校验依次进行
+type QuotaLock struct {
+	Scope   string `gorm:"primaryKey;size:16"`
+	Subject string `gorm:"primaryKey;size:100"`
+}
+
+func (QuotaLock) TableName() string { return "quota_locks" }
@@ -0,0 +1,62 @@
+package entity
+
+import "time"
+
+// 分块上传会话状态
+const (
+	UploadStatusActive     = "active"
+	UploadStatusCompleting = "completing"
+	UploadStatusCompleted  = "completed"
+	UploadStatusAborted    = "aborted"
+	UploadStatusExpired    = "expired"
+)
+
+// 分块上传会话类型：file 完成后写入一条文件记录，archive 完成后解压写入压缩包内的文件
+const (
+	UploadKindFile    = "file"
+	UploadKindArchive = "archive"
+)
+
+// UploadSession 映射到数据库表 `upload_sessions`，记录分块上传会话，
+// 使会话在服务重启后仍然有效，并可被负载均衡后的任意实例处理
+type UploadSession struct {
+	ID              string       `gorm:"primaryKey;size:36"`
+	Kind            string       `gorm:"size:20;not null"`
+	Bucket          string       `gorm:"size:100;not null"`
+	FolderID        *uint64      `gorm:"type:bigint"`            // 初始化时指定的目标文件夹，完成时写入文件记录
+	PreservePaths   bool         `gorm:"not null;default:false"` // 压缩包会话：解压时保留目录结构
+	Filename        string       `gorm:"size:255;not null"`
+	MimeType        *string      `gorm:"size:100"`
+	ObjectName      string       `gorm:"size:255;not null"`
+	StorageUploadID string       `gorm:"size:255;not null"`
+	TotalSize       *int64       `gorm:"type:bigint"`
+	TotalChunks     *int         `gorm:"type:integer"`
+	ChecksumAlgo    *string      `gorm:"size:20"`               // 客户端声明的整个文件摘要算法
+	Checksum        *string      `gorm:"size:128"`              // 客户端声明的整个文件摘要（hex），完成时校验
+	HashedParts     int          `gorm:"not null;default:0"`    // 已按序累积到整体摘要中的分片数（第 1..HashedParts 片）
+	HashState       []byte       `json:"-"`                     // 整体 SHA-256 的中间状态
+	ChecksumState   []byte       `json:"-"`                     // 初始化时声明的 md5 / crc32c 整体摘要的中间状态
+	Tags            Tags         `gorm:"not null;default:'[]'"` // 初始化时指定，完成时写入文件记录
+	Metadata        Metadata     `gorm:"not null;default:'{}'"`
+	Status          string       `gorm:"size:20;not null;default:active;index"`
+	OwnerID         *uint64      `gorm:"type:bigint;index"`
+	ExpiresAt       time.Time    `gorm:"type:timestamp;not null;index"`
+	CreatedAt       time.Time    `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
+	UpdatedAt       time.Time    `gorm:"type:timestamp;autoUpdateTime"`
+	Parts           []UploadPart `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:",omitempty"`
+}
+
+func (UploadSession) TableName() string { return "upload_sessions" }
+
+// UploadPart 映射到数据库表 `upload_parts`，记录会话已接收的分片
+type UploadPart struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement;type:bigint"`
+	SessionID  string    `gorm:"size:36;not null;uniqueIndex:idx_upload_part"`
+	PartNumber int       `gorm:"not null;uniqueIndex:idx_upload_part"`
+	Size       int64     `gorm:"type:bigint;not null"`
+	ETag       string    `gorm:"size:255"`
+	SHA256     string    `gorm:"column:sha256;size:64"`
+	CreatedAt  time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
+}
+
+func (UploadPart) TableName() string { return "upload_parts" }
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// User 映射到数据库表 `users`，用户名密码登录后签发 JWT
+type User struct {
+	ID           uint64     `gorm:"primaryKey;autoIncrement;type:bigint"`
+	Username     string     `gorm:"size:100;not null;uniqueIndex"`
+	PasswordHash string     `gorm:"size:255;not null" json:"-"`
+	Scopes       string     `gorm:"size:255;not null;default:''"` // 逗号分隔：read / write / admin
+	QuotaBytes   *int64     `gorm:"type:bigint"`                  // 为空表示使用 quota.user_quota
+	DisabledAt   *time.Time `gorm:"type:timestamp"`
+	CreatedAt    time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
+	UpdatedAt    time.Time  `gorm:"type:timestamp;autoUpdateTime"`
+}
+
+func (User) TableName() string { return "users" }
+
+// ApiKey 映射到数据库表 `api_keys`，供其他后端以 `Authorization: ApiKey <key>` 调用；
+// 只保存密钥的 SHA-256，明文仅在创建时返回一次
+type ApiKey struct {
+	ID         uint64     `gorm:"primaryKey;autoIncrement;type:bigint"`
+	UserID     uint64     `gorm:"type:bigint;not null;index"`
+	Name       string     `gorm:"size:100;not null"`
+	Prefix     string     `gorm:"size:16;not null"` // 明文前缀，便于识别
+	KeyHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"size:255;not null;default:''"` // 逗号分隔，不超过所属用户的权限范围
+	ExpiresAt  *time.Time `gorm:"type:timestamp"`
+	LastUsedAt *time.Time `gorm:"type:timestamp"`
+	RevokedAt  *time.Time `gorm:"type:timestamp"`
+	CreatedAt  time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;autoCreateTime"`
+}
+
+func (ApiKey) TableName() string { return "api_keys" }
@@ -0,0 +1,17 @@
+package response
+
+// 业务错误码：HTTP 状态码之外需要区分具体原因的场景
+const (
+    // CodeObjectTooLarge 单个对象超过最大大小限制（HTTP 413）
+    CodeObjectTooLarge = 41301
+    // CodeQuotaExceeded 超出 Bucket 或用户的容量配额（HTTP 413）
+    CodeQuotaExceeded = 41302
+    // CodeArchiveUnsupported 无法识别的压缩包格式（HTTP 400）
+    CodeArchiveUnsupported = 40001
+    // CodeArchiveEncrypted 压缩包已加密，不支持解密（HTTP 400）
+    CodeArchiveEncrypted = 40002
+    // CodeArchiveCorrupt 压缩包损坏或截断（HTTP 400）
+    CodeArchiveCorrupt = 40003
+    // CodeArchiveLimit 压缩包条目数或解压总量超出限制（HTTP 400）
+    CodeArchiveLimit = 40004
+)
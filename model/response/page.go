@@ -0,0 +1,12 @@
+package response
+
+// Page 分页列表数据：Total 为满足筛选条件的总数；
+// 游标分页时 NextCursor 非空表示还有下一页，偏移分页时通过 Offset + Limit 翻页
+type Page[T any] struct {
+    Items      []T    `json:"items"`
+    Total      int64  `json:"total"`
+    Limit      int    `json:"limit"`
+    Offset     int    `json:"offset"`
+    HasMore    bool   `json:"has_more"`
+    NextCursor string `json:"next_cursor,omitempty"`
+}
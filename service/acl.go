@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/binhy/go-template/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrOwnerRequired 修改 owner 角色需要由当前 owner（或全局管理员）操作
+var ErrOwnerRequired = errors.New("only the bucket owner can change ownership")
+
+// bucketRoleRank 角色由低到高，高角色包含低角色的全部权限
+var bucketRoleRank = map[string]int{
+	entity.BucketRoleReader: 1,
+	entity.BucketRoleWriter: 2,
+	entity.BucketRoleAdmin:  3,
+	entity.BucketRoleOwner:  4,
+}
+
+// ValidBucketRole 判断是否为受支持的 Bucket 角色
+func ValidBucketRole(role string) bool {
+	_, ok := bucketRoleRank[role]
+	return ok
+}
+
+// BucketRoleAllows 判断 role 是否满足 need 所需的权限
+func BucketRoleAllows(role, need string) bool {
+	return bucketRoleRank[role] > 0 && bucketRoleRank[role] >= bucketRoleRank[need]
+}
+
+// BucketRole 返回用户在 Bucket 上的角色（无授权时为空）；claimed 表示该 Bucket 是否已有任何授权记录
+func BucketRole(db *gorm.DB, bucket string, userID uint64) (role string, claimed bool, err error) {
+	var acls []entity.BucketACL
+	if err := db.Where("bucket = ?", bucket).Find(&acls).Error; err != nil {
+		return "", false, err
+	}
+	for _, acl := range acls {
+		if acl.UserID == userID {
+			role = acl.Role
+		}
+	}
+	return role, len(acls) > 0, nil
+}
+
+// ClaimBucket Bucket 尚无 owner 时由 userID 认领为 owner，返回认领后该用户是否为 owner；
+// 每个 Bucket 至多一个 owner 由部分唯一索引 idx_bucket_acl_owner 保证，并发认领时先写入者生效，其余插入被忽略
+func ClaimBucket(db *gorm.DB, bucket string, userID uint64) (bool, error) {
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.BucketACL{Bucket: bucket, UserID: userID, Role: entity.BucketRoleOwner}).Error
+	if err != nil {
+		return false, err
+	}
+	var owner entity.BucketACL
+	if err := db.Where("bucket = ? AND role = ?", bucket, entity.BucketRoleOwner).First(&owner).Error; err != nil {
+		return false, err
+	}
+	return owner.UserID == userID, nil
+}
+
+// GrantBucketRole 设置用户在 Bucket 上的角色；授予 owner 时原 owner 降级为 admin
+func GrantBucketRole(db *gorm.DB, bucket string, userID uint64, role string, grantedBy *uint64) (*entity.BucketACL, error) {
+	acl := &entity.BucketACL{Bucket: bucket, UserID: userID, Role: role, GrantedBy: grantedBy}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if role == entity.BucketRoleOwner {
+			if err := tx.Model(&entity.BucketACL{}).
+				Where("bucket = ? AND role = ? AND user_id <> ?", bucket, entity.BucketRoleOwner, userID).
+				Update("role", entity.BucketRoleAdmin).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "bucket"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "granted_by", "updated_at"}),
+		}).Create(acl).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Where("bucket = ? AND user_id = ?", bucket, userID).First(acl).Error; err != nil {
+		return nil, err
+	}
+	return acl, nil
+}
+
+// RevokeBucketRole 撤销用户在 Bucket 上的角色；owner 需先转移所有权，不能直接撤销
+func RevokeBucketRole(db *gorm.DB, bucket string, userID uint64) (bool, error) {
+	res := db.Where("bucket = ? AND user_id = ? AND role <> ?", bucket, userID, entity.BucketRoleOwner).
+		Delete(&entity.BucketACL{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// ListBucketACL 返回 Bucket 的全部授权记录
+func ListBucketACL(db *gorm.DB, bucket string) ([]entity.BucketACL, error) {
+	var acls []entity.BucketACL
+	err := db.Where("bucket = ?", bucket).Order("id ASC").Find(&acls).Error
+	return acls, err
+}
+
+// AccessibleBuckets 返回用户拥有任意角色的 Bucket 名称
+func AccessibleBuckets(db *gorm.DB, userID uint64) (map[string]string, error) {
+	var acls []entity.BucketACL
+	if err := db.Where("user_id = ?", userID).Find(&acls).Error; err != nil {
+		return nil, err
+	}
+	roles := make(map[string]string, len(acls))
+	for _, acl := range acls {
+		roles[acl.Bucket] = acl.Role
+	}
+	return roles, nil
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/binhy/go-template/model/entity"
+)
+
+func TestBucketRoleAllows(t *testing.T) {
+	roles := []string{entity.BucketRoleReader, entity.BucketRoleWriter, entity.BucketRoleAdmin, entity.BucketRoleOwner}
+	for i, role := range roles {
+		for j, need := range roles {
+			if got, want := BucketRoleAllows(role, need), i >= j; got != want {
+				t.Errorf("BucketRoleAllows(%q, %q) = %v, want %v", role, need, got, want)
+			}
+		}
+	}
+	for _, role := range []string{"", "guest", "OWNER"} {
+		if BucketRoleAllows(role, entity.BucketRoleReader) {
+			t.Errorf("unknown role %q allowed", role)
+		}
+		if ValidBucketRole(role) {
+			t.Errorf("ValidBucketRole(%q) = true", role)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package service 存放 api 与 core 共用、需要同时操作数据库与对象存储的业务逻辑
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AcquireBlob 在写入存储前查找相同内容的对象；命中时引用计数加一并返回其对象名
+func AcquireBlob(db *gorm.DB, bucket, sha256 string) (string, bool, error) {
+	var objectName string
+	var found bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var blob entity.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bucket = ? AND sha256 = ?", bucket, sha256).
+			First(&blob).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&blob).Update("ref_count", gorm.Expr("ref_count + 1")).Error; err != nil {
+			return err
+		}
+		objectName, found = blob.ObjectName, true
+		return nil
+	})
+	return objectName, found, err
+}
+
+// RegisterBlob 在对象写入存储后登记其内容摘要并持有一个引用。
+// 若并发上传已登记了相同内容，则改为引用已有对象并返回其对象名，调用方需删除自己刚写入的对象
+func RegisterBlob(db *gorm.DB, bucket, sha256, objectName string, size int64) (string, error) {
+	final := objectName
+	err := db.Transaction(func(tx *gorm.DB) error {
+		blob := entity.Blob{Bucket: bucket, SHA256: sha256, ObjectName: objectName, Size: size, RefCount: 1}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&blob)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil
+		}
+		var existing entity.Blob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bucket = ? AND sha256 = ?", bucket, sha256).
+			First(&existing).Error; err != nil {
+			return err
+		}
+		final = existing.ObjectName
+		return tx.Model(&existing).Update("ref_count", gorm.Expr("ref_count + 1")).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return final, nil
+}
+
+// ReleaseObject 释放 File 记录对对象的引用：去重对象引用计数减一并在归零时删除，
+// 未登记的对象（未开启去重）直接删除
+func ReleaseObject(ctx context.Context, db *gorm.DB, store storage.ObjectStore, bucket, objectName string) error {
+	var remove bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		remove, err = ReleaseBlob(tx, bucket, objectName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !remove {
+		return nil
+	}
+	if err := store.RemoveObject(ctx, bucket, objectName); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// ReleaseBlob 在事务 tx 中释放对对象的引用，返回对象是否已无引用、需要从存储中删除；
+// 用于批量操作等需要与其他数据库修改一同提交、提交后再删除对象的场景
+func ReleaseBlob(tx *gorm.DB, bucket, objectName string) (bool, error) {
+	var blob entity.Blob
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("bucket = ? AND object_name = ?", bucket, objectName).
+		First(&blob).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if blob.RefCount > 1 {
+		return false, tx.Model(&blob).Update("ref_count", gorm.Expr("ref_count - 1")).Error
+	}
+	if err := tx.Delete(&blob).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ObjectRef 存储中的一个对象
+type ObjectRef struct {
+	Bucket string
+	Key    string
+}
+
+// RemoveObjects 按 Bucket 分组批量删除对象，返回删除失败的对象及原因
+func RemoveObjects(ctx context.Context, store storage.ObjectStore, refs []ObjectRef) map[ObjectRef]error {
+	keys := map[string][]string{}
+	var buckets []string
+	for _, r := range refs {
+		if _, ok := keys[r.Bucket]; !ok {
+			buckets = append(buckets, r.Bucket)
+		}
+		keys[r.Bucket] = append(keys[r.Bucket], r.Key)
+	}
+	failed := map[ObjectRef]error{}
+	for _, b := range buckets {
+		for k, err := range store.RemoveObjects(ctx, b, keys[b]) {
+			failed[ObjectRef{Bucket: b, Key: k}] = err
+		}
+	}
+	return failed
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/binhy/go-template/internal/dbtest"
+	"github.com/binhy/go-template/model/entity"
+	"github.com/binhy/go-template/storage"
+	"gorm.io/gorm"
+)
+
+const blobSHA = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func blobByHash(t *testing.T, db *gorm.DB) (*entity.Blob, bool) {
+	t.Helper()
+	var blob entity.Blob
+	err := db.First(&blob, "bucket = ? AND sha256 = ?", "docs", blobSHA).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &blob, true
+}
+
+func putObject(t *testing.T, store storage.ObjectStore, key string) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := storage.EnsureBucket(ctx, store, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.PutObject(ctx, "docs", key, bytes.NewReader([]byte("x")), 1, storage.PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterBlobConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+	const n = 8
+	finals := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			finals[i], errs[i] = RegisterBlob(db, "docs", blobSHA, fmt.Sprintf("obj-%d", i), 1)
+		}(i)
+	}
+	wg.Wait()
+
+	// 只有一个上传登记了自己的对象，其余均引用该对象
+	winners := 0
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("register %d: %v", i, errs[i])
+		}
+		if finals[i] != finals[0] {
+			t.Fatalf("register %d = %s, want %s", i, finals[i], finals[0])
+		}
+		if finals[i] == fmt.Sprintf("obj-%d", i) {
+			winners++
+		}
+	}
+	if winners != 1 {
+		t.Fatalf("%d uploads kept their own object, want 1", winners)
+	}
+	blob, ok := blobByHash(t, db)
+	if !ok || blob.ObjectName != finals[0] || blob.RefCount != n {
+		t.Fatalf("blob = %+v", blob)
+	}
+}
+
+func TestReleaseBlobLastReference(t *testing.T) {
+	db := dbtest.Open(t)
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	putObject(t, store, "first")
+	if _, err := RegisterBlob(db, "docs", blobSHA, "first", 1); err != nil {
+		t.Fatal(err)
+	}
+	if name, ok, err := AcquireBlob(db, "docs", blobSHA); err != nil || !ok || name != "first" {
+		t.Fatalf("acquire = %s, %v, %v", name, ok, err)
+	}
+
+	if err := ReleaseObject(ctx, db, store, "docs", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if blob, ok := blobByHash(t, db); !ok || blob.RefCount != 1 {
+		t.Fatalf("blob after first release = %+v", blob)
+	}
+	if _, err := store.StatObject(ctx, "docs", "first"); err != nil {
+		t.Fatalf("object removed while still referenced: %v", err)
+	}
+
+	// 释放最后一个引用时删除记录与对象，之后相同内容须重新写入
+	if err := ReleaseObject(ctx, db, store, "docs", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if blob, ok := blobByHash(t, db); ok {
+		t.Fatalf("blob kept after last release: %+v", blob)
+	}
+	if _, err := store.StatObject(ctx, "docs", "first"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("stat after last release = %v, want ErrNotFound", err)
+	}
+	if _, ok, err := AcquireBlob(db, "docs", blobSHA); err != nil || ok {
+		t.Fatalf("acquire after last release = %v, %v", ok, err)
+	}
+	putObject(t, store, "second")
+	if name, err := RegisterBlob(db, "docs", blobSHA, "second", 1); err != nil || name != "second" {
+		t.Fatalf("register after last release = %s, %v", name, err)
+	}
+	if blob, ok := blobByHash(t, db); !ok || blob.ObjectName != "second" || blob.RefCount != 1 {
+		t.Fatalf("blob = %+v", blob)
+	}
+}
+
+func TestAcquireRacingLastRelease(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		db := dbtest.Open(t)
+		store, err := storage.NewLocalStore(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		putObject(t, store, "obj")
+		if _, err := RegisterBlob(db, "docs", blobSHA, "obj", 1); err != nil {
+			t.Fatal(err)
+		}
+
+		var acquired bool
+		var acquireErr, releaseErr error
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, acquired, acquireErr = AcquireBlob(db, "docs", blobSHA)
+		}()
+		go func() {
+			defer wg.Done()
+			releaseErr = ReleaseObject(ctx, db, store, "docs", "obj")
+		}()
+		wg.Wait()
+		if acquireErr != nil || releaseErr != nil {
+			t.Fatalf("acquire = %v, release = %v", acquireErr, releaseErr)
+		}
+
+		// 抢先引用时对象保留且计数为 1；否则记录与对象均已删除
+		blob, ok := blobByHash(t, db)
+		_, statErr := store.StatObject(ctx, "docs", "obj")
+		if acquired {
+			if !ok || blob.RefCount != 1 || statErr != nil {
+				t.Fatalf("acquired: blob = %+v, stat = %v", blob, statErr)
+			}
+		} else if ok || !errors.Is(statErr, storage.ErrNotFound) {
+			t.Fatalf("released: blob = %+v, stat = %v", blob, statErr)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/binhy/go-template/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxFolderNameLength = 255
+	// MaxFolderPathLength 文件夹完整路径的最大字符数
+	MaxFolderPathLength = 1024
+)
+
+var (
+	// ErrInvalidFolder 文件夹名称或路径不合法
+	ErrInvalidFolder  = errors.New("invalid folder")
+	ErrFolderNotFound = errors.New("folder not found")
+	ErrFolderExists   = errors.New("folder already exists")
+	ErrFolderNotEmpty = errors.New("folder is not empty")
+	// ErrFolderCycle 不能将文件夹移动到自身或其子文件夹中
+	ErrFolderCycle = errors.New("cannot move a folder into itself or its subfolder")
+)
+
+// ValidateFolderName 校验文件夹名称：非空、不含 / 与控制字符、不为 . 或 ..
+func ValidateFolderName(name string) error {
+	if name == "" || name == "." || name == ".." {
+		return fmt.Errorf("%w name %q", ErrInvalidFolder, name)
+	}
+	if utf8.RuneCountInString(name) > maxFolderNameLength {
+		return fmt.Errorf("%w name: at most %d characters", ErrInvalidFolder, maxFolderNameLength)
+	}
+	if strings.TrimSpace(name) != name {
+		return fmt.Errorf("%w name %q: leading or trailing spaces", ErrInvalidFolder, name)
+	}
+	for _, r := range name {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return fmt.Errorf("%w name %q: must not contain slashes or control characters", ErrInvalidFolder, name)
+		}
+	}
+	return nil
+}
+
+// SplitFolderPath 将以 / 分隔的路径拆分为各级名称并逐级校验，忽略首尾与重复的 /；根目录返回空切片
+func SplitFolderPath(path string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+		if err := ValidateFolderName(name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if utf8.RuneCountInString(strings.Join(names, "/")) > MaxFolderPathLength {
+		return nil, fmt.Errorf("%w path: at most %d characters", ErrInvalidFolder, MaxFolderPathLength)
+	}
+	return names, nil
+}
+
+// folderPath 拼接子文件夹的完整路径
+func folderPath(parent *entity.Folder, name string) string {
+	if parent == nil {
+		return name
+	}
+	return parent.Path + "/" + name
+}
+
+// FindFolder 按 ID 查找 bucket 中的文件夹，不存在或不属于该 Bucket 时返回 ErrFolderNotFound
+func FindFolder(db *gorm.DB, bucket string, id uint64) (*entity.Folder, error) {
+	var folders []entity.Folder
+	if err := db.Where("id = ? AND bucket = ?", id, bucket).Limit(1).Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	if len(folders) == 0 {
+		return nil, ErrFolderNotFound
+	}
+	return &folders[0], nil
+}
+
+// FindFolderByPath 按路径查找文件夹，根目录返回 nil
+func FindFolderByPath(db *gorm.DB, bucket, path string) (*entity.Folder, error) {
+	names, err := SplitFolderPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var folders []entity.Folder
+	if err := db.Where("bucket = ? AND path = ?", bucket, strings.Join(names, "/")).Limit(1).Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	if len(folders) == 0 {
+		return nil, ErrFolderNotFound
+	}
+	return &folders[0], nil
+}
+
+// CreateFolder 在 parent（为空表示根目录）下创建文件夹，同名文件夹已存在时返回 ErrFolderExists
+func CreateFolder(db *gorm.DB, bucket string, parent *entity.Folder, name string, ownerID *uint64) (*entity.Folder, error) {
+	if err := ValidateFolderName(name); err != nil {
+		return nil, err
+	}
+	f := &entity.Folder{Bucket: bucket, Name: name, Path: folderPath(parent, name), OwnerID: ownerID}
+	if parent != nil {
+		f.ParentID = &parent.ID
+	}
+	if utf8.RuneCountInString(f.Path) > MaxFolderPathLength {
+		return nil, fmt.Errorf("%w path: at most %d characters", ErrInvalidFolder, MaxFolderPathLength)
+	}
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(f)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrFolderExists
+	}
+	return f, nil
+}
+
+// EnsureFolderPath 逐级查找或创建 bucket 中的 path，返回最末级文件夹；path 为根目录时返回 nil
+func EnsureFolderPath(db *gorm.DB, bucket, path string, ownerID *uint64) (*entity.Folder, error) {
+	return EnsureSubfolderPath(db, bucket, nil, path, ownerID)
+}
+
+// EnsureSubfolderPath 在 parent（为空表示根目录）下逐级查找或创建相对路径 path，返回最末级文件夹；path 为空时返回 parent
+func EnsureSubfolderPath(db *gorm.DB, bucket string, parent *entity.Folder, path string, ownerID *uint64) (*entity.Folder, error) {
+	names, err := SplitFolderPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if parent != nil && utf8.RuneCountInString(folderPath(parent, strings.Join(names, "/"))) > MaxFolderPathLength {
+		return nil, fmt.Errorf("%w path: at most %d characters", ErrInvalidFolder, MaxFolderPathLength)
+	}
+	for _, name := range names {
+		f, err := FindFolderByPath(db, bucket, folderPath(parent, name))
+		if errors.Is(err, ErrFolderNotFound) {
+			f, err = CreateFolder(db, bucket, parent, name, ownerID)
+			if errors.Is(err, ErrFolderExists) {
+				// 并发创建同一路径时以先写入者为准
+				f, err = FindFolderByPath(db, bucket, folderPath(parent, name))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent = f
+	}
+	return parent, nil
+}
+
+// RenameFolder 重命名文件夹，并同步更新全部子文件夹的路径
+func RenameFolder(db *gorm.DB, f *entity.Folder, name string) error {
+	if err := ValidateFolderName(name); err != nil {
+		return err
+	}
+	var parent *entity.Folder
+	if f.ParentID != nil {
+		p, err := FindFolder(db, f.Bucket, *f.ParentID)
+		if err != nil {
+			return err
+		}
+		parent = p
+	}
+	return relocateFolder(db, f, parent, name)
+}
+
+// MoveFolder 将文件夹移动到 parent（为空表示根目录）下，并同步更新全部子文件夹的路径
+func MoveFolder(db *gorm.DB, f *entity.Folder, parent *entity.Folder) error {
+	if parent != nil {
+		if parent.Bucket != f.Bucket {
+			return ErrFolderNotFound
+		}
+		if parent.ID == f.ID || strings.HasPrefix(parent.Path, f.Path+"/") {
+			return ErrFolderCycle
+		}
+	}
+	return relocateFolder(db, f, parent, f.Name)
+}
+
+// relocateFolder 将文件夹改为 parent 下名为 name 的文件夹；子文件夹的 ParentID 不变，仅替换路径前缀
+func relocateFolder(db *gorm.DB, f *entity.Folder, parent *entity.Folder, name string) error {
+	oldPath, newPath := f.Path, folderPath(parent, name)
+	if oldPath == newPath {
+		return nil
+	}
+	if utf8.RuneCountInString(newPath) > MaxFolderPathLength {
+		return fmt.Errorf("%w path: at most %d characters", ErrInvalidFolder, MaxFolderPathLength)
+	}
+	var parentID *uint64
+	if parent != nil {
+		parentID = &parent.ID
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&entity.Folder{}).Where("bucket = ? AND path = ?", f.Bucket, newPath).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrFolderExists
+		}
+		if err := tx.Model(&entity.Folder{}).Where("id = ?", f.ID).
+			Updates(map[string]interface{}{"parent_id": parentID, "name": name, "path": newPath}).Error; err != nil {
+			return err
+		}
+		// 按字符数截取前缀比较，避免路径中的 % 与 _ 被 LIKE 当作通配符
+		prefix := oldPath + "/"
+		return tx.Model(&entity.Folder{}).
+			Where("bucket = ? AND SUBSTR(path, 1, ?) = ?", f.Bucket, utf8.RuneCountInString(prefix), prefix).
+			Update("path", gorm.Expr("CAST(? AS TEXT) || SUBSTR(path, ?)", newPath+"/", utf8.RuneCountInString(prefix)+1)).Error
+	})
+	if err != nil {
+		return err
+	}
+	f.ParentID, f.Name, f.Path = parentID, name, newPath
+	return nil
+}
+
+// DeleteFolder 删除空文件夹；仍有子文件夹或文件（含回收站中的文件）时返回 ErrFolderNotEmpty
+func DeleteFolder(db *gorm.DB, f *entity.Folder) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var folders, files int64
+		if err := tx.Model(&entity.Folder{}).Where("parent_id = ?", f.ID).Count(&folders).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.File{}).Where("folder_id = ?", f.ID).Count(&files).Error; err != nil {
+			return err
+		}
+		if folders > 0 || files > 0 {
+			return ErrFolderNotEmpty
+		}
+		return tx.Delete(f).Error
+	})
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateFolderName(t *testing.T) {
+	valid := []string{"docs", "季度报告", "a b", "v1.2", "...", strings.Repeat("a", maxFolderNameLength)}
+	for _, name := range valid {
+		if err := ValidateFolderName(name); err != nil {
+			t.Errorf("ValidateFolderName(%q) = %v, want nil", name, err)
+		}
+	}
+	invalid := []string{
+		"",
+		".",
+		"..",
+		" docs",
+		"docs ",
+		"a/b",
+		"a\\b",
+		"a\x00b",
+		"a\nb",
+		strings.Repeat("a", maxFolderNameLength+1),
+	}
+	for _, name := range invalid {
+		if err := ValidateFolderName(name); !errors.Is(err, ErrInvalidFolder) {
+			t.Errorf("ValidateFolderName(%q) = %v, want ErrInvalidFolder", name, err)
+		}
+	}
+}
+
+func TestSplitFolderPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "", want: nil},
+		{path: "/", want: nil},
+		{path: "docs", want: []string{"docs"}},
+		{path: "/docs/2024/", want: []string{"docs", "2024"}},
+		{path: "docs//2024", want: []string{"docs", "2024"}},
+		{path: "docs/../etc", wantErr: true},
+		{path: "docs/./2024", wantErr: true},
+		{path: "docs/ 2024", wantErr: true},
+		{path: "docs\\2024", wantErr: true},
+		{path: strings.Repeat("abcdefghi/", MaxFolderPathLength/10+1), wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SplitFolderPath(tt.path)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidFolder) {
+				t.Errorf("SplitFolderPath(%q) error = %v, want ErrInvalidFolder", tt.path, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SplitFolderPath(%q) error = %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitFolderPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
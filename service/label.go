@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/binhy/go-template/model/entity"
+)
+
+// 标签与元数据限制，与 S3 对象标签（最多 10 个，键 128 字符）及用户元数据（2KB）的限制一致，保证可同步到存储后端
+const (
+	MaxFileTags      = 10
+	maxTagLength     = 128
+	maxMetaKeyLength = 128
+	maxMetadataBytes = 2048
+)
+
+var (
+	// tagPattern S3 对象标签允许的字符
+	tagPattern = regexp.MustCompile(`^[\p{L}\p{N}\p{Zs}+\-=._:/@]+$`)
+	// metaKeyPattern 元数据键会作为 HTTP 头名称（x-amz-meta-<key>），仅允许小写字母、数字、- 与 _
+	metaKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+)
+
+// NormalizeTags 去除首尾空白与重复标签并校验，保持原有顺序
+func NormalizeTags(raw []string) (entity.Tags, error) {
+	tags := entity.Tags{}
+	seen := map[string]bool{}
+	for _, t := range raw {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		if utf8.RuneCountInString(t) > maxTagLength || !tagPattern.MatchString(t) {
+			return nil, fmt.Errorf("invalid tag %q: at most %d letters, digits, spaces or + - = . _ : / @", t, maxTagLength)
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	if len(tags) > MaxFileTags {
+		return nil, fmt.Errorf("too many tags: at most %d allowed", MaxFileTags)
+	}
+	return tags, nil
+}
+
+// NormalizeMetadata 键统一转为小写并校验，总大小（键 + 值）不超过 2KB
+func NormalizeMetadata(raw map[string]string) (entity.Metadata, error) {
+	meta := entity.Metadata{}
+	total := 0
+	for k, v := range raw {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if len(k) > maxMetaKeyLength || !metaKeyPattern.MatchString(k) {
+			return nil, fmt.Errorf("invalid metadata key %q: use lowercase letters, digits, - or _", k)
+		}
+		if _, dup := meta[k]; dup {
+			return nil, fmt.Errorf("duplicate metadata key %q", k)
+		}
+		if !utf8.ValidString(v) {
+			return nil, fmt.Errorf("metadata value for %q is not valid UTF-8", k)
+		}
+		meta[k] = v
+		total += len(k) + len(v)
+	}
+	if total > maxMetadataBytes {
+		return nil, fmt.Errorf("metadata too large: %d bytes, at most %d allowed", total, maxMetadataBytes)
+	}
+	return meta, nil
+}
+
+// ObjectTags 将文件标签转换为对象标签（标签作为键，值为空）
+func ObjectTags(tags entity.Tags) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(tags))
+	for _, t := range tags {
+		m[t] = ""
+	}
+	return m
+}
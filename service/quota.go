@@ -0,0 +1,218 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/binhy/go-template/config"
+	"github.com/binhy/go-template/model/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 超限的配额范围
+const (
+	QuotaScopeObject = "object"
+	QuotaScopeBucket = "bucket"
+	QuotaScopeUser   = "user"
+)
+
+// QuotaError 上传超出大小限制或容量配额
+type QuotaError struct {
+	Scope     string
+	Limit     int64
+	Used      int64
+	Requested int64
+}
+
+func (e *QuotaError) Error() string {
+	if e.Scope == QuotaScopeObject {
+		return fmt.Sprintf("object size %d exceeds the limit of %d bytes", e.Requested, e.Limit)
+	}
+	return fmt.Sprintf("%s quota exceeded: %d of %d bytes used, %d requested", e.Scope, e.Used, e.Limit, e.Requested)
+}
+
+// QuotaLimits 生效的限制（字节），0 表示不限制
+type QuotaLimits struct {
+	MaxObjectSize int64
+	BucketQuota   int64
+	UserQuota     int64
+}
+
+// ResolveQuotaLimits 计算上传到 bucket 时生效的限制：Bucket / 用户上的设置优先于配置默认值，
+// Bucket 的最大对象大小不能超过全局上限
+func ResolveQuotaLimits(db *gorm.DB, cfg config.QuotaConfig, bucket string, userID *uint64) (QuotaLimits, error) {
+	limits := QuotaLimits{MaxObjectSize: cfg.MaxObjectSize, BucketQuota: cfg.BucketQuota}
+	// 隐式创建的 Bucket 与外部签发令牌的用户可能没有记录，使用 Find 避免记录不存在时的错误日志
+	var recs []entity.Bucket
+	if err := db.Where("name = ?", bucket).Limit(1).Find(&recs).Error; err != nil {
+		return limits, err
+	}
+	if len(recs) == 1 {
+		if recs[0].QuotaBytes != nil {
+			limits.BucketQuota = *recs[0].QuotaBytes
+		}
+		if m := recs[0].MaxObjectSize; m != nil && (limits.MaxObjectSize == 0 || *m < limits.MaxObjectSize) {
+			limits.MaxObjectSize = *m
+		}
+	}
+	if userID == nil {
+		return limits, nil
+	}
+	limits.UserQuota = cfg.UserQuota
+	var users []entity.User
+	if err := db.Select("id", "quota_bytes").Where("id = ?", *userID).Limit(1).Find(&users).Error; err != nil {
+		return limits, err
+	}
+	if len(users) == 1 && users[0].QuotaBytes != nil {
+		limits.UserQuota = *users[0].QuotaBytes
+	}
+	return limits, nil
+}
+
+// BucketUsage 统计 Bucket 已占用的字节数：文件记录（含软删除，对象仍在存储中）与分块上传已接收的分片。
+// 每条文件记录按其完整大小计入，开启去重后复用已有对象的上传同样计入，使用量与删除后释放的额度一致
+func BucketUsage(db *gorm.DB, bucket string) (int64, error) {
+	return usage(db, "bucket = ?", "upload_sessions.bucket = ?", bucket)
+}
+
+// UserUsage 统计用户已占用的字节数，口径同 BucketUsage
+func UserUsage(db *gorm.DB, userID uint64) (int64, error) {
+	return usage(db, "uploader_id = ?", "upload_sessions.owner_id = ?", userID)
+}
+
+func usage(db *gorm.DB, fileCond, sessionCond string, arg interface{}) (int64, error) {
+	var files, parts int64
+	if err := db.Model(&entity.File{}).Where(fileCond, arg).
+		Select("COALESCE(SUM(size), 0)").Scan(&files).Error; err != nil {
+		return 0, err
+	}
+	// 合并中的文件会话在写入文件记录的同一事务中删除分片，合并期间仍计入；
+	// 压缩包会话进入合并后按解压出的文件逐个计入，暂存的分片不再计入
+	if err := db.Model(&entity.UploadPart{}).
+		Joins("JOIN upload_sessions ON upload_sessions.id = upload_parts.session_id").
+		Where(sessionCond, arg).
+		Where("upload_sessions.status = ? OR (upload_sessions.status = ? AND upload_sessions.kind = ?)",
+			entity.UploadStatusActive, entity.UploadStatusCompleting, entity.UploadKindFile).
+		Select("COALESCE(SUM(upload_parts.size), 0)").Scan(&parts).Error; err != nil {
+		return 0, err
+	}
+	return files + parts, nil
+}
+
+// EnforceQuota 在事务 tx 中锁定 Bucket 与用户的配额行，按当前用量校验新增的 delta 字节，超限时返回 *QuotaError。
+// 调用方须在同一事务中写入占用这些字节的记录（文件记录或分片），提交前其他写入在配额行上等待，
+// 避免并发上传各自读取旧用量后共同超出配额；delta 不大于 0 或未设置配额时不做校验
+func EnforceQuota(tx *gorm.DB, limits QuotaLimits, bucket string, userID *uint64, delta int64) error {
+	if delta <= 0 {
+		return nil
+	}
+	if limits.BucketQuota > 0 {
+		if err := lockQuota(tx, QuotaScopeBucket, bucket); err != nil {
+			return err
+		}
+		used, err := BucketUsage(tx, bucket)
+		if err != nil {
+			return err
+		}
+		if used+delta > limits.BucketQuota {
+			return &QuotaError{Scope: QuotaScopeBucket, Limit: limits.BucketQuota, Used: used, Requested: delta}
+		}
+	}
+	if limits.UserQuota > 0 && userID != nil {
+		if err := lockQuota(tx, QuotaScopeUser, strconv.FormatUint(*userID, 10)); err != nil {
+			return err
+		}
+		used, err := UserUsage(tx, *userID)
+		if err != nil {
+			return err
+		}
+		if used+delta > limits.UserQuota {
+			return &QuotaError{Scope: QuotaScopeUser, Limit: limits.UserQuota, Used: used, Requested: delta}
+		}
+	}
+	return nil
+}
+
+// CreateFileWithQuota 在事务中按配额校验并写入文件记录，rec.Size 计入 Bucket 与上传者的用量
+func CreateFileWithQuota(db *gorm.DB, limits QuotaLimits, rec *entity.File) error {
+	var size int64
+	if rec.Size != nil {
+		size = *rec.Size
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := EnforceQuota(tx, limits, rec.Bucket, rec.UploaderID, size); err != nil {
+			return err
+		}
+		return tx.Create(rec).Error
+	})
+}
+
+// lockQuota 锁定配额行，不存在时先创建；并发创建同一行时 DoNothing 的插入会等待先插入的事务结束
+func lockQuota(tx *gorm.DB, scope, subject string) error {
+	lock := entity.QuotaLock{Scope: scope, Subject: subject}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ? AND subject = ?", scope, subject).First(&lock).Error
+}
+
+// QuotaTracker 在一次上传（含压缩包内的多个文件）中累计已写入的字节并校验限制，用于写入存储前尽早拒绝超限的上传；
+// 读取的用量可能已被并发上传改变，写入记录时须再由 EnforceQuota 在事务中校验
+type QuotaTracker struct {
+	Limits     QuotaLimits
+	BucketUsed int64
+	UserUsed   int64
+}
+
+// NewQuotaTracker 解析生效限制并读取当前用量；未设置的配额不做统计
+func NewQuotaTracker(db *gorm.DB, cfg config.QuotaConfig, bucket string, userID *uint64) (*QuotaTracker, error) {
+	limits, err := ResolveQuotaLimits(db, cfg, bucket, userID)
+	if err != nil {
+		return nil, err
+	}
+	t := &QuotaTracker{Limits: limits}
+	if limits.BucketQuota > 0 {
+		if t.BucketUsed, err = BucketUsage(db, bucket); err != nil {
+			return nil, err
+		}
+	}
+	if limits.UserQuota > 0 && userID != nil {
+		if t.UserUsed, err = UserUsage(db, *userID); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Check 校验大小为 objectSize 的对象能否写入，其中 delta 为本次新增占用的字节数
+// （分块上传时 objectSize 为会话累计大小，delta 为当前分片大小）
+func (t *QuotaTracker) Check(objectSize, delta int64) error {
+	if t.Limits.MaxObjectSize > 0 && objectSize > t.Limits.MaxObjectSize {
+		return &QuotaError{Scope: QuotaScopeObject, Limit: t.Limits.MaxObjectSize, Requested: objectSize}
+	}
+	if t.Limits.BucketQuota > 0 && t.BucketUsed+delta > t.Limits.BucketQuota {
+		return &QuotaError{Scope: QuotaScopeBucket, Limit: t.Limits.BucketQuota, Used: t.BucketUsed, Requested: delta}
+	}
+	if t.Limits.UserQuota > 0 && t.UserUsed+delta > t.Limits.UserQuota {
+		return &QuotaError{Scope: QuotaScopeUser, Limit: t.Limits.UserQuota, Used: t.UserUsed, Requested: delta}
+	}
+	return nil
+}
+
+// Reserve 校验通过后将 size 计入用量
+func (t *QuotaTracker) Reserve(size int64) error {
+	if err := t.Check(size, size); err != nil {
+		return err
+	}
+	t.BucketUsed += size
+	t.UserUsed += size
+	return nil
+}
+
+// Release 写入失败时归还 Reserve 计入的用量
+func (t *QuotaTracker) Release(size int64) {
+	t.BucketUsed -= size
+	t.UserUsed -= size
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/binhy/go-template/internal/dbtest"
+	"github.com/binhy/go-template/model/entity"
+	"gorm.io/gorm"
+)
+
+func TestCreateFileWithQuotaConcurrent(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits QuotaLimits
+		scope  string
+	}{
+		{name: "bucket", limits: QuotaLimits{BucketQuota: 10}, scope: QuotaScopeBucket},
+		{name: "user", limits: QuotaLimits{UserQuota: 10}, scope: QuotaScopeUser},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := dbtest.Open(t)
+			owner := uint64(7)
+			const n = 8
+			errs := make([]error, n)
+			var wg sync.WaitGroup
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					size := int64(3)
+					// 各上传写入不同的 Bucket 时只有用户配额生效
+					bucket := "docs"
+					if tt.scope == QuotaScopeUser {
+						bucket = fmt.Sprintf("docs-%d", i)
+					}
+					rec := &entity.File{Bucket: bucket, ObjectName: fmt.Sprintf("obj-%d", i), URL: "x", Size: &size, UploaderID: &owner}
+					errs[i] = CreateFileWithQuota(db, tt.limits, rec)
+				}(i)
+			}
+			wg.Wait()
+
+			stored := 0
+			for i, err := range errs {
+				var qe *QuotaError
+				switch {
+				case err == nil:
+					stored++
+				case errors.As(err, &qe):
+					if qe.Scope != tt.scope {
+						t.Fatalf("upload %d: scope = %s, want %s", i, qe.Scope, tt.scope)
+					}
+				default:
+					t.Fatalf("upload %d: %v", i, err)
+				}
+			}
+			if stored != 3 {
+				t.Fatalf("%d uploads stored, want 3", stored)
+			}
+			used, err := UserUsage(db, owner)
+			if err != nil || used != 9 {
+				t.Fatalf("usage = %d, %v", used, err)
+			}
+		})
+	}
+}
+
+func TestUsageAccounting(t *testing.T) {
+	db := dbtest.Open(t)
+	owner := uint64(7)
+	size := int64(4)
+	// 开启去重后复用同一对象的两条记录各按完整大小计入
+	for _, name := range []string{"a.txt", "b.txt"} {
+		name := name
+		rec := &entity.File{Bucket: "docs", ObjectName: "shared", OriginalName: &name, URL: "x", Size: &size, UploaderID: &owner}
+		if err := db.Create(rec).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	sessions := []struct {
+		id, kind, status string
+	}{
+		{"s-active", entity.UploadKindFile, entity.UploadStatusActive},
+		{"s-completing", entity.UploadKindFile, entity.UploadStatusCompleting},
+		{"s-archive", entity.UploadKindArchive, entity.UploadStatusCompleting},
+		{"s-aborted", entity.UploadKindFile, entity.UploadStatusAborted},
+	}
+	for _, s := range sessions {
+		sess := &entity.UploadSession{ID: s.id, Kind: s.kind, Bucket: "docs", Filename: "big.bin", ObjectName: s.id, StorageUploadID: s.id, Status: s.status, OwnerID: &owner}
+		if err := db.Create(sess).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&entity.UploadPart{SessionID: s.id, PartNumber: 1, Size: 100}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 仍在接收分片与合并中的文件会话计入；合并中的压缩包按解压出的文件计入，暂存分片不再计入
+	const want = 4 + 4 + 100 + 100
+	if used, err := BucketUsage(db, "docs"); err != nil || used != want {
+		t.Fatalf("bucket usage = %d, %v, want %d", used, err, want)
+	}
+	if used, err := UserUsage(db, owner); err != nil || used != want {
+		t.Fatalf("user usage = %d, %v, want %d", used, err, want)
+	}
+
+	var qe *QuotaError
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return EnforceQuota(tx, QuotaLimits{BucketQuota: want + 1}, "docs", &owner, 2)
+	})
+	if !errors.As(err, &qe) || qe.Used != want || qe.Requested != 2 {
+		t.Fatalf("EnforceQuota = %v", err)
+	}
+	// 未设置配额或未新增字节时不做校验
+	for _, tc := range []struct {
+		limits QuotaLimits
+		delta  int64
+	}{{QuotaLimits{}, 1 << 30}, {QuotaLimits{BucketQuota: 1}, 0}, {QuotaLimits{UserQuota: 1}, -5}} {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return EnforceQuota(tx, tc.limits, "docs", &owner, tc.delta)
+		}); err != nil {
+			t.Fatalf("EnforceQuota(%+v, %d) = %v", tc.limits, tc.delta, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ValidateBucketName 按 S3 Bucket 命名规则校验名称：3-63 个字符，仅包含小写字母、数字、点与连字符，
+// 以字母或数字开头和结尾，不能包含连续的点，不能是 IP 地址格式，也不能使用 S3 保留的前缀与后缀
+func ValidateBucketName(name string) error {
+	if len(name) < 3 || len(name) > 63 {
+		return fmt.Errorf("bucket name must be between 3 and 63 characters long")
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if !(ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || ch == '.' || ch == '-') {
+			return fmt.Errorf("bucket name can only contain lowercase letters, numbers, dots and hyphens")
+		}
+	}
+	if !isAlnum(name[0]) || !isAlnum(name[len(name)-1]) {
+		return fmt.Errorf("bucket name must begin and end with a letter or number")
+	}
+	if strings.Contains(name, "..") {
+		return fmt.Errorf("bucket name must not contain two adjacent periods")
+	}
+	if net.ParseIP(name) != nil {
+		return fmt.Errorf("bucket name must not be formatted as an IP address")
+	}
+	for _, prefix := range []string{"xn--", "sthree-", "amzn-s3-demo-"} {
+		if strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("bucket name must not start with the reserved prefix %q", prefix)
+		}
+	}
+	for _, suffix := range []string{"-s3alias", "--ol-s3", "--x-s3", ".mrap"} {
+		if strings.HasSuffix(name, suffix) {
+			return fmt.Errorf("bucket name must not end with the reserved suffix %q", suffix)
+		}
+	}
+	return nil
+}
+
+func isAlnum(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9'
+}
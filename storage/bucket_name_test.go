@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateBucketName(t *testing.T) {
+	valid := []string{
+		"abc",
+		"my-bucket",
+		"my.bucket.2024",
+		"0numbers9",
+		strings.Repeat("a", 63),
+	}
+	for _, name := range valid {
+		if err := ValidateBucketName(name); err != nil {
+			t.Errorf("ValidateBucketName(%q) = %v, want nil", name, err)
+		}
+	}
+	invalid := []string{
+		"",
+		"ab",
+		strings.Repeat("a", 64),
+		"MyBucket",
+		"my_bucket",
+		"my bucket",
+		"-bucket",
+		"bucket-",
+		".bucket",
+		"bucket.",
+		"my..bucket",
+		"192.168.1.1",
+		"xn--bucket",
+		"sthree-bucket",
+		"amzn-s3-demo-bucket",
+		"bucket-s3alias",
+		"bucket--ol-s3",
+		"bucket--x-s3",
+		"bucket.mrap",
+		"../etc",
+	}
+	for _, name := range invalid {
+		if err := ValidateBucketName(name); err == nil {
+			t.Errorf("ValidateBucketName(%q) = nil, want error", name)
+		}
+	}
+}
@@ -0,0 +1,581 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// localMetaDir 存放对象元数据 sidecar 文件的隐藏目录（root/.meta/<bucket>/<key>.json）
+	localMetaDir = ".meta"
+	// localTmpDir 存放写入中的临时文件，写完后原子 rename 到目标位置
+	localTmpDir = ".tmp"
+	// localMultipartDir 存放进行中的分块上传（root/.multipart/<uploadID>/part_000001）
+	localMultipartDir = ".multipart"
+)
+
+// LocalStore 基于本地文件系统的 ObjectStore 实现：
+// 每个 Bucket 对应 root 下的一个目录，对象以文件形式存放；
+// Content-Type 等元信息保存在 .meta 目录下的 JSON sidecar 中
+type LocalStore struct {
+	root string
+}
+
+// localUpload 分块上传会话信息，保存在 .multipart/<uploadID>/upload.json
+type localUpload struct {
+	Bucket       string            `json:"bucket"`
+	Key          string            `json:"key"`
+	ContentType  string            `json:"content_type"`
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+// localMeta 对象 sidecar 元数据
+type localMeta struct {
+	ContentType  string            `json:"content_type"`
+	ETag         string            `json:"etag"`
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+// NewLocalStore 创建本地磁盘存储驱动，root 不存在时自动创建
+func NewLocalStore(root string) (*LocalStore, error) {
+	if root == "" {
+		return nil, errors.New("storage: local root is required")
+	}
+	for _, dir := range []string{root, filepath.Join(root, localMetaDir), filepath.Join(root, localTmpDir), filepath.Join(root, localMultipartDir)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &LocalStore{root: root}, nil
+}
+
+func (s *LocalStore) Driver() string { return DriverLocal }
+
+func (s *LocalStore) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	p, err := s.bucketPath(bucket)
+	if err != nil {
+		return false, err
+	}
+	fi, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return fi.IsDir(), nil
+}
+
+// MakeBucket 本地磁盘不支持版本控制与对象锁定，请求这些选项时返回 ErrNotSupported
+func (s *LocalStore) MakeBucket(ctx context.Context, bucket string, opts BucketOptions) error {
+	if opts.Versioning || opts.ObjectLocking || opts.RetentionMode != "" {
+		return ErrNotSupported
+	}
+	p, err := s.bucketPath(bucket)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(p, 0o755)
+}
+
+func (s *LocalStore) RemoveBucket(ctx context.Context, bucket string) error {
+	p, err := s.bucketPath(bucket)
+	if err != nil {
+		return err
+	}
+	if ok, err := s.BucketExists(ctx, bucket); err != nil {
+		return err
+	} else if !ok {
+		return ErrNotFound
+	}
+	objects, err := s.ListObjects(ctx, bucket, "")
+	if err != nil {
+		return err
+	}
+	if len(objects) > 0 {
+		return ErrBucketNotEmpty
+	}
+	if err := os.RemoveAll(filepath.Join(s.root, localMetaDir, bucket)); err != nil {
+		return err
+	}
+	return os.RemoveAll(p)
+}
+
+func (s *LocalStore) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]BucketInfo, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, BucketInfo{Name: e.Name(), CreatedAt: fi.ModTime()})
+	}
+	return list, nil
+}
+
+func (s *LocalStore) PutObject(ctx context.Context, bucket, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error) {
+	p, err := s.objectPath(bucket, key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if ok, err := s.BucketExists(ctx, bucket); err != nil {
+		return ObjectInfo{}, err
+	} else if !ok {
+		return ObjectInfo{}, ErrNotFound
+	}
+
+	// 先写入临时文件，校验大小后再 rename，避免读到写了一半的对象
+	tmp, err := os.CreateTemp(filepath.Join(s.root, localTmpDir), "put-*")
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if size >= 0 && n != size {
+		return ObjectInfo{}, fmt.Errorf("storage: size mismatch, expected %d got %d", size, n)
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(key))
+	}
+	if err := renameIntoPlace(tmpPath, p); err != nil {
+		return ObjectInfo{}, err
+	}
+	// sidecar 在对象就位后写入，rename 失败不会留下没有对象的元数据；写入失败时删除对象，不留下缺少元数据的对象
+	meta := localMeta{ContentType: contentType, ETag: hex.EncodeToString(h.Sum(nil)), UserMetadata: opts.UserMetadata, Tags: opts.Tags}
+	if err := s.writeMeta(bucket, key, meta); err != nil {
+		_ = s.RemoveObject(ctx, bucket, key)
+		return ObjectInfo{}, err
+	}
+	return s.StatObject(ctx, bucket, key)
+}
+
+func (s *LocalStore) GetObject(ctx context.Context, bucket, key string, opts GetOptions) (io.ReadCloser, error) {
+	p, err := s.objectPath(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	start, end, ok := opts.Range()
+	if !ok {
+		return f, nil
+	}
+	if start < 0 || end < start {
+		_ = f.Close()
+		return nil, fmt.Errorf("storage: invalid range %d-%d", start, end)
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &rangeReadCloser{Reader: io.LimitReader(f, end-start+1), f: f}, nil
+}
+
+func (s *LocalStore) StatObject(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	p, err := s.objectPath(bucket, key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	fi, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if fi.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+	meta := s.readMeta(bucket, key)
+	if meta.ContentType == "" {
+		meta.ContentType = mime.TypeByExtension(filepath.Ext(key))
+	}
+	return ObjectInfo{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         fi.Size(),
+		ContentType:  meta.ContentType,
+		ETag:         meta.ETag,
+		LastModified: fi.ModTime(),
+		UserMetadata: meta.UserMetadata,
+	}, nil
+}
+
+func (s *LocalStore) RemoveObject(ctx context.Context, bucket, key string) error {
+	p, err := s.objectPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	// 与 S3 语义一致：删除不存在的对象不视为错误
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	base, _ := s.bucketPath(bucket)
+	pruneEmptyDirs(filepath.Dir(p), base)
+	if mp, err := s.metaPath(bucket, key); err == nil {
+		_ = os.Remove(mp)
+		pruneEmptyDirs(filepath.Dir(mp), filepath.Join(s.root, localMetaDir, bucket))
+	}
+	return nil
+}
+
+func (s *LocalStore) RemoveObjects(ctx context.Context, bucket string, keys []string) map[string]error {
+	failed := map[string]error{}
+	for _, k := range keys {
+		if err := s.RemoveObject(ctx, bucket, k); err != nil {
+			failed[k] = err
+		}
+	}
+	return failed
+}
+
+func (s *LocalStore) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, opts PutOptions) (ObjectInfo, error) {
+	src, err := s.GetObject(ctx, srcBucket, srcKey, GetOptions{})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer src.Close()
+	return s.PutObject(ctx, dstBucket, dstKey, src, -1, opts)
+}
+
+func (s *LocalStore) ListObjects(ctx context.Context, bucket, prefix string) ([]ObjectInfo, error) {
+	base, err := s.bucketPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]ObjectInfo, 0)
+	err = filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := s.StatObject(ctx, bucket, key)
+		if err != nil {
+			return err
+		}
+		list = append(list, info)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list, nil
+}
+
+func (s *LocalStore) SetObjectTags(ctx context.Context, bucket, key string, tags map[string]string) error {
+	return s.updateMeta(ctx, bucket, key, func(meta *localMeta) { meta.Tags = tags })
+}
+
+func (s *LocalStore) SetObjectMetadata(ctx context.Context, bucket, key string, meta map[string]string) error {
+	return s.updateMeta(ctx, bucket, key, func(m *localMeta) { m.UserMetadata = meta })
+}
+
+// updateMeta 修改已存在对象的 sidecar 元数据
+func (s *LocalStore) updateMeta(ctx context.Context, bucket, key string, update func(*localMeta)) error {
+	info, err := s.StatObject(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	meta := s.readMeta(bucket, key)
+	meta.ContentType = info.ContentType
+	update(&meta)
+	return s.writeMeta(bucket, key, meta)
+}
+
+func (s *LocalStore) PresignedGetObject(ctx context.Context, bucket, key string, expiry time.Duration) (*url.URL, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *LocalStore) NewMultipartUpload(ctx context.Context, bucket, key string, opts PutOptions) (string, error) {
+	if _, err := s.objectPath(bucket, key); err != nil {
+		return "", err
+	}
+	if ok, err := s.BucketExists(ctx, bucket); err != nil {
+		return "", err
+	} else if !ok {
+		return "", ErrNotFound
+	}
+	uploadID := uuid.New().String()
+	dir := filepath.Join(s.root, localMultipartDir, uploadID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(localUpload{Bucket: bucket, Key: key, ContentType: opts.ContentType, UserMetadata: opts.UserMetadata, Tags: opts.Tags})
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "upload.json"), b, 0o644); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return uploadID, nil
+}
+
+func (s *LocalStore) PutObjectPart(ctx context.Context, bucket, key, uploadID string, number int, r io.Reader, size int64) (Part, error) {
+	dir, _, err := s.uploadDir(bucket, key, uploadID)
+	if err != nil {
+		return Part{}, err
+	}
+	tmp, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return Part{}, err
+	}
+	defer os.Remove(tmp.Name())
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Part{}, err
+	}
+	if size >= 0 && n != size {
+		return Part{}, fmt.Errorf("storage: part size mismatch, expected %d got %d", size, n)
+	}
+	// 重复上传同一分片号时原子覆盖
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, fmt.Sprintf("part_%06d", number))); err != nil {
+		return Part{}, err
+	}
+	return Part{Number: number, Size: n, ETag: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+func (s *LocalStore) ListObjectParts(ctx context.Context, bucket, key, uploadID string) ([]Part, error) {
+	dir, _, err := s.uploadDir(bucket, key, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]Part, 0, len(entries))
+	for _, e := range entries {
+		var number int
+		if _, err := fmt.Sscanf(e.Name(), "part_%06d", &number); err != nil || e.IsDir() {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		parts = append(parts, Part{Number: number, Size: fi.Size()})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+func (s *LocalStore) CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []Part) (ObjectInfo, error) {
+	dir, up, err := s.uploadDir(bucket, key, uploadID)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	readers := make([]io.Reader, 0, len(parts))
+	files := make([]*os.File, 0, len(parts))
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	var total int64
+	for _, p := range parts {
+		f, err := os.Open(filepath.Join(dir, fmt.Sprintf("part_%06d", p.Number)))
+		if err != nil {
+			return ObjectInfo{}, fmt.Errorf("storage: part %d: %w", p.Number, ErrNotFound)
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return ObjectInfo{}, err
+		}
+		files = append(files, f)
+		readers = append(readers, f)
+		total += fi.Size()
+	}
+	info, err := s.PutObject(ctx, bucket, key, io.MultiReader(readers...), total, PutOptions{ContentType: up.ContentType, UserMetadata: up.UserMetadata, Tags: up.Tags})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	_ = os.RemoveAll(dir)
+	return info, nil
+}
+
+func (s *LocalStore) AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	dir, _, err := s.uploadDir(bucket, key, uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// uploadDir 返回分块上传目录，并校验 bucket/key 与初始化时一致
+func (s *LocalStore) uploadDir(bucket, key, uploadID string) (string, localUpload, error) {
+	var up localUpload
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return "", up, ErrNotFound
+	}
+	dir := filepath.Join(s.root, localMultipartDir, uploadID)
+	b, err := os.ReadFile(filepath.Join(dir, "upload.json"))
+	if err != nil {
+		return "", up, ErrNotFound
+	}
+	if err := json.Unmarshal(b, &up); err != nil {
+		return "", up, err
+	}
+	if up.Bucket != bucket || up.Key != key {
+		return "", up, ErrNotFound
+	}
+	return dir, up, nil
+}
+
+func (s *LocalStore) bucketPath(bucket string) (string, error) {
+	if bucket == "" || strings.HasPrefix(bucket, ".") || strings.ContainsAny(bucket, `/\`) {
+		return "", fmt.Errorf("storage: invalid bucket name %q", bucket)
+	}
+	return filepath.Join(s.root, bucket), nil
+}
+
+// objectPath 计算对象在磁盘上的路径，并拒绝逃逸出 bucket 目录的 key
+func (s *LocalStore) objectPath(bucket, key string) (string, error) {
+	base, err := s.bucketPath(bucket)
+	if err != nil {
+		return "", err
+	}
+	p := filepath.Join(base, filepath.FromSlash(key))
+	if key == "" || !strings.HasPrefix(p, base+string(filepath.Separator)) {
+		return "", fmt.Errorf("storage: invalid object key %q", key)
+	}
+	return p, nil
+}
+
+func (s *LocalStore) metaPath(bucket, key string) (string, error) {
+	if _, err := s.objectPath(bucket, key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, localMetaDir, bucket, filepath.FromSlash(key)+".json"), nil
+}
+
+func (s *LocalStore) readMeta(bucket, key string) localMeta {
+	var meta localMeta
+	p, err := s.metaPath(bucket, key)
+	if err != nil {
+		return meta
+	}
+	if b, err := os.ReadFile(p); err == nil {
+		_ = json.Unmarshal(b, &meta)
+	}
+	return meta
+}
+
+// writeMeta 同样以“临时文件 + rename”的方式原子写入 sidecar
+func (s *LocalStore) writeMeta(bucket, key string, meta localMeta) error {
+	p, err := s.metaPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Join(s.root, localTmpDir), "meta-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return renameIntoPlace(tmp.Name(), p)
+}
+
+// renameIntoPlace 创建目标目录并将临时文件 rename 到 dst；目标目录在 rename 前被并发的 RemoveObject 清理时重试一次
+func renameIntoPlace(tmpPath, dst string) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if err = os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		if err = os.Rename(tmpPath, dst); !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return err
+}
+
+// pruneEmptyDirs 自 dir 起逐级向上删除空目录，直到 stop（不含）；key 前缀目录不再包含对象时随之清理
+func pruneEmptyDirs(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop+string(filepath.Separator)) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// rangeReadCloser 只读取文件的指定区间，Close 时关闭底层文件
+type rangeReadCloser struct {
+	io.Reader
+	f *os.File
+}
+
+func (r *rangeReadCloser) Close() error { return r.f.Close() }
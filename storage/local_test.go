@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLocalStore(t *testing.T, buckets ...string) (*LocalStore, string) {
+	t.Helper()
+	root := t.TempDir()
+	s, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range buckets {
+		if err := s.MakeBucket(context.Background(), b, BucketOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, root
+}
+
+func TestLocalStorePutGetRemove(t *testing.T) {
+	ctx := context.Background()
+	s, root := newTestLocalStore(t, "docs")
+	data := []byte("hello, local store")
+	info, err := s.PutObject(ctx, "docs", "a/b/c.txt", bytes.NewReader(data), int64(len(data)), PutOptions{
+		ContentType:  "text/plain",
+		UserMetadata: map[string]string{"project": "apollo"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != int64(len(data)) || info.ContentType != "text/plain" || info.ETag == "" || info.UserMetadata["project"] != "apollo" {
+		t.Fatalf("unexpected info %+v", info)
+	}
+
+	rc, err := s.GetObject(ctx, "docs", "a/b/c.txt", GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if !bytes.Equal(got, data) {
+		t.Fatalf("got %q, want %q", got, data)
+	}
+
+	if err := s.RemoveObject(ctx, "docs", "a/b/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.StatObject(ctx, "docs", "a/b/c.txt"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("stat after remove: %v", err)
+	}
+	// 删除不存在的对象不视为错误
+	if err := s.RemoveObject(ctx, "docs", "a/b/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	// 空的 key 前缀目录与 sidecar 目录随之清理，Bucket 目录保留
+	for _, dir := range []string{filepath.Join(root, "docs", "a"), filepath.Join(root, localMetaDir, "docs", "a")} {
+		if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("%s not pruned: %v", dir, err)
+		}
+	}
+	if ok, err := s.BucketExists(ctx, "docs"); err != nil || !ok {
+		t.Fatalf("bucket removed: %v %v", ok, err)
+	}
+}
+
+func TestLocalStorePruneKeepsSiblings(t *testing.T) {
+	ctx := context.Background()
+	s, root := newTestLocalStore(t, "docs")
+	for _, key := range []string{"a/x.txt", "a/b/y.txt"} {
+		if _, err := s.PutObject(ctx, "docs", key, strings.NewReader(key), -1, PutOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RemoveObject(ctx, "docs", "a/b/y.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "docs", "a", "b")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("empty directory not pruned: %v", err)
+	}
+	if _, err := s.StatObject(ctx, "docs", "a/x.txt"); err != nil {
+		t.Fatalf("sibling object removed: %v", err)
+	}
+}
+
+func TestLocalStoreRange(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestLocalStore(t, "docs")
+	if _, err := s.PutObject(ctx, "docs", "digits", strings.NewReader("0123456789"), 10, PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		start, end int64
+		want       string
+	}{
+		{0, 0, "0"},
+		{2, 5, "2345"},
+		{7, 9, "789"},
+		{8, 100, "89"},
+	}
+	for _, tt := range tests {
+		var opts GetOptions
+		opts.SetRange(tt.start, tt.end)
+		rc, err := s.GetObject(ctx, "docs", "digits", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		if string(got) != tt.want {
+			t.Errorf("range %d-%d: got %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+	var bad GetOptions
+	bad.SetRange(5, 2)
+	if _, err := s.GetObject(ctx, "docs", "digits", bad); err == nil {
+		t.Error("expected error for inverted range")
+	}
+}
+
+func TestLocalStoreSizeMismatch(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestLocalStore(t, "docs")
+	if _, err := s.PutObject(ctx, "docs", "short", strings.NewReader("abc"), 10, PutOptions{}); err == nil {
+		t.Fatal("expected size mismatch error")
+	}
+	if _, err := s.StatObject(ctx, "docs", "short"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("partial object left behind: %v", err)
+	}
+}
+
+func TestLocalStoreRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	s, root := newTestLocalStore(t, "docs")
+	keys := []string{"", "../escape.txt", "a/../../escape.txt", "../docs2/x"}
+	for _, key := range keys {
+		if _, err := s.PutObject(ctx, "docs", key, strings.NewReader("x"), 1, PutOptions{}); err == nil {
+			t.Errorf("PutObject(%q) succeeded", key)
+		}
+		if _, err := s.GetObject(ctx, "docs", key, GetOptions{}); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("GetObject(%q) error = %v, want invalid key", key, err)
+		}
+		if err := s.RemoveObject(ctx, "docs", key); err == nil {
+			t.Errorf("RemoveObject(%q) succeeded", key)
+		}
+	}
+	for _, bucket := range []string{"", ".meta", "a/b", `a\b`} {
+		if _, err := s.PutObject(ctx, bucket, "x", strings.NewReader("x"), 1, PutOptions{}); err == nil {
+			t.Errorf("PutObject into bucket %q succeeded", bucket)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "escape.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("object written outside the bucket")
+	}
+}
+
+func TestLocalStoreMultipart(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestLocalStore(t, "docs")
+	id, err := s.NewMultipartUpload(ctx, "docs", "big.bin", PutOptions{ContentType: "application/octet-stream"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, chunk := range []string{"first-", "second-", "third"} {
+		if _, err := s.PutObjectPart(ctx, "docs", "big.bin", id, i+1, strings.NewReader(chunk), int64(len(chunk))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 同一分片号重传时覆盖
+	if _, err := s.PutObjectPart(ctx, "docs", "big.bin", id, 2, strings.NewReader("SECOND-"), 7); err != nil {
+		t.Fatal(err)
+	}
+	parts, err := s.ListObjectParts(ctx, "docs", "big.bin", id)
+	if err != nil || len(parts) != 3 {
+		t.Fatalf("parts = %v, %v", parts, err)
+	}
+	if _, err := s.ListObjectParts(ctx, "docs", "other.bin", id); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("upload bound to another key: %v", err)
+	}
+	if _, err := s.CompleteMultipartUpload(ctx, "docs", "big.bin", id, parts); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := s.GetObject(ctx, "docs", "big.bin", GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(got) != "first-SECOND-third" {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+// streamPartSize 大小未知的流式上传使用的分片大小，单个对象最多 10000 个分片（约 156GiB）
+const streamPartSize = 16 << 20
+
+// MinioStore 基于 MinIO 客户端的 ObjectStore 实现
+type MinioStore struct {
+	client *minio.Client
+}
+
+// NewMinioStore 使用已初始化的 MinIO 客户端创建存储驱动
+func NewMinioStore(client *minio.Client) *MinioStore {
+	return &MinioStore{client: client}
+}
+
+// Client 返回底层 MinIO 客户端，供需要原生能力的场景使用
+func (s *MinioStore) Client() *minio.Client { return s.client }
+
+func (s *MinioStore) Driver() string { return DriverMinIO }
+
+func (s *MinioStore) BucketExists(ctx context.Context, bucket string) (bool, error) {
+	return s.client.BucketExists(ctx, bucket)
+}
+
+func (s *MinioStore) MakeBucket(ctx context.Context, bucket string, opts BucketOptions) error {
+	if err := s.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: opts.Region, ObjectLocking: opts.ObjectLocking}); err != nil {
+		return err
+	}
+	// 开启对象锁定时 MinIO 会自动启用版本控制
+	if opts.Versioning && !opts.ObjectLocking {
+		if err := s.client.EnableVersioning(ctx, bucket); err != nil {
+			return err
+		}
+	}
+	if opts.RetentionMode != "" {
+		mode := minio.RetentionMode(opts.RetentionMode)
+		validity := uint(opts.RetentionDays)
+		unit := minio.Days
+		if err := s.client.SetObjectLockConfig(ctx, bucket, &mode, &validity, &unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MinioStore) RemoveBucket(ctx context.Context, bucket string) error {
+	err := s.client.RemoveBucket(ctx, bucket)
+	if minio.ToErrorResponse(err).Code == "BucketNotEmpty" {
+		return ErrBucketNotEmpty
+	}
+	return mapMinioError(err)
+}
+
+func (s *MinioStore) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	buckets, err := s.client.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]BucketInfo, 0, len(buckets))
+	for _, b := range buckets {
+		list = append(list, BucketInfo{Name: b.Name, CreatedAt: b.CreationDate})
+	}
+	return list, nil
+}
+
+func (s *MinioStore) PutObject(ctx context.Context, bucket, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error) {
+	putOpts := putObjectOptions(opts)
+	if size < 0 {
+		// 大小未知时 SDK 默认按最大对象估算分片大小（约 512MiB 缓冲），改用固定分片
+		putOpts.PartSize = streamPartSize
+	}
+	info, err := s.client.PutObject(ctx, bucket, key, r, size, putOpts)
+	if err != nil {
+		return ObjectInfo{}, mapMinioError(err)
+	}
+	return ObjectInfo{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         info.Size,
+		ContentType:  opts.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+		UserMetadata: opts.UserMetadata,
+	}, nil
+}
+
+func (s *MinioStore) GetObject(ctx context.Context, bucket, key string, opts GetOptions) (io.ReadCloser, error) {
+	getOpts := minio.GetObjectOptions{}
+	if start, end, ok := opts.Range(); ok {
+		if err := getOpts.SetRange(start, end); err != nil {
+			return nil, err
+		}
+	}
+	obj, err := s.client.GetObject(ctx, bucket, key, getOpts)
+	if err != nil {
+		return nil, mapMinioError(err)
+	}
+	return obj, nil
+}
+
+func (s *MinioStore) StatObject(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	stat, err := s.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, mapMinioError(err)
+	}
+	return toObjectInfo(bucket, stat), nil
+}
+
+func (s *MinioStore) RemoveObject(ctx context.Context, bucket, key string) error {
+	return mapMinioError(s.client.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (s *MinioStore) RemoveObjects(ctx context.Context, bucket string, keys []string) map[string]error {
+	objects := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objects)
+		for _, k := range keys {
+			select {
+			case objects <- minio.ObjectInfo{Key: k}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	// 由 SDK 按每批最多 1000 个对象调用 S3 DeleteObjects
+	failed := map[string]error{}
+	for e := range s.client.RemoveObjects(ctx, bucket, objects, minio.RemoveObjectsOptions{}) {
+		if err := mapMinioError(e.Err); err != nil && !errors.Is(err, ErrNotFound) {
+			failed[e.ObjectName] = err
+		}
+	}
+	return failed
+}
+
+func (s *MinioStore) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, opts PutOptions) (ObjectInfo, error) {
+	dst := minio.CopyDestOptions{
+		Bucket:          dstBucket,
+		Object:          dstKey,
+		ContentType:     opts.ContentType,
+		UserMetadata:    encodeUserMetadata(opts.UserMetadata),
+		ReplaceMetadata: true,
+		UserTags:        opts.Tags,
+		ReplaceTags:     true,
+	}
+	// ComposeObject 对超过 5GiB 的对象自动改用分块复制
+	info, err := s.client.ComposeObject(ctx, dst, minio.CopySrcOptions{Bucket: srcBucket, Object: srcKey})
+	if err != nil {
+		return ObjectInfo{}, mapMinioError(err)
+	}
+	return ObjectInfo{Bucket: dstBucket, Key: dstKey, Size: info.Size, ContentType: opts.ContentType, ETag: info.ETag, LastModified: info.LastModified}, nil
+}
+
+func (s *MinioStore) ListObjects(ctx context.Context, bucket, prefix string) ([]ObjectInfo, error) {
+	list := make([]ObjectInfo, 0)
+	for obj := range s.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, mapMinioError(obj.Err)
+		}
+		list = append(list, toObjectInfo(bucket, obj))
+	}
+	return list, nil
+}
+
+func (s *MinioStore) SetObjectTags(ctx context.Context, bucket, key string, objectTags map[string]string) error {
+	if len(objectTags) == 0 {
+		return mapMinioError(s.client.RemoveObjectTagging(ctx, bucket, key, minio.RemoveObjectTaggingOptions{}))
+	}
+	t, err := tags.NewTags(objectTags, true)
+	if err != nil {
+		return err
+	}
+	return mapMinioError(s.client.PutObjectTagging(ctx, bucket, key, t, minio.PutObjectTaggingOptions{}))
+}
+
+// SetObjectMetadata S3 不支持原地修改元数据，通过复制到自身并替换元数据实现（ComposeObject 对超过 5GiB 的对象改用分块复制，
+// 分块复制不会自动保留标签，因此显式带上现有标签）。开启版本控制（含对象锁定）的 Bucket 中每次复制都会保留一个完整大小的历史版本，
+// 此时返回 ErrNotSupported，由调用方仅在数据库中保存元数据
+func (s *MinioStore) SetObjectMetadata(ctx context.Context, bucket, key string, meta map[string]string) error {
+	versioning, err := s.client.GetBucketVersioning(ctx, bucket)
+	if err != nil {
+		return mapMinioError(err)
+	}
+	if versioning.Enabled() {
+		return ErrNotSupported
+	}
+	stat, err := s.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return mapMinioError(err)
+	}
+	dst := minio.CopyDestOptions{
+		Bucket:          bucket,
+		Object:          key,
+		ContentType:     stat.ContentType,
+		UserMetadata:    encodeUserMetadata(meta),
+		ReplaceMetadata: true,
+	}
+	if stat.UserTagCount > 0 {
+		t, err := s.client.GetObjectTagging(ctx, bucket, key, minio.GetObjectTaggingOptions{})
+		if err != nil {
+			return mapMinioError(err)
+		}
+		dst.UserTags, dst.ReplaceTags = t.ToMap(), true
+	}
+	_, err = s.client.ComposeObject(ctx, dst, minio.CopySrcOptions{Bucket: bucket, Object: key, MatchETag: stat.ETag})
+	return mapMinioError(err)
+}
+
+func (s *MinioStore) PresignedGetObject(ctx context.Context, bucket, key string, expiry time.Duration) (*url.URL, error) {
+	return s.client.PresignedGetObject(ctx, bucket, key, expiry, nil)
+}
+
+func (s *MinioStore) core() minio.Core { return minio.Core{Client: s.client} }
+
+func (s *MinioStore) NewMultipartUpload(ctx context.Context, bucket, key string, opts PutOptions) (string, error) {
+	return s.core().NewMultipartUpload(ctx, bucket, key, putObjectOptions(opts))
+}
+
+func (s *MinioStore) PutObjectPart(ctx context.Context, bucket, key, uploadID string, number int, r io.Reader, size int64) (Part, error) {
+	p, err := s.core().PutObjectPart(ctx, bucket, key, uploadID, number, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return Part{}, mapMinioError(err)
+	}
+	return Part{Number: p.PartNumber, Size: p.Size, ETag: p.ETag}, nil
+}
+
+func (s *MinioStore) ListObjectParts(ctx context.Context, bucket, key, uploadID string) ([]Part, error) {
+	parts := make([]Part, 0)
+	marker := 0
+	for {
+		res, err := s.core().ListObjectParts(ctx, bucket, key, uploadID, marker, 1000)
+		if err != nil {
+			return nil, mapMinioError(err)
+		}
+		for _, p := range res.ObjectParts {
+			parts = append(parts, Part{Number: p.PartNumber, Size: p.Size, ETag: p.ETag})
+		}
+		if !res.IsTruncated {
+			break
+		}
+		marker = res.NextPartNumberMarker
+	}
+	return parts, nil
+}
+
+func (s *MinioStore) CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []Part) (ObjectInfo, error) {
+	complete := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		complete = append(complete, minio.CompletePart{PartNumber: p.Number, ETag: p.ETag})
+	}
+	if _, err := s.core().CompleteMultipartUpload(ctx, bucket, key, uploadID, complete, minio.PutObjectOptions{}); err != nil {
+		return ObjectInfo{}, mapMinioError(err)
+	}
+	return s.StatObject(ctx, bucket, key)
+}
+
+func (s *MinioStore) AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	return mapMinioError(s.core().AbortMultipartUpload(ctx, bucket, key, uploadID))
+}
+
+func putObjectOptions(opts PutOptions) minio.PutObjectOptions {
+	return minio.PutObjectOptions{ContentType: opts.ContentType, UserMetadata: encodeUserMetadata(opts.UserMetadata), UserTags: opts.Tags}
+}
+
+// encodeUserMetadata HTTP 头只能携带 ASCII，非 ASCII 的值按 RFC 2047 编码（与 S3 的建议一致）
+func encodeUserMetadata(meta map[string]string) map[string]string {
+	if len(meta) == 0 {
+		return nil
+	}
+	encoded := make(map[string]string, len(meta))
+	for k, v := range meta {
+		encoded[k] = mime.QEncoding.Encode("utf-8", v)
+	}
+	return encoded
+}
+
+func toObjectInfo(bucket string, o minio.ObjectInfo) ObjectInfo {
+	return ObjectInfo{
+		Bucket:       bucket,
+		Key:          o.Key,
+		Size:         o.Size,
+		ContentType:  o.ContentType,
+		ETag:         o.ETag,
+		LastModified: o.LastModified,
+		UserMetadata: decodeUserMetadata(o.UserMetadata),
+	}
+}
+
+func decodeUserMetadata(meta map[string]string) map[string]string {
+	if len(meta) == 0 {
+		return nil
+	}
+	dec := new(mime.WordDecoder)
+	decoded := make(map[string]string, len(meta))
+	for k, v := range meta {
+		if d, err := dec.DecodeHeader(v); err == nil {
+			v = d
+		}
+		decoded[strings.ToLower(k)] = v
+	}
+	return decoded
+}
+
+// mapMinioError 将 MinIO 的“不存在”类错误统一映射为 ErrNotFound
+func mapMinioError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchBucket", "NoSuchUpload":
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+// Package storage 定义对象存储抽象，屏蔽 MinIO / 本地磁盘等具体实现
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"time"
+)
+
+const (
+	// DriverMinIO 使用 MinIO（或兼容 S3 的服务）作为存储后端
+	DriverMinIO = "minio"
+	// DriverLocal 使用本地文件系统作为存储后端
+	DriverLocal = "local"
+)
+
+var (
+	// ErrNotFound 对象或 Bucket 不存在
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrNotSupported 当前存储驱动不支持该操作
+	ErrNotSupported = errors.New("storage: operation not supported")
+	// ErrBucketNotEmpty 删除的 Bucket 中仍有对象
+	ErrBucketNotEmpty = errors.New("storage: bucket not empty")
+)
+
+// ObjectInfo 对象元信息
+type ObjectInfo struct {
+	Bucket       string
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	// UserMetadata 用户自定义元数据（不含 x-amz-meta- 前缀）
+	UserMetadata map[string]string
+}
+
+// BucketInfo Bucket 元信息
+type BucketInfo struct {
+	Name      string
+	CreatedAt time.Time
+}
+
+// 对象锁定的默认保留模式（与 S3 一致）
+const (
+	RetentionGovernance = "GOVERNANCE"
+	RetentionCompliance = "COMPLIANCE"
+)
+
+// BucketOptions 创建 Bucket 的选项；零值表示使用存储后端默认配置
+type BucketOptions struct {
+	Region        string
+	Versioning    bool
+	ObjectLocking bool
+	// RetentionMode / RetentionDays 对象锁定的默认保留策略，需开启 ObjectLocking
+	RetentionMode string
+	RetentionDays int
+}
+
+// PutOptions 上传对象选项
+type PutOptions struct {
+	ContentType string
+	// UserMetadata 写入对象的用户自定义元数据（S3 x-amz-meta-*）
+	UserMetadata map[string]string
+	// Tags 写入对象的标签（S3 对象标签）
+	Tags map[string]string
+}
+
+// GetOptions 读取对象选项，支持 Range 读取
+type GetOptions struct {
+	start, end int64
+	ranged     bool
+}
+
+// SetRange 设置读取范围 [start, end]（闭区间，与 HTTP Range 一致）
+func (o *GetOptions) SetRange(start, end int64) {
+	o.start, o.end, o.ranged = start, end, true
+}
+
+// Range 返回读取范围；ok 为 false 表示读取整个对象
+func (o GetOptions) Range() (start, end int64, ok bool) {
+	return o.start, o.end, o.ranged
+}
+
+// MinPartSize 分块上传中除最后一个分片外每个分片的最小字节数（与 S3 限制一致）
+const MinPartSize = 5 << 20
+
+// Part 分块上传中已上传的分片
+type Part struct {
+	Number int    `json:"index"`
+	Size   int64  `json:"size"`
+	ETag   string `json:"etag"`
+}
+
+// ObjectStore 对象存储统一接口，由 MinIO、本地磁盘等驱动实现
+type ObjectStore interface {
+	// Driver 返回驱动名称（minio / local）
+	Driver() string
+
+	BucketExists(ctx context.Context, bucket string) (bool, error)
+	MakeBucket(ctx context.Context, bucket string, opts BucketOptions) error
+	// RemoveBucket 删除空 Bucket，仍有对象时返回 ErrBucketNotEmpty
+	RemoveBucket(ctx context.Context, bucket string) error
+	ListBuckets(ctx context.Context) ([]BucketInfo, error)
+
+	PutObject(ctx context.Context, bucket, key string, r io.Reader, size int64, opts PutOptions) (ObjectInfo, error)
+	GetObject(ctx context.Context, bucket, key string, opts GetOptions) (io.ReadCloser, error)
+	StatObject(ctx context.Context, bucket, key string) (ObjectInfo, error)
+	RemoveObject(ctx context.Context, bucket, key string) error
+	// RemoveObjects 批量删除同一 Bucket 中的对象，返回删除失败的对象及原因；不存在的对象不视为失败
+	RemoveObjects(ctx context.Context, bucket string, keys []string) map[string]error
+	// CopyObject 在服务端复制对象（可跨 Bucket），目标对象的 Content-Type、用户元数据与标签以 opts 为准
+	CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, opts PutOptions) (ObjectInfo, error)
+	ListObjects(ctx context.Context, bucket, prefix string) ([]ObjectInfo, error)
+	// SetObjectTags 替换对象标签，tags 为空时清除
+	SetObjectTags(ctx context.Context, bucket, key string, tags map[string]string) error
+	// SetObjectMetadata 替换对象的用户自定义元数据，保留 Content-Type 与标签；
+	// 替换需要重写对象且会留下历史版本时（如开启版本控制的 Bucket）返回 ErrNotSupported
+	SetObjectMetadata(ctx context.Context, bucket, key string, meta map[string]string) error
+
+	// PresignedGetObject 生成直连下载链接；不支持的驱动返回 ErrNotSupported
+	PresignedGetObject(ctx context.Context, bucket, key string, expiry time.Duration) (*url.URL, error)
+
+	// 原生分块上传：分片直接写入存储后端，完成时由后端拼接为目标对象
+	NewMultipartUpload(ctx context.Context, bucket, key string, opts PutOptions) (string, error)
+	PutObjectPart(ctx context.Context, bucket, key, uploadID string, number int, r io.Reader, size int64) (Part, error)
+	ListObjectParts(ctx context.Context, bucket, key, uploadID string) ([]Part, error)
+	CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []Part) (ObjectInfo, error)
+	AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error
+}
+
+// EnsureBucket 确保 bucket 存在，不存在时创建；created 表示本次调用创建了该 bucket
+func EnsureBucket(ctx context.Context, s ObjectStore, bucket string) (created bool, err error) {
+	exists, err := s.BucketExists(ctx, bucket)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+	if err := s.MakeBucket(ctx, bucket, BucketOptions{}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -10,6 +10,31 @@ export const api = axios.create({
   },
 })
 
+// 访问令牌（JWT）：优先使用 localStorage 中的 token，其次使用构建时的 VITE_API_TOKEN
+const TOKEN_KEY = 'token'
+
+export function getToken(): string | null {
+  return localStorage.getItem(TOKEN_KEY) || import.meta.env.VITE_API_TOKEN || null
+}
+
+export function setToken(token: string | null) {
+  if (token) localStorage.setItem(TOKEN_KEY, token)
+  else localStorage.removeItem(TOKEN_KEY)
+}
+
+api.interceptors.request.use((config) => {
+  const token = getToken()
+  if (token) config.headers.Authorization = `Bearer ${token}`
+  return config
+})
+
+// 浏览器直接打开的下载链接无法携带请求头，通过 access_token 查询参数传递令牌
+export function withAccessToken(url: string) {
+  const token = getToken()
+  if (!url || !token) return url
+  return `${url}${url.includes('?') ? '&' : '?'}access_token=${encodeURIComponent(token)}`
+}
+
 // 简单的健康检查
 export async function getHealth() {
   const { data } = await api.get('/healthz')
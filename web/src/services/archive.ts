@@ -50,16 +50,29 @@ export async function uploadArchiveChunk(payload: {
   return data
 }
 
-// 前端分片调度：将 File 切片并按序上传，最后一个分片返回后端处理结果（uploaded/ skipped 列表）
+// 显式完成压缩包分块上传：POST /api/v1/files/archive/multipart/complete
+export async function completeArchiveChunkUpload(uploadId: string, totalChunks: number) {
+  const form = new FormData()
+  form.append('upload_id', uploadId)
+  form.append('total_chunks', String(totalChunks))
+  const { data } = await api.post('/api/v1/files/archive/multipart/complete', form, {
+    headers: { 'Content-Type': 'multipart/form-data' },
+  })
+  return data
+}
+
+// 前端分片调度：将 File 切片并发上传，全部分片到齐后后端返回处理结果（uploaded/ skipped 列表）
 export async function uploadArchiveInChunks(opts: {
   file: File
   bucket: string
   chunkSize?: number // bytes, default ~5MB
+  concurrency?: number // 并发上传的分片数，默认 3
   onProgress?: (p: { loadedBytes: number; totalBytes: number; percent: number; currentChunk: number; totalChunks: number }) => void
   onChunkUploaded?: (i: number) => void
 }) {
   const { file, bucket } = opts
   const chunkSize = opts.chunkSize ?? 5 * 1024 * 1024
+  const concurrency = Math.max(1, opts.concurrency ?? 3)
   const totalBytes = file.size
   const totalChunks = Math.ceil(totalBytes / chunkSize)
 
@@ -68,34 +81,33 @@ export async function uploadArchiveInChunks(opts: {
   const uploadId = initResp?.data?.upload_id || initResp?.data?.uploadId || initResp?.upload_id
   if (!uploadId) throw new Error('初始化分块上传失败：缺少 upload_id')
 
+  // 2) 并发上传分片
+  const pending = Array.from({ length: totalChunks }, (_, i) => i + 1)
   let loadedBytes = 0
+  let completed: any = null
+  const worker = async () => {
+    while (pending.length > 0) {
+      const chunkIndex = pending.shift()!
+      const start = (chunkIndex - 1) * chunkSize
+      const end = Math.min(start + chunkSize, totalBytes)
+      const resp = await uploadArchiveChunk({
+        upload_id: uploadId,
+        chunk_index: chunkIndex,
+        total_chunks: totalChunks,
+        chunk: file.slice(start, end),
+        bucket,
+        filename: file.name,
+      })
+      if (resp?.data?.uploaded) completed = resp
 
-  for (let i = 0; i < totalChunks; i++) {
-    const start = i * chunkSize
-    const end = Math.min(start + chunkSize, totalBytes)
-    const blob = file.slice(start, end)
-    const chunkIndex = i + 1
-
-    const resp = await uploadArchiveChunk({
-      upload_id: uploadId,
-      chunk_index: chunkIndex,
-      total_chunks: totalChunks,
-      chunk: blob,
-      bucket,
-      filename: file.name,
-    })
-
-    loadedBytes = end
-    const percent = Math.round((loadedBytes / totalBytes) * 100)
-    opts.onProgress?.({ loadedBytes, totalBytes, percent, currentChunk: chunkIndex, totalChunks })
-    opts.onChunkUploaded?.(chunkIndex)
-
-    // 最后一个分片返回后端处理结果
-    if (chunkIndex === totalChunks) {
-      return resp
+      loadedBytes += end - start
+      const percent = Math.round((loadedBytes / totalBytes) * 100)
+      opts.onProgress?.({ loadedBytes, totalBytes, percent, currentChunk: chunkIndex, totalChunks })
+      opts.onChunkUploaded?.(chunkIndex)
     }
   }
+  await Promise.all(Array.from({ length: Math.min(concurrency, Math.max(totalChunks, 1)) }, worker))
 
-  // 正常不会走到这里
-  return { code: 0, msg: 'upload finished', data: null }
-}
\ No newline at end of file
+  // 3) 自动合并结果未返回时显式完成
+  return completed ?? (await completeArchiveChunkUpload(uploadId, totalChunks))
+}
@@ -18,12 +18,15 @@ export async function uploadChunk(payload: {
   chunk: Blob
   bucket?: string
   filename?: string
+  // 分片 SHA-256（hex），服务端写入前校验
+  checksum?: string
 }) {
   const form = new FormData()
   form.append('upload_id', payload.upload_id)
   form.append('chunk_index', String(payload.chunk_index))
   form.append('total_chunks', String(payload.total_chunks))
   form.append('chunk', payload.chunk)
+  if (payload.checksum) form.append('checksum', payload.checksum)
   if (payload.bucket) form.append('bucket', payload.bucket)
   if (payload.filename) form.append('filename', payload.filename)
   const { data } = await api.post('/api/v1/files/multipart/chunk', form, {
@@ -34,51 +37,97 @@ export async function uploadChunk(payload: {
   return data
 }
 
+// 计算 SHA-256（hex）；非安全上下文（无 crypto.subtle）时返回 undefined，跳过校验
+export async function sha256Hex(blob: Blob): Promise<string | undefined> {
+  if (!globalThis.crypto?.subtle) return undefined
+  const digest = await globalThis.crypto.subtle.digest('SHA-256', await blob.arrayBuffer())
+  return Array.from(new Uint8Array(digest), (b) => b.toString(16).padStart(2, '0')).join('')
+}
+
+// 查询会话已接收的分片，用于断点续传：GET /api/v1/files/multipart/:upload_id
+export async function getChunkUploadStatus(uploadId: string) {
+  const { data } = await api.get(`/api/v1/files/multipart/${encodeURIComponent(uploadId)}`)
+  return data
+}
+
+// 取消分块上传（中止存储后端的分块上传）：DELETE /api/v1/files/multipart/:upload_id
+export async function abortChunkUpload(uploadId: string) {
+  const { data } = await api.delete(`/api/v1/files/multipart/${encodeURIComponent(uploadId)}`)
+  return data
+}
+
+// 显式完成分块上传：POST /api/v1/files/multipart/complete
+export async function completeChunkUpload(uploadId: string, totalChunks: number) {
+  const form = new FormData()
+  form.append('upload_id', uploadId)
+  form.append('total_chunks', String(totalChunks))
+  const { data } = await api.post('/api/v1/files/multipart/complete', form, {
+    headers: { 'Content-Type': 'multipart/form-data' },
+  })
+  return data
+}
+
 export async function uploadLargeFileInChunks(opts: {
   file: File
   bucket: string
-  chunkSize?: number // bytes, default ~5MB
+  uploadId?: string // 传入已有会话ID时，跳过服务端已接收的分片（断点续传）
+  chunkSize?: number // bytes, default 5MiB（除最后一片外不得小于 5MiB）
+  concurrency?: number // 并发上传的分片数，默认 3
   onProgress?: (p: { loadedBytes: number; totalBytes: number; percent: number; currentChunk: number; totalChunks: number }) => void
   onChunkUploaded?: (i: number) => void
 }) {
   const { file, bucket } = opts
   const chunkSize = opts.chunkSize ?? 5 * 1024 * 1024
+  const concurrency = Math.max(1, opts.concurrency ?? 3)
   const totalBytes = file.size
   const totalChunks = Math.ceil(totalBytes / chunkSize)
 
-  // 1) init session
-  const initResp = await initChunkUpload(bucket, file.name, file.type)
-  const uploadId = initResp?.data?.upload_id || initResp?.data?.uploadId || initResp?.upload_id
+  // 1) init session（或复用已有会话并查询已接收分片）
+  let uploadId = opts.uploadId
+  const received = new Set<number>()
+  if (uploadId) {
+    const status = await getChunkUploadStatus(uploadId)
+    for (const p of status?.data?.received ?? []) received.add(p.index)
+  } else {
+    const initResp = await initChunkUpload(bucket, file.name, file.type)
+    uploadId = initResp?.data?.upload_id || initResp?.data?.uploadId || initResp?.upload_id
+  }
   if (!uploadId) throw new Error('初始化分块上传失败：缺少 upload_id')
+  const sessionId = uploadId
 
+  // 2) 并发上传缺失的分片；服务端在全部分片到齐时自动合并并返回文件记录
+  const pending: number[] = []
   let loadedBytes = 0
+  for (let i = 1; i <= totalChunks; i++) {
+    if (received.has(i)) loadedBytes += Math.min(chunkSize, totalBytes - (i - 1) * chunkSize)
+    else pending.push(i)
+  }
+  let completed: any = null
+  const worker = async () => {
+    while (pending.length > 0) {
+      const chunkIndex = pending.shift()!
+      const start = (chunkIndex - 1) * chunkSize
+      const end = Math.min(start + chunkSize, totalBytes)
+      const chunk = file.slice(start, end)
+      const resp = await uploadChunk({
+        upload_id: sessionId,
+        chunk_index: chunkIndex,
+        total_chunks: totalChunks,
+        chunk,
+        bucket,
+        filename: file.name,
+        checksum: await sha256Hex(chunk),
+      })
+      if (resp?.msg === 'upload completed') completed = resp
 
-  for (let i = 0; i < totalChunks; i++) {
-    const start = i * chunkSize
-    const end = Math.min(start + chunkSize, totalBytes)
-    const blob = file.slice(start, end)
-
-    const chunkIndex = i + 1
-    const resp = await uploadChunk({
-      upload_id: uploadId,
-      chunk_index: chunkIndex,
-      total_chunks: totalChunks,
-      chunk: blob,
-      bucket,
-      filename: file.name,
-    })
-
-    loadedBytes = end
-    const percent = Math.round((loadedBytes / totalBytes) * 100)
-    opts.onProgress?.({ loadedBytes, totalBytes, percent, currentChunk: chunkIndex, totalChunks })
-    opts.onChunkUploaded?.(chunkIndex)
-
-    // 当最后一个分片上传完毕，后端会返回完整文件记录
-    if (chunkIndex === totalChunks) {
-      return resp
+      loadedBytes += end - start
+      const percent = Math.round((loadedBytes / totalBytes) * 100)
+      opts.onProgress?.({ loadedBytes, totalBytes, percent, currentChunk: chunkIndex, totalChunks })
+      opts.onChunkUploaded?.(chunkIndex)
     }
   }
+  await Promise.all(Array.from({ length: Math.min(concurrency, Math.max(pending.length, 1)) }, worker))
 
-  // 正常不会走到这里
-  return { code: 0, msg: 'upload finished', data: null }
-}
\ No newline at end of file
+  // 3) 若自动合并未在本次请求中返回结果（例如续传时分片已全部存在），显式完成上传
+  return completed ?? (await completeChunkUpload(sessionId, totalChunks))
+}